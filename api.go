@@ -95,9 +95,20 @@ func ParseFile(f FileInput, opts ...Option) (prog *Prog, _ error) {
 func parseWithOpts(inputs <-chan string, name string, opts []Option) (*Prog, error) {
 	cf := makeConfig(opts)
 
-	prog, pstats, err := parse(inputs, name, writers{cf.output, cf.logw})
+	prog, pstats, err := parse(
+		inputs, name, writers{cf.output, cf.logw},
+		parseConfig{
+			strictScope:   cf.strictScope,
+			maxLocals:     cf.maxStack,
+			maxBlockDepth: cf.maxBlockDepth,
+		},
+	)
+	if err == nil {
+		prog.optimize(cf.optimize)
+		prog.setMeta(cf.meta)
+	}
 	if err == nil && cf.disasm {
-		prog.disasm()
+		prog.disasm(cf.output)
 	}
 	if cf.stats {
 		pstats.print(cf.output)
@@ -109,9 +120,22 @@ func LoadProg(r io.Reader, name string, opts ...Option) (*Prog, error) {
 	cf := makeConfig(opts)
 
 	prog := newProg(name, writers{cf.output, cf.logw})
-	err := prog.Load(r)
+	err := prog.load(r, cf.verifyKeys)
+	if err == nil && cf.disasm {
+		prog.disasm(cf.output)
+	}
+	return prog, err
+}
+
+// Assemble makes the Prog from its textual assembly, as written by
+// [Prog.WriteAsm]. The code is verified like the one loaded with LoadProg.
+func Assemble(input []byte, name string, opts ...Option) (*Prog, error) {
+	cf := makeConfig(opts)
+
+	prog := newProg(name, writers{cf.output, cf.logw})
+	err := assemble(input, prog)
 	if err == nil && cf.disasm {
-		prog.disasm()
+		prog.disasm(cf.output)
 	}
 	return prog, err
 }
@@ -120,7 +144,12 @@ func LoadProg(r io.Reader, name string, opts ...Option) (*Prog, error) {
 func Execute(prog *Prog, opts ...Option) (result []Block, binding Binding, err error) {
 	cf := makeConfig(opts)
 
-	result, binding, xstats, err := execute(prog, vmConfig{cf.trace})
+	result, binding, xstats, err := execute(prog, vmConfig{
+		trace:         cf.trace,
+		maxStack:      cf.maxStack,
+		maxBlockDepth: cf.maxBlockDepth,
+		maxSteps:      cf.maxSteps,
+	})
 	if cf.stats {
 		xstats.print(cf.output)
 	}
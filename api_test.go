@@ -4,11 +4,35 @@ package bcl_test
 
 import (
 	"errors"
+	"io"
+	"math/big"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/wkhere/bcl"
 )
 
+func TestDecimalField(t *testing.T) {
+	res, _, err := bcl.Interpret([]byte(`def b { price = 12.50d }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := res[0].Fields["price"].(bcl.Decimal)
+	if !ok {
+		t.Fatalf("field type mismatch: %T", res[0].Fields["price"])
+	}
+	if text, _ := d.MarshalText(); d.String() != "12.50" || string(text) != "12.50" {
+		t.Errorf("decimal text mismatch: %s, %s", d, text)
+	}
+	if d.Rat().Cmp(big.NewRat(25, 2)) != 0 {
+		t.Errorf("decimal value mismatch: %s", d.Rat())
+	}
+	if s := (bcl.Decimal{}).String(); s != "0" {
+		t.Errorf("zero decimal mismatch: %s", s)
+	}
+}
+
 func TestParseFileErr(t *testing.T) {
 	err1 := errors.New("test err")
 	r := &errfile{err: err1}
@@ -18,6 +42,227 @@ func TestParseFileErr(t *testing.T) {
 	}
 }
 
+func TestStrictScope(t *testing.T) {
+	var tab = []struct {
+		input     string
+		errWanted bool
+	}{
+		{`var x=1; def b {var.x=2}`, false},
+		{`var x=1; def b {field.x=2}`, false},
+		{`var x=1; def b {x=2}`, true},
+		{`var x=1; def b {eval x=2}`, true},
+		{`var x=1; def b {print x=2}`, true},
+		{`var x=1; def b {y=x+1}`, false},
+		{`def b {var x; x=2}`, false},
+		{`def b {var x; def c {x=2}}`, true},
+		{`var x; eval x=2`, false},
+	}
+
+	for i, tc := range tab {
+		_, err := bcl.Parse(
+			[]byte(tc.input), "input",
+			bcl.OptStrictScope(true), bcl.OptLogger(io.Discard),
+		)
+		switch {
+		case err != nil && !tc.errWanted:
+			t.Errorf("tc#%d: unexpected error: %v", i, err)
+		case err == nil && tc.errWanted:
+			t.Errorf("tc#%d: no error when expecting one", i)
+		}
+	}
+}
+
+func TestStrictScopeErrMsg(t *testing.T) {
+	log := new(strings.Builder)
+	_, err := bcl.Parse(
+		[]byte(`var x=1; def b {x=2}`), "input",
+		bcl.OptStrictScope(true), bcl.OptLogger(log),
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if s := log.String(); !strings.Contains(s, "ambiguous assignment") {
+		t.Errorf("error mismatch, have: %s", s)
+	}
+}
+
+func TestConstAssignErrMsg(t *testing.T) {
+	log := new(strings.Builder)
+	_, err := bcl.Parse(
+		[]byte(`const x = 1; def b {x = 2; y = 3}`), "input", bcl.OptLogger(log),
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	want := "line 1:22: error at 'x': cannot assign to a constant\n"
+	if s := log.String(); s != want {
+		t.Errorf("error mismatch\nhave: %s\nwant: %s", s, want)
+	}
+}
+
+func TestLexErrInLiteral(t *testing.T) {
+	var tab = []struct {
+		input, want string
+	}{
+		{`print [1, "abc`, "line 1:15: error: unterminated quoted string\n"},
+		{`print [1, [2, "abc`, "line 1:19: error: unterminated quoted string\n"},
+		{`print {a: 1, b: "abc`, "line 1:21: error: unterminated quoted string\n" +
+			"line 1:21: error: expected expression\n",
+		},
+	}
+
+	for i, tc := range tab {
+		log := new(strings.Builder)
+		_, err := bcl.Parse([]byte(tc.input), "input", bcl.OptLogger(log))
+		if err == nil {
+			t.Errorf("tc#%d: no error when expecting one", i)
+		}
+		if s := log.String(); s != tc.want {
+			t.Errorf("tc#%d: error mismatch\nhave: %s\nwant: %s", i, s, tc.want)
+		}
+	}
+}
+
+func TestLongJumps(t *testing.T) {
+	const n = 12000 // makes the code jumped over longer than 64KiB
+	var (
+		sum   = strings.Repeat("a + ", n) + "a"
+		evals = strings.Repeat("eval a = a + 1\n", n)
+	)
+	var tab = []struct {
+		input, output string
+	}{
+		{"var a = 1; print a and (" + sum + ")", "12001"},
+		{"var a = 0; print a or (" + sum + ")", "0"},
+		{"var a = 1; print nil ?? (" + sum + ")", "12001"},
+		{"var a = 0; if a == 0 {\n" + evals + "} else { print 0 }\nprint a", "12000"},
+		{"var a = 0; for i in range(3) {\n" + evals + "}\nprint a", "36000"},
+	}
+
+	for i, tc := range tab {
+		for _, level := range []int{0, 2} {
+			out := new(strings.Builder)
+			_, _, err := bcl.Interpret([]byte(tc.input),
+				bcl.OptOptimize(level), bcl.OptOutput(out),
+			)
+			if err != nil {
+				t.Errorf("tc#%d -O%d: unexpected error: %v", i, level, err)
+				continue
+			}
+			if s := strings.TrimSpace(out.String()); s != tc.output {
+				t.Errorf("tc#%d -O%d: mismatch, have: %s, want: %s",
+					i, level, s, tc.output,
+				)
+			}
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	nest := func(n int) string {
+		return strings.Repeat("def b {", n) + strings.Repeat("}", n)
+	}
+	var tab = []struct {
+		input    string
+		opts     []bcl.Option
+		errMatch string
+	}{
+		{nest(100), nil, ""},
+		{nest(4), []bcl.Option{bcl.OptMaxBlockDepth(4)}, ""},
+		{nest(5), []bcl.Option{bcl.OptMaxBlockDepth(4)},
+			"line 1:36: error at '{': blocks nested too deeply (max 4)",
+		},
+		{"var a; var b", []bcl.Option{bcl.OptMaxStack(2)}, ""},
+		{"var a; var b; var c", []bcl.Option{bcl.OptMaxStack(2)},
+			"line 1:20: error at 'c': too many local variables (max 2)",
+		},
+		{"print [1, 2, 3]", []bcl.Option{bcl.OptMaxStack(2)},
+			"runtime error: line 1:15: stack overflow (max 2)",
+		},
+		{"print [" + strings.Repeat("1, ", 1<<16+1) + "]", nil,
+			"stack overflow (max 65536)",
+		},
+		{nest(100), []bcl.Option{bcl.OptMaxBlockDepth(-1)}, ""},
+		{"var a; print [1, 2, 3]", []bcl.Option{bcl.OptMaxStack(-1)}, ""},
+		{"var a; print [1, 2, 3]", []bcl.Option{bcl.OptMaxStack(0)}, ""},
+		{"for i in range(3) {}", []bcl.Option{bcl.OptMaxSteps(100)}, ""},
+		{"for i in range(1 << 40) {}", []bcl.Option{bcl.OptMaxSteps(100)},
+			"too many steps (max 100)",
+		},
+	}
+
+	for i, tc := range tab {
+		log := new(strings.Builder)
+		opts := append(tc.opts, bcl.OptOutput(io.Discard), bcl.OptLogger(log))
+		_, _, err := bcl.Interpret([]byte(tc.input), opts...)
+
+		switch {
+		case err != nil && tc.errMatch == "":
+			t.Errorf("tc#%d: unexpected error: %v %s", i, err, log)
+		case err == nil && tc.errMatch != "":
+			t.Errorf("tc#%d: no error when expecting one", i)
+		case err != nil:
+			if s := err.Error() + log.String(); !strings.Contains(s, tc.errMatch) {
+				t.Errorf("tc#%d: error mismatch\nhave: %s\nwant matching: %s",
+					i, s, tc.errMatch,
+				)
+			}
+		}
+	}
+}
+
+func TestBlockDepthRuntime(t *testing.T) {
+	prog, err := bcl.Parse([]byte("def a {\n def b {\n def c {}}}"), "input")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, _, err = bcl.Execute(prog, bcl.OptMaxBlockDepth(2))
+	if err == nil {
+		t.Fatal("expected runtime error")
+	}
+	want := "runtime error: line 3:9: blocks nested too deeply (max 2)"
+	if s := err.Error(); s != want {
+		t.Errorf("error mismatch\nhave: %s\nwant: %s", s, want)
+	}
+}
+
+func TestMultilineStringsByteChunks(t *testing.T) {
+	const input = "def b {\n" +
+		"\tx = <<-EOF\n\t\tfoo\n\t\t  bar\n\tEOF\n" +
+		"\ty = `raw\nstring`\n" +
+		"}\n"
+
+	res, _, err := bcl.InterpretFile(onebytefile(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if x := res[0].Fields["x"]; x != "foo\n  bar\n" {
+		t.Errorf("heredoc mismatch, have: %q", x)
+	}
+	if y := res[0].Fields["y"]; y != "raw\nstring" {
+		t.Errorf("raw string mismatch, have: %q", y)
+	}
+
+	_, _, err = bcl.InterpretFile(onebytefile(input + "print 1+true\n"))
+	if err == nil {
+		t.Fatal("expected runtime error")
+	}
+	if s := err.Error(); !strings.Contains(s, "line 9:13:") {
+		t.Errorf("error position mismatch, have: %s", s)
+	}
+}
+
+// onebytefile makes the input to be read in one-byte chunks.
+func onebytefile(s string) bcl.FileInput {
+	return &readfile{iotest.OneByteReader(strings.NewReader(s))}
+}
+
+type readfile struct{ io.Reader }
+
+func (*readfile) Close() error { return nil }
+func (*readfile) Name() string { return "<reader>" }
+
 type errfile struct {
 	first bool
 	err   error
@@ -0,0 +1,571 @@
+package bcl
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The assembly is a textual form of the Prog, which round-trips;
+// the format is described in the README.
+
+// WriteAsm writes the Prog in the textual assembly, which can be turned back
+// into the Prog with [Assemble].
+func (p *Prog) WriteAsm(dest io.Writer) error {
+	code, err := decodeCode(p, nil)
+	if err != nil {
+		return err
+	}
+	labels := map[*instr]bool{}
+	for _, in := range code {
+		if in.target != nil {
+			labels[in.target] = true
+		}
+	}
+
+	w := bufio.NewWriter(dest)
+	h := p.header
+	fmt.Fprintf(w, "; bcl assembly, bytecode %d.%d\n", h.version[0], h.version[1])
+	fmt.Fprintf(w, ".name %s\n", strconv.Quote(p.name))
+	fmt.Fprintf(w, ".compiler %s\n", strconv.Quote(h.compiler))
+	fmt.Fprintf(w, ".created %s\n", h.created.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, ".source %x\n", h.source)
+
+	keys := make([]string, 0, len(h.meta))
+	for k := range h.meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, ".meta %s %s\n", strconv.Quote(k), strconv.Quote(h.meta[k]))
+	}
+
+	var lfs []int
+	if p.linePos != nil {
+		lfs = p.linePos.lfs
+	}
+	const linesPerRow = 16
+	for i, lf := range lfs {
+		switch {
+		case i%linesPerRow == 0:
+			fmt.Fprintf(w, ".lines %d", lf)
+		default:
+			fmt.Fprintf(w, " %d", lf)
+		}
+		if i%linesPerRow == linesPerRow-1 || i == len(lfs)-1 {
+			fmt.Fprintln(w)
+		}
+	}
+
+	fmt.Fprintln(w)
+	for i, v := range p.constants {
+		fmt.Fprintf(w, ".const %d %s\n", i, constLiteral(v))
+	}
+
+	fmt.Fprintln(w)
+	prevPos := -1
+	for _, in := range code {
+		if labels[in] {
+			fmt.Fprintf(w, "L%04d:\n", in.offset)
+		}
+
+		at := ""
+		if in.pos >= 0 && in.pos != prevPos {
+			at = "@" + p.linePos.format(in.pos)
+			prevPos = in.pos
+		}
+
+		args, comment := asmArgs(p, in.raw, in)
+		line := fmt.Sprintf("        %-8s %-10s %s", at, in.raw, args)
+		if comment != "" {
+			line = fmt.Sprintf("%-40s ; %s", line, comment)
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	return w.Flush()
+}
+
+// asmArgs gives the operands of the instruction and the comment
+// showing the constants or the type they refer to.
+func asmArgs(p *Prog, op opcode, in *instr) (args, comment string) {
+	var aa, cc []string
+	k := 0
+	for _, kind := range operands[op] {
+		switch kind {
+		case argByte:
+			switch op {
+			case opBIND:
+				aa = append(aa, fmt.Sprintf("%#02x", in.args[k]))
+			case opCONV:
+				aa = append(aa, strconv.Itoa(in.args[k]))
+				cc = append(cc, "@"+convTypeName(typecode(in.args[k])))
+			default:
+				aa = append(aa, strconv.Itoa(in.args[k]))
+			}
+			k++
+		case argNum:
+			aa = append(aa, strconv.Itoa(in.args[k]))
+			k++
+		case argConst:
+			aa = append(aa, strconv.Itoa(in.args[k]))
+			cc = append(cc, constValue(p.constants[in.args[k]]))
+			k++
+		case argConsts:
+			n := in.args[k]
+			idx := make([]string, n)
+			for i, x := range in.args[k+1 : k+1+n] {
+				idx[i] = strconv.Itoa(x)
+				cc = append(cc, constValue(p.constants[x]))
+			}
+			aa = append(aa, "["+strings.Join(idx, " ")+"]")
+			k += 1 + n
+		case argJump, argLoop, argJumpW, argLoopW:
+			aa = append(aa, fmt.Sprintf("L%04d", in.target.offset))
+		}
+	}
+	return strings.Join(aa, " "), strings.Join(cc, " ")
+}
+
+// constLiteral gives the constant with its type, as in the .const directive.
+func constLiteral(v value) string {
+	if v == nil {
+		return "nil"
+	}
+	return vtype(v) + " " + constValue(v)
+}
+
+func constValue(v value) string {
+	switch x := v.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case string:
+		return strconv.Quote(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func parseConst(typ string, args []string) (value, error) {
+	if typ == "nil" {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("unexpected %s", args[0])
+		}
+		return nil, nil
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected one %s value", typ)
+	}
+	s := args[0]
+
+	switch typ {
+	case "int":
+		x, err := strconv.ParseInt(s, 10, 64)
+		return int(x), err
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	case "string":
+		return strconv.Unquote(s)
+	case "bool":
+		return strconv.ParseBool(s)
+	case "duration":
+		return time.ParseDuration(s)
+	case "time":
+		return time.Parse(time.RFC3339Nano, s)
+	case "decimal":
+		return parseDecimal(s)
+	}
+	return nil, fmt.Errorf("unknown type %s", typ)
+}
+
+// asmLineCol is a source position as written in the assembly.
+type asmLineCol struct{ line, col int }
+
+// assembler keeps the state of assembling, line by line.
+type assembler struct {
+	prog *Prog
+	line int
+
+	code      []*instr
+	lineCols  []*asmLineCol // of each instruction, nil when not given
+	labels    map[string]int
+	jumps     map[*instr]string
+	jumpLines map[*instr]int
+	hasPos    bool
+}
+
+func assemble(input []byte, prog *Prog) error {
+	prog.header = progHeader{
+		version:  [2]uint8{bytecodeMajor, bytecodeMinor},
+		compiler: compilerVersion(),
+		created:  time.Unix(time.Now().Unix(), 0),
+		source:   sha256.Sum256(input),
+	}
+	prog.linePos = newLineCalc()
+	prog.constants = []value{}
+
+	a := &assembler{
+		prog:      prog,
+		labels:    map[string]int{},
+		jumps:     map[*instr]string{},
+		jumpLines: map[*instr]int{},
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(input))
+	sc.Buffer(nil, maxStringLen)
+	for sc.Scan() {
+		a.line++
+		ff, err := asmFields(sc.Text())
+		if err == nil && len(ff) > 0 {
+			err = a.assembleLine(ff)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", a.line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if err := a.finish(); err != nil {
+		return err
+	}
+	return prog.verify()
+}
+
+func (a *assembler) assembleLine(ff []string) error {
+	if strings.HasPrefix(ff[0], ".") {
+		return a.directive(ff[0], ff[1:])
+	}
+
+	for len(ff) > 0 && isAsmLabel(ff[0]) {
+		name := strings.TrimSuffix(ff[0], ":")
+		if _, ok := a.labels[name]; ok {
+			return fmt.Errorf("label %s already defined", name)
+		}
+		a.labels[name] = len(a.code)
+		ff = ff[1:]
+	}
+	if len(ff) == 0 {
+		return nil
+	}
+
+	var lc *asmLineCol
+	if strings.HasPrefix(ff[0], "@") {
+		l, c, ok := strings.Cut(ff[0][1:], ":")
+		line, err1 := strconv.Atoi(l)
+		col, err2 := strconv.Atoi(c)
+		if !ok || err1 != nil || err2 != nil || line < 1 || col < 1 {
+			return fmt.Errorf("invalid position %s", ff[0])
+		}
+		lc = &asmLineCol{line, col}
+		a.hasPos = true
+		ff = ff[1:]
+		if len(ff) == 0 {
+			return fmt.Errorf("missing instruction after the position")
+		}
+	}
+
+	op, ok := opcodesByName[ff[0]]
+	if !ok {
+		return fmt.Errorf("unknown instruction %s", ff[0])
+	}
+	in := &instr{op: op}
+	if err := a.operands(in, ff[1:]); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.code = append(a.code, in)
+	a.lineCols = append(a.lineCols, lc)
+	return nil
+}
+
+func (a *assembler) operands(in *instr, ff []string) error {
+	next := func() (string, error) {
+		if len(ff) == 0 {
+			return "", fmt.Errorf("missing operand")
+		}
+		s := ff[0]
+		ff = ff[1:]
+		return s, nil
+	}
+	num := func(bits int) (int, error) {
+		s, err := next()
+		if err != nil {
+			return 0, err
+		}
+		x, err := strconv.ParseUint(s, 0, bits)
+		if err != nil {
+			return 0, fmt.Errorf("invalid operand %s", s)
+		}
+		return int(x), nil
+	}
+
+	for _, kind := range operands[in.op] {
+		switch kind {
+		case argByte:
+			x, err := num(8)
+			if err != nil {
+				return err
+			}
+			in.args = append(in.args, x)
+
+		case argNum, argConst:
+			x, err := num(31)
+			if err != nil {
+				return err
+			}
+			in.args = append(in.args, x)
+
+		case argConsts:
+			if s, err := next(); err != nil || s != "[" {
+				return fmt.Errorf("expected [")
+			}
+			k := len(in.args)
+			in.args = append(in.args, 0)
+			for len(ff) > 0 && ff[0] != "]" {
+				x, err := num(31)
+				if err != nil {
+					return err
+				}
+				in.args = append(in.args, x)
+			}
+			if s, err := next(); err != nil || s != "]" {
+				return fmt.Errorf("expected ]")
+			}
+			in.args[k] = len(in.args) - k - 1
+
+		case argJump, argLoop, argJumpW, argLoopW:
+			s, err := next()
+			if err != nil {
+				return err
+			}
+			a.jumps[in] = s
+			a.jumpLines[in] = a.line
+		}
+	}
+	if len(ff) > 0 {
+		return fmt.Errorf("unexpected %s", ff[0])
+	}
+	return nil
+}
+
+func (a *assembler) directive(d string, args []string) (err error) {
+	p := a.prog
+	str := func() (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("expected one string")
+		}
+		return strconv.Unquote(args[0])
+	}
+
+	switch d {
+	case ".name":
+		p.name, err = str()
+
+	case ".compiler":
+		p.header.compiler, err = str()
+
+	case ".created":
+		if len(args) != 1 {
+			return fmt.Errorf("%s: expected time", d)
+		}
+		var t time.Time
+		t, err = time.Parse(time.RFC3339, args[0])
+		p.header.created = time.Unix(t.Unix(), 0)
+
+	case ".source":
+		var b []byte
+		if len(args) == 1 {
+			b, err = hex.DecodeString(args[0])
+		}
+		if len(b) != sha256.Size {
+			return fmt.Errorf("%s: expected SHA-256 in hex", d)
+		}
+		copy(p.header.source[:], b)
+
+	case ".meta":
+		if len(args) != 2 {
+			return fmt.Errorf("%s: expected key and value", d)
+		}
+		var k, v string
+		k, err = strconv.Unquote(args[0])
+		if err == nil {
+			v, err = strconv.Unquote(args[1])
+		}
+		if p.header.meta == nil {
+			p.header.meta = map[string]string{}
+		}
+		p.header.meta[k] = v
+
+	case ".lines":
+		lfs := p.linePos.lfs
+		for _, s := range args {
+			x, err := strconv.Atoi(s)
+			if err != nil || x < 0 || len(lfs) > 0 && x <= lfs[len(lfs)-1] {
+				return fmt.Errorf("%s: invalid line feed offset %s", d, s)
+			}
+			lfs = append(lfs, x)
+		}
+		p.linePos.lfs = lfs
+
+	case ".const":
+		if len(args) < 2 {
+			return fmt.Errorf("%s: expected index and type", d)
+		}
+		if args[0] != strconv.Itoa(len(p.constants)) {
+			return fmt.Errorf("%s: index %s, expected %d", d, args[0], len(p.constants))
+		}
+		var v value
+		v, err = parseConst(args[1], args[2:])
+		p.constants = append(p.constants, v)
+
+	default:
+		return fmt.Errorf("unknown directive %s", d)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", d, err)
+	}
+	return nil
+}
+
+// finish resolves the jumps and the positions, then encodes the code.
+func (a *assembler) finish() error {
+	for in, name := range a.jumps {
+		i, ok := a.labels[name]
+		if !ok {
+			return fmt.Errorf("line %d: unknown label %s", a.jumpLines[in], name)
+		}
+		if i == len(a.code) {
+			return fmt.Errorf("line %d: label %s is not followed by an instruction",
+				a.jumpLines[in], name,
+			)
+		}
+		in.target = a.code[i]
+	}
+	for i, in := range a.code {
+		if in.target == nil {
+			continue
+		}
+		kinds := operands[in.op]
+		back := kinds[len(kinds)-1] == argLoop || kinds[len(kinds)-1] == argLoopW
+		switch j := a.labels[a.jumps[in]]; {
+		case back && j > i:
+			return fmt.Errorf("line %d: %s to a label after it", a.jumpLines[in], in.op)
+		case !back && j <= i:
+			return fmt.Errorf("line %d: %s to a label before it", a.jumpLines[in], in.op)
+		}
+	}
+
+	if a.hasPos {
+		if a.lineCols[0] == nil {
+			return fmt.Errorf("missing position of the first instruction")
+		}
+		pos := 0
+		for i, in := range a.code {
+			if lc := a.lineCols[i]; lc != nil {
+				var err error
+				pos, err = a.position(*lc)
+				if err != nil {
+					return err
+				}
+			}
+			in.pos = pos
+		}
+	}
+
+	if len(a.code) == 0 {
+		return fmt.Errorf("no instructions")
+	}
+	a.prog.code, a.prog.positions = encodeCode(a.code)
+	if !a.hasPos {
+		a.prog.positions = nil
+	}
+	return nil
+}
+
+// position gives the source position of the line and column,
+// which have to fit the line feeds given with .lines.
+func (a *assembler) position(lc asmLineCol) (int, error) {
+	lfs := a.prog.linePos.lfs
+	if lc.line > len(lfs)+1 {
+		return 0, fmt.Errorf("position @%d:%d: no such line", lc.line, lc.col)
+	}
+	pos := lc.col - 1
+	if lc.line > 1 {
+		pos += lfs[lc.line-2] + 1
+	}
+	if lc.line <= len(lfs) && pos > lfs[lc.line-1] {
+		return 0, fmt.Errorf("position @%d:%d: beyond the line", lc.line, lc.col)
+	}
+	return pos, nil
+}
+
+// asmFields splits the line into fields: words, quoted strings and brackets,
+// skipping the comment.
+func asmFields(line string) (ff []string, _ error) {
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ';':
+			return ff, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '[' || c == ']':
+			ff = append(ff, line[i:i+1])
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(line) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			ff = append(ff, line[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(line) && !strings.ContainsRune(" \t\r;[]\"", rune(line[j])) {
+				j++
+			}
+			ff = append(ff, line[i:j])
+			i = j
+		}
+	}
+	return ff, nil
+}
+
+func isAsmLabel(s string) bool {
+	name, ok := strings.CutSuffix(s, ":")
+	if !ok || name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var opcodesByName = func() map[string]opcode {
+	m := map[string]opcode{}
+	for op := opcode(0); op.valid(); op++ {
+		m[op.String()] = op
+	}
+	return m
+}()
@@ -0,0 +1,143 @@
+package bcl_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/wkhere/bcl"
+)
+
+func testAsmRoundTrip(prog *bcl.Prog, t *testing.T) {
+	t.Helper()
+
+	asm := new(bytes.Buffer)
+	if err := prog.WriteAsm(asm); err != nil {
+		t.Fatalf("asm error: %v", err)
+	}
+	prog2, err := bcl.Assemble(asm.Bytes(), "input", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("assemble error: %v", err)
+	}
+
+	asm2 := new(bytes.Buffer)
+	prog2.WriteAsm(asm2)
+	if asm2.String() != asm.String() {
+		t.Errorf("asm mismatch\nhave:\n%s\nwant:\n%s", asm2, asm)
+	}
+
+	b1, b2 := new(bytes.Buffer), new(bytes.Buffer)
+	prog.Dump(b1)
+	prog2.Dump(b2)
+	if !bytes.Equal(b1.Bytes(), b2.Bytes()) {
+		t.Errorf("assembled prog dumped differently")
+	}
+}
+
+func TestAsmForms(t *testing.T) {
+	testForms(testAsmRoundTrip, t)
+}
+
+func TestStrippedAsm(t *testing.T) {
+	prog := strippedProg("var x = 0\nprint 1 / x", t)
+	testAsmRoundTrip(prog, t)
+
+	asm := new(bytes.Buffer)
+	prog.WriteAsm(asm)
+	if strings.Contains(asm.String(), "@") || strings.Contains(asm.String(), ".lines") {
+		t.Errorf("positions in the stripped asm:\n%s", asm)
+	}
+}
+
+func TestZeroProgAsm(t *testing.T) {
+	var prog bcl.Prog
+	asm := new(bytes.Buffer)
+	if err := prog.WriteAsm(asm); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(asm.String(), "; bcl assembly") {
+		t.Errorf("unexpected asm:\n%s", asm)
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	const input = `
+		.name "hand"
+		.meta "author" "me"
+		.lines 9 21
+
+		.const 0 string "n"
+		.const 1 int 3
+		.const 2 string "x"
+
+		@1:1  DEFBLOCK 0 0        ; def n {
+		@2:5  CONST 1
+		      SETFIELD 2
+		      POP
+		@3:1  ENDBLOCK
+		      RET
+	`
+	prog, err := bcl.Assemble([]byte(input), "input", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := prog.Info(); info.Name != "hand" || info.Meta["author"] != "me" ||
+		info.Stripped {
+		t.Errorf("info mismatch: %+v", info)
+	}
+
+	res, _, err := bcl.Execute(prog, bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Type != "n" || res[0].Fields["x"] != 3 {
+		t.Errorf("result mismatch: %+v", res)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	var tab = []struct {
+		input    string
+		errMatch string
+	}{
+		{"", "no instructions"},
+		{"NOPE", "line 1: unknown instruction NOPE"},
+		{"ZERO 1", "line 1: ZERO: unexpected 1"},
+		{"POPN", "line 1: POPN: missing operand"},
+		{"POPN x", "line 1: POPN: invalid operand x"},
+		{"CONV 256", "line 1: CONV: invalid operand 256"},
+		{"GETPATH 0", "line 1: GETPATH: expected ["},
+		{"GETPATH [0 1", "line 1: GETPATH: expected ]"},
+		{`.const 0 string "x`, "line 1: unterminated string"},
+		{".const 1 int 1", "line 1: .const: index 1, expected 0"},
+		{".const 0 int x", `line 1: .const: strconv.ParseInt: parsing "x"`},
+		{".const 0 complex 1", "line 1: .const: unknown type complex"},
+		{".nope", "line 1: unknown directive .nope"},
+		{".source 12", "line 1: .source: expected SHA-256 in hex"},
+		{".lines 5 3", "line 1: .lines: invalid line feed offset 3"},
+		{"a: ZERO\na: POP", "line 2: label a already defined"},
+		{"JUMP L1\nRET", "line 1: unknown label L1"},
+		{"L1: RET\nJUMP L1", "line 2: JUMP to a label before it"},
+		{"LOOP L1\nL1: RET", "line 1: LOOP to a label after it"},
+		{"RET\nJUMP L1\nL1:", "line 2: label L1 is not followed by an instruction"},
+		{"@0:1 RET", "line 1: invalid position @0:1"},
+		{"@1:1", "line 1: missing instruction after the position"},
+		{"ZERO\n@1:1 POP", "missing position of the first instruction"},
+		{".lines 3\n@3:1 RET", "position @3:1: no such line"},
+		{".lines 3\n@1:5 RET", "position @1:5: beyond the line"},
+		{"ZERO\nRET", "invalid code at 0001: RET: stack not empty at the end"},
+	}
+
+	for i, tc := range tab {
+		_, err := bcl.Assemble([]byte(tc.input), "input")
+		switch {
+		case err == nil:
+			t.Errorf("tc#%d: no error when expecting one", i)
+		case !strings.Contains(err.Error(), tc.errMatch):
+			t.Errorf("tc#%d: error mismatch\nhave: %s\nwant matching: %s",
+				i, err, tc.errMatch,
+			)
+		}
+	}
+}
@@ -0,0 +1,729 @@
+package bcl
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// builtin is a function callable from bcl as `name(args...)`.
+// The arity is checked by the parser, so fn always gets between
+// minArgs and maxArgs arguments; maxArgs < 0 means no upper limit.
+// The args slice is a view of the VM stack and must not be retained.
+type builtin struct {
+	minArgs, maxArgs int
+	fn               func(args []value) (value, error)
+}
+
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"upper": {1, 1, strFunc(strings.ToUpper)},
+		"lower": {1, 1, strFunc(strings.ToLower)},
+		"trim":  {1, 2, builtinTrim},
+		"split": {2, 2, builtinSplit},
+		"join":  {2, 2, builtinJoin},
+
+		"replace":    {3, 3, builtinReplace},
+		"contains":   {2, 2, builtinContains},
+		"has_prefix": {2, 2, strPredicate(strings.HasPrefix)},
+		"has_suffix": {2, 2, strPredicate(strings.HasSuffix)},
+
+		"format": {1, -1, builtinFormat},
+		"len":    {1, 1, builtinLen},
+		"substr": {2, 3, builtinSubstr},
+
+		"regex_match":   {2, 2, builtinRegexMatch},
+		"regex_replace": {3, 3, builtinRegexReplace},
+
+		"min":   {1, -1, extremum(-1)},
+		"max":   {1, -1, extremum(+1)},
+		"abs":   {1, 1, builtinAbs},
+		"floor": {1, 1, rounding(math.Floor, decimalFloor)},
+		"ceil":  {1, 1, rounding(math.Ceil, decimalCeil)},
+		"round": {1, 1, rounding(math.Round, decimalRound)},
+		"clamp": {3, 3, builtinClamp},
+
+		"keys":   {1, 1, builtinKeys},
+		"values": {1, 1, builtinValues},
+		"get":    {2, 3, builtinGet},
+		"sort":   {1, 1, builtinSort},
+		"unique": {1, 1, builtinUnique},
+		"sum":    {1, 1, builtinSum},
+		"range":  {1, 3, builtinRange},
+	}
+}
+
+// rangeMaxLen limits the list given by range.
+const rangeMaxLen = 1 << 20
+
+func (b builtin) accepts(argc int) bool {
+	return argc >= b.minArgs && (b.maxArgs < 0 || argc <= b.maxArgs)
+}
+
+func (b builtin) arity() string {
+	args := "arguments"
+	if b.minArgs == 1 && b.maxArgs <= 1 {
+		args = "argument"
+	}
+	switch {
+	case b.minArgs == b.maxArgs:
+		return fmt.Sprintf("%d %s", b.minArgs, args)
+	case b.maxArgs < 0:
+		return fmt.Sprintf("at least %d %s", b.minArgs, args)
+	default:
+		return fmt.Sprintf("%d to %d %s", b.minArgs, b.maxArgs, args)
+	}
+}
+
+// strArg gives the i-th argument as a string; nil counts as an empty
+// string, the same as in `"s" + nil`.
+func strArg(args []value, i int) (string, error) {
+	switch x := args[i].(type) {
+	case string:
+		return x, nil
+	case nil:
+		return "", nil
+	}
+	return "", argTypeErr(args, i, "string")
+}
+
+func intArg(args []value, i int) (int, error) {
+	x, ok := args[i].(int)
+	if !ok {
+		return 0, argTypeErr(args, i, "int")
+	}
+	return x, nil
+}
+
+// mapArg gives the i-th argument as a map; nil counts as an empty map.
+func mapArg(args []value, i int) (map[string]value, error) {
+	switch x := args[i].(type) {
+	case map[string]value:
+		return x, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, argTypeErr(args, i, "map")
+}
+
+// listArg gives the i-th argument as a list; nil counts as an empty list.
+func listArg(args []value, i int) ([]value, error) {
+	switch x := args[i].(type) {
+	case []value:
+		return x, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, argTypeErr(args, i, "list")
+}
+
+func argTypeErr(args []value, i int, expected string) error {
+	return fmt.Errorf(
+		"argument %d: invalid type: %s, expected %s", i+1, vtype(args[i]), expected,
+	)
+}
+
+func strFunc(f func(string) string) func([]value) (value, error) {
+	return func(args []value) (value, error) {
+		s, err := strArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return f(s), nil
+	}
+}
+
+func strPredicate(f func(s, t string) bool) func([]value) (value, error) {
+	return func(args []value) (value, error) {
+		s, err := strArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		t, err := strArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return f(s, t), nil
+	}
+}
+
+// builtinTrim: trim(s) removes the leading and trailing whitespace,
+// trim(s, chars) removes any of the given chars instead.
+func builtinTrim(args []value) (value, error) {
+	s, err := strArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		return strings.TrimSpace(s), nil
+	}
+	chars, err := strArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Trim(s, chars), nil
+}
+
+// builtinSplit: split(s, sep) gives a list of strings;
+// an empty s gives an empty list.
+func builtinSplit(args []value) (value, error) {
+	s, err := strArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := strArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return []value{}, nil
+	}
+	parts := strings.Split(s, sep)
+	list := make([]value, len(parts))
+	for i, x := range parts {
+		list[i] = x
+	}
+	return list, nil
+}
+
+// builtinJoin: join(list, sep) stringifies the elements, nil ones being
+// empty, and puts sep between them.
+func builtinJoin(args []value) (value, error) {
+	list, err := listArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := strArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(list))
+	n := len(sep) * max(len(list)-1, 0)
+	for i, x := range list {
+		s, ok := stringify(x)
+		if !ok {
+			return nil, fmt.Errorf("element %d: invalid type: %s", i, vtype(x))
+		}
+		parts[i] = s
+		n += len(s)
+		if n > maxStringLen {
+			return nil, errStringTooLong
+		}
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// builtinContains: contains(s, sub) looks for a substring,
+// contains(list, x) for an element equal to x, contains(map, key) for a key.
+func builtinContains(args []value) (value, error) {
+	switch x := args[0].(type) {
+	case []value:
+		for _, e := range x {
+			if elemEqual(e, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]value:
+		key, err := strArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		_, ok := x[key]
+		return ok, nil
+	case string, nil:
+		return strPredicate(strings.Contains)(args)
+	}
+	return nil, argTypeErr(args, 0, "string, list or map")
+}
+
+func builtinReplace(args []value) (value, error) {
+	var ss [3]string
+	for i := range ss {
+		s, err := strArg(args, i)
+		if err != nil {
+			return nil, err
+		}
+		ss[i] = s
+	}
+	s, from, to := ss[0], ss[1], ss[2]
+	if grow := len(to) - len(from); grow > 0 {
+		if n := strings.Count(s, from); n > (maxStringLen-len(s))/grow {
+			return nil, errStringTooLong
+		}
+	}
+	return strings.ReplaceAll(s, from, to), nil
+}
+
+// builtinFormat: format(f, args...) is Go's fmt.Sprintf; times, decimals
+// and lists are passed as their string forms, so that %s and %v work.
+func builtinFormat(args []value) (value, error) {
+	f, err := strArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	xs := make([]any, len(args)-1)
+	for i, x := range args[1:] {
+		switch x.(type) {
+		case time.Time, Decimal, []value, map[string]value:
+			x, _ = stringify(x)
+		}
+		xs[i] = x
+	}
+	if formatBound(f, xs) > maxStringLen {
+		return nil, errStringTooLong
+	}
+	return fmt.Sprintf(f, xs...), nil
+}
+
+// formatBound gives an upper bound of the length of fmt.Sprintf(f, xs...),
+// checked before the string is made: every verb can print the longest
+// of the arguments, escaped by %q to at most 4 times its length, padded
+// to the widths and precisions given in f, each of them, like the `*` ones,
+// limited by fmt to 1e6.
+func formatBound(f string, xs []any) int {
+	const maxWidth = 1e6
+	longest := 512 // a float with %f is the longest of the non-strings
+	for _, x := range xs {
+		if s, ok := x.(string); ok {
+			longest = max(longest, len(s))
+		}
+	}
+	n := len(f) + strings.Count(f, "%")*4*longest
+	for i := 0; i < len(f); i++ {
+		switch {
+		case f[i] == '*':
+			n += maxWidth
+		case isDigit(rune(f[i])):
+			w := int(f[i] - '0')
+			for i+1 < len(f) && isDigit(rune(f[i+1])) {
+				i++
+				w = min(w*10+int(f[i]-'0'), maxWidth)
+			}
+			n += w
+		}
+	}
+	return n
+}
+
+// builtinLen: len gives the number of characters of a string or
+// the number of elements of a list; len(nil) is 0.
+func builtinLen(args []value) (value, error) {
+	switch x := args[0].(type) {
+	case string:
+		return utf8.RuneCountInString(x), nil
+	case []value:
+		return len(x), nil
+	case map[string]value:
+		return len(x), nil
+	case nil:
+		return 0, nil
+	}
+	return nil, argTypeErr(args, 0, "string, list or map")
+}
+
+// builtinSubstr: substr(s, start) or substr(s, start, end) counts
+// characters; negative indices count from the end, and the ones out of
+// range are clamped, so the result is empty rather than an error.
+func builtinSubstr(args []value) (value, error) {
+	s, err := strArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(s)
+	n := len(runes)
+
+	start, err := intArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end := n
+	if len(args) == 3 {
+		end, err = intArg(args, 2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clamp := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		return min(max(i, 0), n)
+	}
+	start, end = clamp(start), clamp(end)
+	if start >= end {
+		return "", nil
+	}
+	return string(runes[start:end]), nil
+}
+
+// builtinRegexMatch: regex_match(s, re) tells if re matches any part of s;
+// anchor the pattern with ^ and $ to match the whole string.
+func builtinRegexMatch(args []value) (value, error) {
+	s, re, err := regexArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(s), nil
+}
+
+// builtinRegexReplace: regex_replace(s, re, repl) replaces all matches;
+// repl can refer to the submatches as $1 or ${name}.
+func builtinRegexReplace(args []value) (value, error) {
+	s, re, err := regexArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	repl, err := strArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return re.ReplaceAllString(s, repl), nil
+}
+
+func regexArgs(args []value) (string, *regexp.Regexp, error) {
+	s, err := strArg(args, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	pat, err := strArg(args, 1)
+	if err != nil {
+		return "", nil, err
+	}
+	re, err := compileRegex(pat)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, re, nil
+}
+
+// regexCache keeps the compiled regexps by their patterns, as a builtin
+// called in a loop would compile the same one over and over;
+// it is cleared when full.
+var regexCache struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}
+
+const regexCacheSize = 256
+
+func compileRegex(pat string) (*regexp.Regexp, error) {
+	regexCache.Lock()
+	re, ok := regexCache.m[pat]
+	regexCache.Unlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		return nil, err
+	}
+
+	regexCache.Lock()
+	if len(regexCache.m) >= regexCacheSize || regexCache.m == nil {
+		regexCache.m = make(map[string]*regexp.Regexp)
+	}
+	regexCache.m[pat] = re
+	regexCache.Unlock()
+	return re, nil
+}
+
+// compare orders two values of the same kind: numbers, with the int/float
+// promotion of the arithmetics, strings, durations or times.
+func compare(a, b value) (int, error) {
+	switch {
+	case isNumber(a) && isNumber(b):
+		switch lt := binopNumeric(opLT, a, b); {
+		case lt == nil:
+			break
+		case lt == true:
+			return -1, nil
+		case binopNumeric(opGT, a, b) == true:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	case isString(a) && isString(b):
+		return strings.Compare(a.(string), b.(string)), nil
+	case isDuration(a) && isDuration(b):
+		return cmp.Compare(a.(time.Duration), b.(time.Duration)), nil
+	case isTime(a) && isTime(b):
+		return a.(time.Time).Compare(b.(time.Time)), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", vtype(a), vtype(b))
+}
+
+// extremum gives min for sign -1 and max for +1; they take either
+// the values to choose from, or a single list of them.
+// The chosen value is returned as is, e.g. max(1, 1.5, 2) is int 2.
+func extremum(sign int) func([]value) (value, error) {
+	return func(args []value) (value, error) {
+		xs := args
+		if list, ok := args[0].([]value); ok && len(args) == 1 {
+			xs = list
+		}
+		if len(xs) == 0 {
+			return nil, fmt.Errorf("empty list")
+		}
+		m := xs[0]
+		for _, x := range xs[1:] {
+			c, err := compare(x, m)
+			if err != nil {
+				return nil, err
+			}
+			if c*sign > 0 {
+				m = x
+			}
+		}
+		return m, nil
+	}
+}
+
+func builtinAbs(args []value) (value, error) {
+	switch x := args[0].(type) {
+	case int:
+		return max(x, -x), nil
+	case float64:
+		return math.Abs(x), nil
+	case Decimal:
+		if x.coef.Sign() < 0 {
+			return x.neg(), nil
+		}
+		return x, nil
+	case time.Duration:
+		return x.Abs(), nil
+	}
+	return nil, argTypeErr(args, 0, "number or duration")
+}
+
+// rounding makes floor, ceil and round, which give an int;
+// round goes half away from zero.
+func rounding(f func(float64) float64, d func(Decimal) Decimal) func([]value) (value, error) {
+	return func(args []value) (value, error) {
+		switch x := args[0].(type) {
+		case int:
+			return x, nil
+		case float64:
+			return convert(f(x), typeINT)
+		case Decimal:
+			return convert(d(x), typeINT)
+		}
+		return nil, argTypeErr(args, 0, "number")
+	}
+}
+
+func decimalFloor(d Decimal) Decimal { return d.floorQuo(decimalFromInt(1)) }
+
+func decimalCeil(d Decimal) Decimal { return decimalFloor(d.neg()).neg() }
+
+func decimalRound(d Decimal) Decimal {
+	half := Decimal{big.NewInt(5), 1}
+	if d.coef.Sign() < 0 {
+		return decimalFloor(d.neg().add(half)).neg()
+	}
+	return decimalFloor(d.add(half))
+}
+
+// builtinClamp: clamp(x, lo, hi) limits x to the range, giving lo or hi
+// when x is outside of it.
+func builtinClamp(args []value) (value, error) {
+	x, lo, hi := args[0], args[1], args[2]
+	c, err := compare(lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	if c > 0 {
+		return nil, fmt.Errorf("empty range: %s > %s", formatElem(lo), formatElem(hi))
+	}
+	if c, err = compare(x, lo); err != nil || c < 0 {
+		return lo, err
+	}
+	if c, err = compare(x, hi); err != nil || c > 0 {
+		return hi, err
+	}
+	return x, nil
+}
+
+// builtinKeys gives the sorted keys of a map.
+func builtinKeys(args []value) (value, error) {
+	m, err := mapArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := sortedKeys(m)
+	list := make([]value, len(keys))
+	for i, k := range keys {
+		list[i] = k
+	}
+	return list, nil
+}
+
+// builtinGet gives the element of a map by its key, or of a list by its
+// index; a missing one gives the default when there is one, or an error.
+func builtinGet(args []value) (value, error) {
+	var x value
+	var found bool
+	switch c := args[0].(type) {
+	case map[string]value, nil:
+		m, _ := mapArg(args, 0)
+		key, err := strArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		x, found = m[key]
+		if !found && len(args) < 3 {
+			return nil, fmt.Errorf("no key %q", key)
+		}
+	case []value:
+		i, err := intArg(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		found = i >= 0 && i < len(c)
+		if found {
+			x = c[i]
+		} else if len(args) < 3 {
+			return nil, fmt.Errorf("index out of range: %d", i)
+		}
+	default:
+		return nil, argTypeErr(args, 0, "map or list")
+	}
+	if !found {
+		return args[2], nil
+	}
+	return x, nil
+}
+
+// builtinValues gives the values of a map, in the order of sorted keys.
+func builtinValues(args []value) (value, error) {
+	m, err := mapArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := sortedKeys(m)
+	list := make([]value, len(keys))
+	for i, k := range keys {
+		list[i] = m[k]
+	}
+	return list, nil
+}
+
+// builtinSort gives a sorted copy of a list; equal elements keep their order.
+func builtinSort(args []value) (value, error) {
+	list, err := listArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sorted := slices.Clone(list)
+	slices.SortStableFunc(sorted, func(a, b value) int {
+		c, e := compare(a, b)
+		if e != nil && err == nil {
+			err = e
+		}
+		return c
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sorted, nil
+}
+
+// builtinUnique gives the list without repeated elements, keeping
+// the first of the equal ones.
+func builtinUnique(args []value) (value, error) {
+	list, err := listArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	uniq := []value{}
+	for _, x := range list {
+		if !slices.ContainsFunc(uniq, func(y value) bool { return elemEqual(x, y) }) {
+			uniq = append(uniq, x)
+		}
+	}
+	return uniq, nil
+}
+
+// builtinSum adds the numbers or durations of a list; sum of an empty
+// list is 0.
+func builtinSum(args []value) (value, error) {
+	list, err := listArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return 0, nil
+	}
+	var sum value
+	for i, x := range list {
+		switch {
+		case i == 0 && (isNumber(x) || isDuration(x)):
+			sum = x
+		case isNumber(sum) && isNumber(x):
+			sum = binopNumeric(opADD, sum, x)
+		case isDuration(sum) && isDuration(x):
+			sum = binopTime(opADD, sum, x)
+		default:
+			sum = nil
+		}
+		if sum == nil {
+			return nil, fmt.Errorf("element %d: invalid type: %s", i, vtype(x))
+		}
+	}
+	return sum, nil
+}
+
+// builtinRange: range(end), range(start, end) or range(start, end, step)
+// gives a list of ints from start, 0 by default, up to but without end.
+func builtinRange(args []value) (value, error) {
+	var ii [3]int
+	for i := range args {
+		x, err := intArg(args, i)
+		if err != nil {
+			return nil, err
+		}
+		ii[i] = x
+	}
+	start, end, step := 0, ii[0], 1
+	if len(args) > 1 {
+		start, end = ii[0], ii[1]
+	}
+	if len(args) > 2 {
+		step = ii[2]
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("zero step")
+	}
+
+	// unsigned, so that the distance can't overflow
+	var dist, ustep uint64
+	switch {
+	case step > 0 && end > start:
+		dist, ustep = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && end < start:
+		dist, ustep = uint64(start)-uint64(end), -uint64(step)
+	}
+	n := dist / max(ustep, 1)
+	if ustep > 0 && dist%ustep != 0 {
+		n++
+	}
+	if n > rangeMaxLen {
+		return nil, fmt.Errorf("too many elements: %d", n)
+	}
+	list := make([]value, n)
+	for i := range list {
+		list[i] = start + i*step
+	}
+	return list, nil
+}
@@ -2,34 +2,52 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 type parsedArgs struct {
 	file string
-
-	disasm bool
-	trace  bool
-	result bool
-	stats  bool
-	bdump  bool
-	bload  bool
-	force  bool
+	asm  bool
+
+	disasm       bool
+	disasmFormat string
+	trace        bool
+	result       bool
+	stats        bool
+	bdump        bool
+	bstrip       bool
+	bload        bool
+	binfo        bool
+	force        bool
+
+	strictScope bool
+	optimize    int
 
 	bdumpFile string
 	bloadFile string
+	bmeta     [][2]string
+
+	signKeyFile   string
+	verifyKeyFile string
 
 	help func()
 }
 
-const usage = "usage: bcl" +
-	" [-d|--disasm] [-t|--trace] [-r|--result] [-s|--stats]" +
-	" [--bdump|--bdump=BFILE] [--bload|--bload=BFILE]" +
-	" [-f|--force]" +
+const usage = "usage: bcl [asm]" +
+	" [-d|--disasm] [--disasm-format=text|asm|json] [-t|--trace] [-r|--result] [-s|--stats]" +
+	" [--bdump|--bdump=BFILE|--bdump-strip|--bdump-strip=BFILE]" +
+	" [--bload|--bload=BFILE]" +
+	" [--binfo] [--bmeta=KEY=VALUE] [--sign-key=KEY] [--verify-key=PUBKEY]" +
+	" [-f|--force] [--strict-scope] [-O|-O2|--optimize=LEVEL]" +
 	" [FILE|-]"
 
 func parseArgs(args []string) (a parsedArgs, _ error) {
 	var rest []string
+	if len(args) > 0 && args[0] == "asm" {
+		a.asm = true
+		args = args[1:]
+	}
 flags:
 	for ; len(args) > 0; args = args[1:] {
 		switch arg := args[0]; {
@@ -42,6 +60,16 @@ flags:
 			a.disasm = true
 			continue
 
+		case strings.HasPrefix(arg, "--disasm-format="):
+			a.disasmFormat = arg[len("--disasm-format="):]
+			switch a.disasmFormat {
+			case "text", "asm", "json":
+			default:
+				return a, fmt.Errorf("invalid disasm format: %s\n%s", a.disasmFormat, usage)
+			}
+			a.disasm = true
+			continue
+
 		case arg == "-t", arg == "--trace":
 			a.trace = true
 			continue
@@ -58,9 +86,33 @@ flags:
 			a.force = true
 			continue
 
+		case arg == "--strict-scope":
+			a.strictScope = true
+			continue
+
+		case arg == "-O":
+			a.optimize = 1
+			continue
+
+		case strings.HasPrefix(arg, "-O"), strings.HasPrefix(arg, "--optimize="):
+			_, s, _ := strings.Cut(arg, "=")
+			if s == "" {
+				s = arg[len("-O"):]
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return a, fmt.Errorf("invalid optimization level: %s\n%s", arg, usage)
+			}
+			a.optimize = n
+			continue
+
 		case strings.HasPrefix(arg, "--bdump"):
 			a.bdump = true
 			s := arg[len("--bdump"):]
+			if strings.HasPrefix(s, "-strip") {
+				a.bstrip = true
+				s = s[len("-strip"):]
+			}
 			if len(s) > 0 {
 				if s[0] != '=' {
 					return a, fmt.Errorf("unknown flag: %s\n%s", arg, usage)
@@ -69,6 +121,26 @@ flags:
 			}
 			continue
 
+		case arg == "--binfo":
+			a.binfo = true
+			continue
+
+		case strings.HasPrefix(arg, "--bmeta="):
+			k, v, ok := strings.Cut(arg[len("--bmeta="):], "=")
+			if !ok || k == "" {
+				return a, fmt.Errorf("invalid metadata: %s\n%s", arg, usage)
+			}
+			a.bmeta = append(a.bmeta, [2]string{k, v})
+			continue
+
+		case strings.HasPrefix(arg, "--sign-key="):
+			a.signKeyFile = arg[len("--sign-key="):]
+			continue
+
+		case strings.HasPrefix(arg, "--verify-key="):
+			a.verifyKeyFile = arg[len("--verify-key="):]
+			continue
+
 		case strings.HasPrefix(arg, "--bload"):
 			a.bload = true
 			s := arg[len("--bload"):]
@@ -116,7 +188,11 @@ flags:
 	if a.bdump {
 		switch a.bdumpFile {
 		case "":
-			if !strings.HasSuffix(a.file, ".bcl") {
+			base, ok := strings.CutSuffix(a.file, ".bcl")
+			if a.asm {
+				base, ok = strings.CutSuffix(a.file, ".bcasm")
+			}
+			if !ok {
 				return a, fmt.Errorf(
 					"--bdump requires knowing BFILE name, "+
 						"either given as a flag, or derived from FILE"+
@@ -124,13 +200,17 @@ flags:
 					usage,
 				)
 			}
-			a.bdumpFile = a.file[:len(a.file)-len(".bcl")] + ".bcb"
+			a.bdumpFile = base + ".bcb"
 
 		case "-":
 			return a, fmt.Errorf("`-` is not a valid BFILE for --bdump")
 		}
 	}
 
+	if a.asm && a.bload {
+		return a, fmt.Errorf("conflicting asm and --bload\n%s", usage)
+	}
+
 	if a.bload {
 		switch {
 		case a.file == "" && a.bloadFile == "":
@@ -142,6 +222,13 @@ flags:
 		}
 	}
 
+	if a.signKeyFile != "" && !a.bdump {
+		return a, fmt.Errorf("--sign-key requires --bdump\n%s", usage)
+	}
+	if a.verifyKeyFile != "" && !a.bload {
+		return a, fmt.Errorf("--verify-key requires --bload\n%s", usage)
+	}
+
 	if a.file == "" {
 		a.file = "-"
 	}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// The keys are read from PEM files, as made by:
+//
+//	openssl genpkey -algorithm ed25519 -out key.pem
+//	openssl pkey -in key.pem -pubout -out pub.pem
+
+func readPrivateKey(file string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(file, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 private key", file)
+	}
+	return key, nil
+}
+
+func readPublicKey(file string) (ed25519.PublicKey, error) {
+	der, err := readPEM(file, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	pub, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ed25519 public key", file)
+	}
+	return pub, nil
+}
+
+func readPEM(file, typ string) ([]byte, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != typ {
+		return nil, fmt.Errorf("%s: no PEM block of type %s", file, typ)
+	}
+	return block.Bytes, nil
+}
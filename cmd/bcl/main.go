@@ -1,8 +1,13 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/wkhere/bcl"
 )
@@ -30,37 +35,90 @@ func run(a *parsedArgs) (err error) {
 	}
 
 	var prog *bcl.Prog
+	disasm := a.disasm && (a.disasmFormat == "" || a.disasmFormat == "text")
 
-	if a.bload {
-		prog, err = bcl.LoadProg(
-			f, a.file,
-			bcl.OptDisasm(a.disasm),
-		)
+	switch {
+	case a.asm:
+		var input []byte
+		input, err = io.ReadAll(f)
 		f.Close()
-	} else {
-		prog, err = bcl.ParseFile(
-			f,
-			bcl.OptDisasm(a.disasm),
+		if err == nil {
+			prog, err = bcl.Assemble(input, a.file, bcl.OptDisasm(disasm))
+		}
+
+	case a.bload:
+		opts := []bcl.Option{bcl.OptDisasm(disasm)}
+		if a.verifyKeyFile != "" {
+			pub, err := readPublicKey(a.verifyKeyFile)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			opts = append(opts, bcl.OptVerifyKey(pub))
+		}
+		prog, err = bcl.LoadProg(f, a.file, opts...)
+		f.Close()
+
+	default:
+		opts := []bcl.Option{
+			bcl.OptDisasm(disasm),
 			bcl.OptStats(a.stats),
-		)
+			bcl.OptStrictScope(a.strictScope),
+			bcl.OptOptimize(a.optimize),
+		}
+		for _, kv := range a.bmeta {
+			opts = append(opts, bcl.OptMeta(kv[0], kv[1]))
+		}
+		prog, err = bcl.ParseFile(f, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case a.disasm && a.disasmFormat == "asm":
+		err = prog.WriteAsm(os.Stdout)
+	case a.disasm && a.disasmFormat == "json":
+		var b []byte
+		b, err = json.MarshalIndent(prog, "", "  ")
+		if err == nil {
+			_, err = os.Stdout.Write(append(b, '\n'))
+		}
 	}
 	if err != nil {
 		return err
 	}
 
 	if a.bdump {
+		var key ed25519.PrivateKey
+		if a.signKeyFile != "" {
+			key, err = readPrivateKey(a.signKeyFile)
+			if err != nil {
+				return fmt.Errorf("dump: %w", err)
+			}
+		}
+
 		bf, err := openOutput(a.bdumpFile, a.force)
 		if err != nil {
 			return fmt.Errorf("dump: %w", err)
 		}
 
-		err = prog.Dump(bf)
+		err = prog.DumpWith(bf, bcl.DumpOptions{StripDebug: a.bstrip, SignKey: key})
 		safeClose(bf, &err)
 		if err != nil {
 			return fmt.Errorf("dump: %w", err)
 		}
 	}
 
+	if a.binfo {
+		printInfo(prog.Info())
+		return nil
+	}
+	if a.disasm && !disasm {
+		// the asm or json is the output, so that it can be read back
+		return nil
+	}
+
 	res, binding, err := bcl.Execute(
 		prog,
 		bcl.OptTrace(a.trace),
@@ -76,6 +134,32 @@ func run(a *parsedArgs) (err error) {
 	return nil
 }
 
+func printInfo(info bcl.ProgInfo) {
+	fmt.Printf("name:      %s\n", info.Name)
+	fmt.Printf("bytecode:  %s\n", info.Version)
+	fmt.Printf("compiler:  %s\n", info.Compiler)
+	fmt.Printf("created:   %s\n", info.Created.UTC().Format(time.RFC3339))
+	fmt.Printf("source:    %x\n", info.Source)
+	if info.Checksum != [32]byte{} {
+		fmt.Printf("checksum:  %x\n", info.Checksum)
+	}
+	if info.Signed {
+		fmt.Printf("signed:    ed25519\n")
+	}
+	if info.Stripped {
+		fmt.Printf("stripped:  no source positions\n")
+	}
+
+	keys := make([]string, 0, len(info.Meta))
+	for k := range info.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("meta:      %s=%s\n", k, info.Meta[k])
+	}
+}
+
 func main() {
 	a, err := parseArgs(os.Args[1:])
 	if err != nil {
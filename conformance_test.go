@@ -0,0 +1,194 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	diff "github.com/akedrou/textdiff"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the conformance suite")
+
+// TestConformance runs the suite in testdata/conformance, which is meant
+// also for the VMs written in other languages: each NAME.bcl is parsed
+// into NAME.prog.json, which executed gives NAME.blocks.json and prints
+// NAME.out, if there is one. See testdata/conformance/README.md.
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob("testdata/conformance/*.bcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no conformance cases")
+	}
+
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".bcl")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			testConformance(t, file, base)
+		})
+	}
+}
+
+func testConformance(t *testing.T, file, base string) {
+	input, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := Parse(input, filepath.Base(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog.header.compiler = "bcl (conformance)"
+	prog.header.created = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	progJSON, err := json.Marshal(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, base+".prog.json", indentProgJSON(progJSON))
+
+	golden, err := os.ReadFile(base + ".prog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	loaded := &Prog{output: out, log: out}
+	if err := json.Unmarshal(golden, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	d1, d2 := new(bytes.Buffer), new(bytes.Buffer)
+	prog.Dump(d1)
+	loaded.Dump(d2)
+	if !bytes.Equal(d1.Bytes(), d2.Bytes()) {
+		t.Errorf("prog loaded from json dumped differently")
+	}
+
+	result, _, err := Execute(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := make([]blockJSON, len(result))
+	for i, b := range result {
+		blocks[i] = blockToJSON(b)
+	}
+	blocksJSON, err := json.MarshalIndent(blocks, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, base+".blocks.json", append(blocksJSON, '\n'))
+	checkGolden(t, base+".out", out.Bytes())
+}
+
+// checkGolden compares the data with the golden file, or with -update
+// writes it there; an empty data means no file.
+func checkGolden(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if *updateGolden {
+		var err error
+		if len(data) > 0 {
+			err = os.WriteFile(path, data, 0o644)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if d := diff.Unified("want", "have", string(want), string(data)); d != "" {
+		t.Errorf("%s mismatch:\n%s", path, d)
+	}
+}
+
+// indentProgJSON puts each field of the Prog JSON on its own line,
+// and so each of the constants and the instructions.
+func indentProgJSON(data []byte) []byte {
+	var fields []string
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.Token()
+	for dec.More() {
+		key, _ := dec.Token()
+		var v json.RawMessage
+		dec.Decode(&v)
+
+		var list []json.RawMessage
+		if json.Unmarshal(v, &list) == nil && len(list) > 0 && list[0][0] == '{' {
+			items := make([]string, len(list))
+			for i, x := range list {
+				items[i] = "    " + string(x)
+			}
+			v = []byte("[\n" + strings.Join(items, ",\n") + "\n  ]")
+		}
+		fields = append(fields, fmt.Sprintf("  %q: %s", key, v))
+	}
+	return []byte("{\n" + strings.Join(fields, ",\n") + "\n}\n")
+}
+
+// blockJSON is a Block as given in the conformance suite, with the fields
+// being typed like the constants of the Prog JSON; besides their types,
+// a field can be a list, a map or a nested block.
+type blockJSON struct {
+	Type   string                    `json:"type"`
+	Name   string                    `json:"name"`
+	Fields map[string]typedValueJSON `json:"fields"`
+}
+
+type typedValueJSON struct {
+	Type  string `json:"type"`
+	Value any    `json:"value,omitempty"`
+}
+
+func blockToJSON(b Block) blockJSON {
+	bj := blockJSON{
+		Type:   b.Type,
+		Name:   b.Name,
+		Fields: make(map[string]typedValueJSON, len(b.Fields)),
+	}
+	for k, v := range b.Fields {
+		bj.Fields[k] = valueToJSON(v)
+	}
+	return bj
+}
+
+func valueToJSON(v value) typedValueJSON {
+	switch x := v.(type) {
+	case Block:
+		return typedValueJSON{"block", blockToJSON(x)}
+	case []value:
+		list := make([]typedValueJSON, len(x))
+		for i, e := range x {
+			list[i] = valueToJSON(e)
+		}
+		return typedValueJSON{"list", list}
+	case map[string]value:
+		m := make(map[string]typedValueJSON, len(x))
+		for k, e := range x {
+			m[k] = valueToJSON(e)
+		}
+		return typedValueJSON{"map", m}
+	}
+
+	c, err := constJSON(v)
+	if err != nil {
+		panic(err)
+	}
+	if c.Value == nil {
+		return typedValueJSON{Type: c.Type}
+	}
+	return typedValueJSON{c.Type, c.Value}
+}
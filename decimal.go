@@ -0,0 +1,151 @@
+package bcl
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal is an exact decimal number, the value of a literal like 12.50d,
+// as found in the block fields and the constants: coef * 10^-scale.
+// The scale is kept as written in the literal, so that 12.50d stays 12.50;
+// arithmetic doesn't round, except for the division, which is carried
+// decimalQuoDigits past the bigger scale of the operands.
+// The zero value is 0.
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+const decimalQuoDigits = 16
+
+func parseDecimal(s string) (Decimal, error) {
+	intpart, frac, _ := strings.Cut(s, ".")
+	coef, ok := new(big.Int).SetString(intpart+frac, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal: %s", s)
+	}
+	return Decimal{coef, len(frac)}, nil
+}
+
+func decimalFromInt(x int) Decimal {
+	return Decimal{big.NewInt(int64(x)), 0}
+}
+
+// String gives the decimal with its scale, like "12.50".
+func (d Decimal) String() string {
+	if d.coef == nil {
+		d.coef = new(big.Int)
+	}
+	s := new(big.Int).Abs(d.coef).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.coef.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalText gives the decimal as String does.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Rat gives the exact value of the decimal.
+func (d Decimal) Rat() *big.Rat {
+	if d.coef == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(d.coef, pow10(d.scale))
+}
+
+func (d Decimal) isZero() bool { return d.coef.Sign() == 0 }
+
+func (d Decimal) neg() Decimal {
+	return Decimal{new(big.Int).Neg(d.coef), d.scale}
+}
+
+// rescale gives the coefficient at the scale not smaller than the current.
+func (d Decimal) rescale(scale int) *big.Int {
+	return new(big.Int).Mul(d.coef, pow10(scale-d.scale))
+}
+
+func (d Decimal) cmp(e Decimal) int {
+	scale := max(d.scale, e.scale)
+	return d.rescale(scale).Cmp(e.rescale(scale))
+}
+
+func (d Decimal) add(e Decimal) Decimal {
+	scale := max(d.scale, e.scale)
+	return Decimal{new(big.Int).Add(d.rescale(scale), e.rescale(scale)), scale}
+}
+
+func (d Decimal) sub(e Decimal) Decimal {
+	return d.add(e.neg())
+}
+
+func (d Decimal) mul(e Decimal) Decimal {
+	return Decimal{new(big.Int).Mul(d.coef, e.coef), d.scale + e.scale}
+}
+
+// quo divides, rounding half to even; e must not be zero.
+// Trailing zeros past the bigger scale of the operands are removed.
+func (d Decimal) quo(e Decimal) Decimal {
+	minScale := max(d.scale, e.scale)
+	scale := minScale + decimalQuoDigits
+
+	num := new(big.Int).Mul(d.coef, pow10(scale+e.scale-d.scale))
+	den := e.coef
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	r.Abs(r).Lsh(r, 1)
+	if c := r.Cmp(new(big.Int).Abs(den)); c > 0 || c == 0 && q.Bit(0) == 1 {
+		if num.Sign() == den.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+
+	ten := big.NewInt(10)
+	m := new(big.Int)
+	for scale > minScale {
+		if q.QuoRem(q, ten, m); m.Sign() != 0 {
+			q.Mul(q, ten).Add(q, m)
+			break
+		}
+		scale--
+	}
+	return Decimal{q, scale}
+}
+
+// floorQuo gives the quotient rounded towards negative infinity, with scale 0;
+// e must not be zero.
+func (d Decimal) floorQuo(e Decimal) Decimal {
+	scale := max(d.scale, e.scale)
+	num, den := d.rescale(scale), e.rescale(scale)
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if m.Sign() != 0 && num.Sign() != den.Sign() {
+		q.Sub(q, big.NewInt(1))
+	}
+	return Decimal{q, 0}
+}
+
+// pow gives d to the power of n; for negative n, it's the division of 1
+// by the positive power, so d must not be zero then.
+func (d Decimal) pow(n int) Decimal {
+	k := max(n, -n)
+	x := Decimal{new(big.Int).Exp(d.coef, big.NewInt(int64(k)), nil), d.scale * k}
+	if n < 0 {
+		return decimalFromInt(1).quo(x)
+	}
+	return x
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
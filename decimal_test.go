@@ -0,0 +1,45 @@
+package bcl
+
+import "testing"
+
+func TestDecimal(t *testing.T) {
+	var tab = []struct {
+		a, b                     string
+		sum, diff, product, quot string
+	}{
+		{"1", "1", "2", "0", "1", "1"},
+		{"12.50", "1", "13.50", "11.50", "12.50", "12.50"},
+		{"0.1", "0.2", "0.3", "-0.1", "0.02", "0.5"},
+		{"1.10", "1.10", "2.20", "0.00", "1.2100", "1.00"},
+		{"1", "3", "4", "-2", "3", "0.3333333333333333"},
+		{"2", "3", "5", "-1", "6", "0.6666666666666667"},
+		{"-1", "3", "2", "-4", "-3", "-0.3333333333333333"},
+		{"0.5", "-0.005", "0.495", "0.505", "-0.0025", "-100.000"},
+		{"0.000", "7", "7.000", "-7.000", "0.000", "0.000"},
+		{"1", "0.0000000000000000000003", "1.0000000000000000000003",
+			"0.9999999999999999999997", "0.0000000000000000000003",
+			"3333333333333333333333.33333333333333333333333333333333333333"},
+	}
+
+	dec := func(s string) Decimal {
+		d, err := parseDecimal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	check := func(i int, op string, have Decimal, want string) {
+		if s := have.String(); s != want {
+			t.Errorf("tc#%d %s: have %s, want %s", i, op, s, want)
+		}
+	}
+
+	for i, tc := range tab {
+		a, b := dec(tc.a), dec(tc.b)
+		check(i, "a", a, tc.a)
+		check(i, "add", a.add(b), tc.sum)
+		check(i, "sub", a.sub(b), tc.diff)
+		check(i, "mul", a.mul(b), tc.product)
+		check(i, "quo", a.quo(b), tc.quot)
+	}
+}
@@ -1,26 +1,35 @@
 package bcl
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 )
 
-func (p *Prog) disasm() {
+// Disasm writes the disassembled code of the Prog to w, in the same form
+// as given by [OptDisasm], regardless of the output the Prog was made with.
+func (p *Prog) Disasm(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	p.disasm(bw)
+	return bw.Flush()
+}
+
+func (p *Prog) disasm(w io.Writer) {
 	if p.name != "" {
-		fmt.Fprintln(p.output, "==", p.name, "==")
+		fmt.Fprintln(w, "==", p.name, "==")
 	}
 
 	for offset := 0; offset < len(p.code); {
-		offset = p.disasmInstr(offset)
+		offset = p.disasmInstr(w, offset)
 	}
 }
 
-func (p *Prog) disasmInstr(offset int) int {
-	fmt.Fprintf(p.output, "%04d ", offset)
-	if offset > 0 && p.positions[offset] == p.positions[offset-1] {
-		fmt.Fprintf(p.output, "     |  ")
+func (p *Prog) disasmInstr(w io.Writer, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if pos := p.posAt(offset); offset > 0 && pos >= 0 && pos == p.posAt(offset-1) {
+		fmt.Fprintf(w, "     |  ")
 	} else {
-		fmt.Fprintf(p.output, "%6s  ", p.linePos.format(p.positions[offset]))
+		fmt.Fprintf(w, "%6s  ", p.linePos.format(pos))
 	}
 
 	instr := opcode(p.code[offset])
@@ -30,29 +39,47 @@ func (p *Prog) disasmInstr(offset int) int {
 		opENDBLOCK,
 		opDEFUBIND, opENDUBIND,
 		opNIL, opZERO, opONE, opTRUE, opFALSE,
-		opEQ, opLT, opGT,
-		opADD, opSUB, opMUL, opDIV, opNEG, opNOT, opUNPLUS:
-		return simpleInstr(p.output, instr, offset)
+		opEQ, opLT, opGT, opNE, opLE, opGE,
+		opADD, opSUB, opMUL, opDIV, opNEG, opNOT, opUNPLUS,
+		opTOSTR,
+		opMOD, opFLOORDIV, opPOW,
+		opBAND, opBOR, opBXOR, opSHL, opSHR:
+		return simpleInstr(w, instr, offset)
 
-	case opCONST, opGETFIELD, opSETFIELD:
-		return constInstr(p.output, instr, p, offset)
+	case opCONST, opGETFIELD, opSETFIELD, opTRYFIELD:
+		return constInstr(w, instr, p, offset)
 
-	case opGETLOCAL, opSETLOCAL, opPOPN:
-		return varbyteargInstr(p.output, instr, p, offset)
+	case opGETLOCAL, opSETLOCAL, opPOPN, opLIST, opMAP:
+		return varbyteargInstr(w, instr, p, offset)
 
 	case opDEFBLOCK:
-		return blockInstr(p.output, instr, p, offset)
+		return blockInstr(w, instr, p, offset)
 
-	case opJUMP, opJFALSE:
-		return jumpInstr(p.output, instr, +1, p, offset)
-	case opLOOP:
-		return jumpInstr(p.output, instr, -1, p, offset)
+	case opDYNBLOCK:
+		return constInstr(w, instr, p, offset)
+
+	case opJUMP, opJFALSE, opJNOTNIL, opJUMPW, opJFALSEW, opJNOTNILW:
+		return jumpInstr(w, instr, +1, p, offset)
+	case opLOOP, opLOOPW:
+		return jumpInstr(w, instr, -1, p, offset)
 
 	case opBIND:
-		return bindInstr(p.output, instr, p, offset)
+		return bindInstr(w, instr, p, offset)
+
+	case opGETPATH, opTRYPATH:
+		return pathInstr(w, instr, p, offset)
+
+	case opFORRANGE, opFORRANGEW, opFORLIST, opFORLISTW, opFORSTEP, opFORSTEPW:
+		return forRangeInstr(w, instr, p, offset)
+
+	case opCONV:
+		return convInstr(w, instr, p, offset)
+
+	case opCALL:
+		return callInstr(w, instr, p, offset)
 
 	default:
-		fmt.Fprintln(p.output, "unknown opcode", instr)
+		fmt.Fprintln(w, "unknown opcode", instr)
 		return offset + 1
 	}
 }
@@ -62,6 +89,19 @@ func simpleInstr(w io.Writer, o opcode, offset int) int {
 	return offset + 1
 }
 
+func convInstr(w io.Writer, o opcode, p *Prog, offset int) int {
+	code := typecode(p.code[offset+1])
+	fmt.Fprintf(w, "%-10s %4d @%s\n", o, code, convTypeName(code))
+	return offset + 2
+}
+
+func callInstr(w io.Writer, o opcode, p *Prog, offset int) int {
+	idx, n1 := uvarintFromBytes(p.code[offset+1:])
+	argc, n2 := uvarintFromBytes(p.code[offset+1+n1:])
+	fmt.Fprintf(w, "%-10s %4d '%v'\t%4d#\n", o, idx, p.constants[idx], argc)
+	return offset + 1 + n1 + n2
+}
+
 func byteargInstr(w io.Writer, o opcode, p *Prog, offset int) int {
 	arg := p.code[offset+1]
 	fmt.Fprintf(w, "%-10s %4d\n", o, arg)
@@ -91,11 +131,20 @@ func blockInstr(w io.Writer, o opcode, p *Prog, offset int) int {
 }
 
 func jumpInstr(w io.Writer, o opcode, sign int, p *Prog, offset int) int {
-	jump := u16FromBytes(p.code[offset+1:])
+	jump, n := jumpFromBytes(o, p.code[offset+1:])
 	fmt.Fprintf(w, "%-10s %4d -> %04d\n",
-		o, jump, offset+1+jumpByteLength+sign*int(jump),
+		o, jump, offset+1+n+sign*jump,
 	)
-	return offset + 1 + jumpByteLength
+	return offset + 1 + n
+}
+
+// jumpFromBytes reads the jump operand, which is wide for the wide ops.
+func jumpFromBytes(o opcode, b []byte) (jump, n int) {
+	switch o {
+	case opJUMPW, opLOOPW, opJFALSEW, opJNOTNILW, opFORRANGEW, opFORLISTW, opFORSTEPW:
+		return int(u32FromBytes(b)), wideJumpByteLength
+	}
+	return int(u16FromBytes(b)), jumpByteLength
 }
 
 func bindInstr(w io.Writer, o opcode, p *Prog, offset int) int {
@@ -123,3 +172,30 @@ func bindInstr(w io.Writer, o opcode, p *Prog, offset int) int {
 	fmt.Fprintln(w)
 	return offset + 2 + n + m + k
 }
+
+func pathInstr(w io.Writer, o opcode, p *Prog, offset int) int {
+	cnt, n := uvarintFromBytes(p.code[offset+1:])
+	fmt.Fprintf(w, "%-10s %4d#", o, cnt)
+
+	k := offset + 1 + n
+	for i := uint64(0); i < cnt; i++ {
+		idx, j := uvarintFromBytes(p.code[k:])
+		fmt.Fprintf(w, "\t%4d '%v'", idx, p.constants[idx])
+		k += j
+	}
+	fmt.Fprintln(w)
+	return k
+}
+
+func forRangeInstr(w io.Writer, o opcode, p *Prog, offset int) int {
+	fmt.Fprintf(w, "%-10s", o)
+	k := offset + 1
+	for range operands[o][1:] {
+		slot, n := uvarintFromBytes(p.code[k:])
+		fmt.Fprintf(w, " %4d", slot)
+		k += n
+	}
+	jump, n := jumpFromBytes(o, p.code[k:])
+	fmt.Fprintf(w, " %4d -> %04d\n", jump, k+n+jump)
+	return k + n
+}
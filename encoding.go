@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"time"
 
 	"github.com/mohae/uvarint"
 )
@@ -19,6 +20,14 @@ func u16FromBytes(b []byte) uint16 {
 	return uint16(b[0])<<8 | uint16(b[1])
 }
 
+func u32ToBytes(p []byte, x uint32) {
+	stdbinary.BigEndian.PutUint32(p, x)
+}
+
+func u32FromBytes(b []byte) uint32 {
+	return stdbinary.BigEndian.Uint32(b)
+}
+
 func uvarintToBytes(p []byte, x uint64) int {
 	return uvarint.Encode(p, x)
 }
@@ -27,12 +36,36 @@ func uvarintFromBytes(p []byte) (uint64, int) {
 	return uvarint.Decode(p)
 }
 
+// uvarintSize gives the size of the encoded uvarint by its first byte.
+func uvarintSize(b byte) int {
+	switch {
+	case b <= 0xF0:
+		return 1
+	case b <= 0xF8:
+		return 2
+	default:
+		return int(b-0xF9) + 3
+	}
+}
+
+// uvarintFromCode is the checked uvarintFromBytes, for the untrusted code.
+func uvarintFromCode(p []byte) (uint64, int, bool) {
+	if len(p) == 0 || len(p) < uvarintSize(p[0]) {
+		return 0, 0, false
+	}
+	x, n := uvarintFromBytes(p)
+	return x, n, true
+}
+
 func uvarintFromBuf(r *bufio.Reader) (uint64, error) {
 	p, err := r.Peek(9)
 	if err != nil && err != io.EOF {
 		return 0, err
 	}
-	x, n := uvarintFromBytes(p)
+	x, n, ok := uvarintFromCode(p)
+	if !ok {
+		return 0, io.ErrUnexpectedEOF
+	}
 	_, err = r.Discard(n)
 	return x, err
 }
@@ -73,15 +106,7 @@ func valueToBytes(p []byte, v value) (n int) {
 
 	case string:
 		p[0] = byte(typeSTR)
-		p = p[1:]
-		i := uvarintToBytes(p, uint64(len(x)))
-		p = p[i:]
-		if len(p) < len(x) {
-			n = 1 + i
-			panic("no space")
-		}
-		copy(p, []byte(x))
-		n = 1 + i + len(x)
+		n = 1 + stringToBytes(p[1:], x)
 
 	case bool:
 		p[0] = byte(typeBOOL)
@@ -92,6 +117,18 @@ func valueToBytes(p []byte, v value) (n int) {
 		}
 		n = 1 + 1
 
+	case time.Duration:
+		p[0] = byte(typeDURATION)
+		n = 1 + varintToBytes(p[1:], int64(x))
+
+	case time.Time:
+		p[0] = byte(typeTIME)
+		n = 1 + stringToBytes(p[1:], x.Format(time.RFC3339Nano))
+
+	case Decimal:
+		p[0] = byte(typeDECIMAL)
+		n = 1 + stringToBytes(p[1:], x.String())
+
 	default:
 		if v == nil {
 			p[0] = byte(typeNIL)
@@ -118,6 +155,26 @@ func valueFromBytes(p []byte) (value, int) {
 	case typeBOOL:
 		return p[0] != 0, 1 + 1
 
+	case typeDURATION:
+		x, n := varintFromBytes(p)
+		return time.Duration(x), 1 + n
+
+	case typeTIME:
+		k, i := uvarintFromBytes(p)
+		t, err := time.Parse(time.RFC3339Nano, string(p[i:i+int(k)]))
+		if err != nil {
+			panic(err)
+		}
+		return t, 1 + i + int(k)
+
+	case typeDECIMAL:
+		k, i := uvarintFromBytes(p)
+		d, err := parseDecimal(string(p[i : i+int(k)]))
+		if err != nil {
+			panic(err)
+		}
+		return d, 1 + i + int(k)
+
 	case typeNIL:
 		return nil, 1
 
@@ -135,34 +192,67 @@ func valueFromBuf(r *bufio.Reader) (value, error) {
 
 	switch c := typecode(b[0]); c {
 	case typeINT:
-		p, _ := r.Peek(9)
-		x, i := varintFromBytes(p)
-		_, err = r.Discard(i)
-		return int(x), err
+		x, err := uvarintFromBuf(r)
+		return int(u64ToI64(x)), err
 
 	case typeFLOAT:
-		p, _ := r.Peek(8)
-		_, err = r.Discard(len(p))
-		return math.Float64frombits(stdbinary.BigEndian.Uint64(p)), err
+		var p [8]byte
+		_, err = io.ReadFull(r, p[:])
+		return math.Float64frombits(stdbinary.BigEndian.Uint64(p[:])), err
 
 	case typeSTR:
-		p, _ := r.Peek(9)
-		k, i := uvarintFromBytes(p)
-		r.Discard(i)
-		p, _ = r.Peek(int(k))
-		_, err = r.Discard(len(p))
-		return string(p), err
+		return stringFromBuf(r)
 
 	case typeBOOL:
 		_, err = io.ReadFull(r, b[:1])
 		return b[0] != 0, err
 
+	case typeDURATION:
+		x, err := uvarintFromBuf(r)
+		return time.Duration(u64ToI64(x)), err
+
+	case typeTIME:
+		s, err := stringFromBuf(r)
+		if err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+
+	case typeDECIMAL:
+		s, err := stringFromBuf(r)
+		if err != nil {
+			return nil, err
+		}
+		return parseDecimal(s)
+
 	case typeNIL:
 		return nil, nil
 
 	default:
-		panic(errInvalidType{b[0]})
+		return nil, errInvalidType{b[0]}
+	}
+}
+
+func stringToBytes(p []byte, s string) int {
+	i := uvarintToBytes(p, uint64(len(s)))
+	if len(p)-i < len(s) {
+		panic("no space")
+	}
+	copy(p[i:], s)
+	return i + len(s)
+}
+
+func stringFromBuf(r *bufio.Reader) (string, error) {
+	k, err := uvarintFromBuf(r)
+	if err != nil {
+		return "", err
+	}
+	if k > maxStringLen {
+		return "", fmt.Errorf("string too long: %d bytes", k)
 	}
+	p := make([]byte, k)
+	_, err = io.ReadFull(r, p)
+	return string(p), err
 }
 
 type errInvalidType struct{ byte }
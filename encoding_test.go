@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 	"testing/quick"
+	"time"
 )
 
 var qcConf = &quick.Config{MaxCount: 1000}
@@ -81,12 +82,51 @@ func TestEncodingValuesTab(t *testing.T) {
 		math.MaxFloat64, -math.MaxFloat64,
 		true, false,
 		"", "foo", "1234567890",
+		time.Duration(0), time.Second, -90 * time.Minute,
+		time.Duration(math.MaxInt64), time.Duration(math.MinInt64),
 	}
 	for _, v := range tab {
 		testEncodingValue(t, v)
 	}
 }
 
+func TestEncodingTime(t *testing.T) {
+	tab := []time.Time{
+		time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		time.Date(2024, 1, 2, 15, 4, 5, 123456789, time.FixedZone("", -7*3600)),
+		{},
+	}
+	for _, x := range tab {
+		var b [40]byte
+		n := valueToBytes(b[:], x)
+		y, m := valueFromBytes(b[:])
+
+		if n != m {
+			t.Errorf("x=%v: size mismatch n=%d m=%d", x, n, m)
+		}
+		if !x.Equal(y.(time.Time)) {
+			t.Errorf("mismatch x=%v y=%v", x, y)
+		}
+	}
+}
+
+func TestEncodingDecimal(t *testing.T) {
+	tab := []string{"0", "12.50", "-0.001", "123456789012345678901234567890.5"}
+	for _, s := range tab {
+		x, _ := parseDecimal(s)
+		var b [50]byte
+		n := valueToBytes(b[:], x)
+		y, m := valueFromBytes(b[:])
+
+		if n != m {
+			t.Errorf("x=%v: size mismatch n=%d m=%d", x, n, m)
+		}
+		if y.(Decimal).String() != s {
+			t.Errorf("mismatch x=%v y=%v", x, y)
+		}
+	}
+}
+
 func qcEncodingValue(x value) bool {
 	var b [11]byte
 	var p []byte = b[:]
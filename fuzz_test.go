@@ -0,0 +1,56 @@
+package bcl_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/wkhere/bcl"
+)
+
+// FuzzInterpret checks that Interpret never panics, and that the optimized
+// code gives the same errors as the plain one; the steps are limited,
+// as loops can run arbitrarily long.
+// The corpus is in testdata/fuzz/FuzzInterpret.
+func FuzzInterpret(f *testing.F) {
+	f.Add(basicInput)
+	f.Add(big1)
+	f.Add(badbig1)
+	for _, s := range []string{
+		`def a { def b { def c { x = 1 } } }; print a.b.c.x`,
+		`var x = 1; if x > 0 { print "yes" } else { print "no" }`,
+		`print "a" * 3 + "b${1 + 2}c" ?? nil`,
+		`const n = 2; print [n, n ** 10, {k: n}]`,
+		`print 1d / 3d, 1h30m * 2, 2024-01-02T15:04:05Z`,
+		`print upper(trim("  x  ")), min(3, 1, 2), sort([3, 1, 2])`,
+		`print 1 << 63 >> 1 // -1 % 7`,
+		`for i in range(1 << 40) { def b "b" + i {} }`,
+	} {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		_, _, err := bcl.Interpret(input, bcl.OptMaxSteps(fuzzMaxSteps),
+			bcl.OptOutput(io.Discard), bcl.OptLogger(io.Discard),
+		)
+		_, _, err2 := bcl.Interpret(input, bcl.OptMaxSteps(fuzzMaxSteps), bcl.OptOptimize(2),
+			bcl.OptOutput(io.Discard), bcl.OptLogger(io.Discard),
+		)
+
+		// the optimized code takes fewer steps, so it can finish
+		// where the plain one runs out of them
+		if tooManySteps(err) || tooManySteps(err2) {
+			return
+		}
+		if (err == nil) != (err2 == nil) ||
+			err != nil && err.Error() != err2.Error() {
+			t.Errorf("optimized code error mismatch: %v, %v", err, err2)
+		}
+	})
+}
+
+const fuzzMaxSteps = 100_000
+
+func tooManySteps(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "too many steps")
+}
@@ -0,0 +1,264 @@
+package bcl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// instr is a decoded instruction, used by the optimizer and the verifier,
+// and for the asm and JSON forms of a Prog.
+type instr struct {
+	op   opcode
+	raw  opcode // as decoded, before narrowing the wide op into op
+	args []int  // operands; argConsts is the count followed by the indices
+	pos  int
+
+	target   *instr // for jumps
+	jumpedTo int    // count of jumps targeting this instruction
+	offset   int    // offset in the code
+}
+
+// widenJumps re-encodes the code, switching to the wide variants
+// of the jumping ops where needed. The far jumps, which didn't fit in u16
+// during parsing, are given as the absolute targets keyed by the offsets
+// of their operands.
+func (p *Prog) widenJumps(far map[int]int) {
+	code, err := decodeCode(p, far)
+	if err != nil {
+		panic(err) // the parsed code is always valid
+	}
+	p.code, p.positions = encodeCode(code)
+}
+
+// decodeCode gives the instructions of the Prog, with the jumps resolved;
+// the wide ops are turned into the plain ones, as the encoding chooses
+// the variant anyway. As it's also used for verifying the loaded code,
+// everything read is checked.
+func decodeCode(p *Prog, far map[int]int) ([]*instr, error) {
+	if p.positions != nil && p.positions.size != len(p.code) {
+		return nil, fmt.Errorf(
+			"invalid code: %d positions for %d bytes", p.positions.size, len(p.code),
+		)
+	}
+
+	var code []*instr
+	at := map[int]*instr{}
+	targets := map[*instr]int{}
+
+	for offset := 0; offset < len(p.code); {
+		op := opcode(p.code[offset])
+		in := &instr{op: op, raw: op, offset: offset}
+		if !in.op.valid() {
+			return nil, fmt.Errorf("invalid code at %04d: unknown opcode %d", offset, in.op)
+		}
+		at[offset] = in
+		r := codeReader{code: p.code, k: offset + 1}
+
+		for _, kind := range operands[in.op] {
+			switch kind {
+			case argByte:
+				in.args = append(in.args, r.byte())
+			case argNum, argConst:
+				in.args = append(in.args, r.uvarint())
+			case argConsts:
+				cnt := r.uvarint()
+				in.args = append(in.args, cnt)
+				for j := 0; j < cnt && r.err == nil; j++ {
+					in.args = append(in.args, r.uvarint())
+				}
+			case argJump, argLoop:
+				k := r.k
+				jump := r.u16()
+				if t, ok := far[k]; ok {
+					targets[in] = t
+				} else {
+					targets[in] = r.k + jumpSign(kind)*jump
+				}
+			case argJumpW, argLoopW:
+				jump := r.u32()
+				targets[in] = r.k + jumpSign(kind)*jump
+			}
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("invalid code at %04d: %s: %w", offset, in.op, r.err)
+		}
+		for op, wide := range wideOps {
+			if in.op == wide {
+				in.op = op
+			}
+		}
+
+		in.pos = p.posAt(r.k - 1)
+		code = append(code, in)
+		offset = r.k
+	}
+
+	for in, target := range targets {
+		in.target = at[target]
+		if in.target == nil {
+			return nil, fmt.Errorf(
+				"invalid code at %04d: %s: jump to %04d, not to an instruction",
+				in.offset, in.op, target,
+			)
+		}
+		in.target.jumpedTo++
+	}
+	return code, nil
+}
+
+// codeReader reads the operands, failing on the truncated code.
+type codeReader struct {
+	code []byte
+	k    int
+	err  error
+}
+
+var errTruncated = errors.New("truncated operand")
+
+func (r *codeReader) byte() int {
+	if r.err != nil || r.k >= len(r.code) {
+		r.fail(errTruncated)
+		return 0
+	}
+	r.k++
+	return int(r.code[r.k-1])
+}
+
+func (r *codeReader) uvarint() int {
+	if r.err != nil {
+		return 0
+	}
+	x, n, ok := uvarintFromCode(r.code[min(r.k, len(r.code)):])
+	switch {
+	case !ok:
+		r.fail(errTruncated)
+	case x > math.MaxInt32:
+		r.fail(fmt.Errorf("operand out of range: %d", x))
+	}
+	r.k += n
+	return int(x)
+}
+
+func (r *codeReader) u16() int {
+	if r.err != nil || r.k+jumpByteLength > len(r.code) {
+		r.fail(errTruncated)
+		return 0
+	}
+	r.k += jumpByteLength
+	return int(u16FromBytes(r.code[r.k-jumpByteLength:]))
+}
+
+func (r *codeReader) u32() int {
+	if r.err != nil || r.k+wideJumpByteLength > len(r.code) {
+		r.fail(errTruncated)
+		return 0
+	}
+	r.k += wideJumpByteLength
+	return int(u32FromBytes(r.code[r.k-wideJumpByteLength:]))
+}
+
+func (r *codeReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func encodeCode(code []*instr) ([]byte, *posTable) {
+	var buf [9]byte
+	size := func(in *instr) int {
+		k, n := 1, 0
+		for _, kind := range operands[in.op] {
+			switch kind {
+			case argByte:
+				k++
+				n++
+			case argNum, argConst:
+				k += uvarintToBytes(buf[:], uint64(in.args[n]))
+				n++
+			case argConsts:
+				cnt := in.args[n]
+				for _, x := range in.args[n : n+1+cnt] {
+					k += uvarintToBytes(buf[:], uint64(x))
+				}
+				n += 1 + cnt
+			case argJump, argLoop:
+				k += jumpByteLength
+			case argJumpW, argLoopW:
+				k += wideJumpByteLength
+			}
+		}
+		return k
+	}
+
+	// The jumps are widened until all of them fit;
+	// as the code only grows, this ends.
+	var offset int
+	for widened := true; widened; {
+		offset = 0
+		for _, in := range code {
+			in.offset = offset
+			offset += size(in)
+		}
+
+		widened = false
+		for _, in := range code {
+			if in.target == nil {
+				continue
+			}
+			kinds := operands[in.op]
+			kind := kinds[len(kinds)-1]
+			jump := jumpSign(kind) * (in.target.offset - (in.offset + size(in)))
+			if (kind == argJump || kind == argLoop) && jump > math.MaxUint16 {
+				in.op = wideOps[in.op]
+				widened = true
+			}
+		}
+	}
+
+	bytes := make([]byte, 0, offset)
+	positions := newPosTable(offset)
+	for _, in := range code {
+		bytes = append(bytes, byte(in.op))
+		n := 0
+		for _, kind := range operands[in.op] {
+			switch kind {
+			case argByte:
+				bytes = append(bytes, byte(in.args[n]))
+				n++
+			case argNum, argConst:
+				k := uvarintToBytes(buf[:], uint64(in.args[n]))
+				bytes = append(bytes, buf[:k]...)
+				n++
+			case argConsts:
+				cnt := in.args[n]
+				for _, x := range in.args[n : n+1+cnt] {
+					k := uvarintToBytes(buf[:], uint64(x))
+					bytes = append(bytes, buf[:k]...)
+				}
+				n += 1 + cnt
+			case argJump, argLoop:
+				end := len(bytes) + jumpByteLength
+				jump := jumpSign(kind) * (in.target.offset - end)
+				u16ToBytes(buf[:], uint16(jump))
+				bytes = append(bytes, buf[:jumpByteLength]...)
+			case argJumpW, argLoopW:
+				end := len(bytes) + wideJumpByteLength
+				jump := jumpSign(kind) * (in.target.offset - end)
+				u32ToBytes(buf[:], uint32(jump))
+				bytes = append(bytes, buf[:wideJumpByteLength]...)
+			}
+		}
+		for positions.size < len(bytes) {
+			positions.add(in.pos)
+		}
+	}
+	return bytes, positions
+}
+
+func jumpSign(kind operand) int {
+	if kind == argLoop || kind == argLoopW {
+		return -1
+	}
+	return +1
+}
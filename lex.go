@@ -5,6 +5,7 @@ package bcl
 import (
 	"fmt"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -39,6 +40,9 @@ type lexer struct {
 	posShift   int
 	width      int
 	tokens     chan token
+
+	interps []int     // open string interpolations, counting nested curly braces
+	last    tokenType // last emitted token
 }
 
 type stateFn func(*lexer) stateFn
@@ -57,6 +61,7 @@ func (l *lexer) emit(t tokenType) {
 		pos: l.pos + l.posShift,
 	}
 	l.start = l.pos
+	l.last = t
 }
 
 func (l *lexer) emitError(format string, args ...any) {
@@ -145,6 +150,18 @@ func (l *lexer) acceptRun(valid string) (accepted bool) {
 	return accepted
 }
 
+// acceptPattern consumes runes matching the pattern, where '9' stands
+// for any digit. On mismatch, the runes consumed so far are not restored.
+func (l *lexer) acceptPattern(pat string) bool {
+	for _, c := range pat {
+		if r := l.next(); r != c && !(c == '9' && isDigit(r)) {
+			l.backup()
+			return false
+		}
+	}
+	return true
+}
+
 // acceptRunFunc consumes a run of runes satisfying the predicate.
 func (l *lexer) acceptRunFunc(pred func(rune) bool) {
 	for pred(l.next()) {
@@ -192,6 +209,25 @@ func isAlphaNum(r rune) bool {
 	return isAlpha(r) || isDigit(r)
 }
 
+func isIdentChar(r rune) bool {
+	return isAlphaNum(r) || r == '_'
+}
+
+func isUnitChar(r rune) bool {
+	return isAlpha(r) || r == 'µ'
+}
+
+// afterOperand tells if the last token can end an operand of a binary
+// operator; it makes `<<` a shift rather than a start of heredoc.
+func (l *lexer) afterOperand() bool {
+	switch l.last {
+	case tINT, tFLOAT, tDECIMAL, tDURATION, tTIME, tSTR, tHEREDOC, tIDENT,
+		tINTERPEND, tTRUE, tFALSE, tNIL, tRPAREN, tRBRACKET:
+		return true
+	}
+	return false
+}
+
 // state finalizers
 
 func (l *lexer) fail(format string, args ...any) stateFn {
@@ -205,10 +241,16 @@ func (l *lexer) fail(format string, args ...any) stateFn {
 
 var keywords = map[string]tokenType{
 	"var":   tVAR,
+	"const": tCONST,
 	"def":   tDEF,
 	"eval":  tEVAL,
 	"print": tPRINT,
 	"bind":  tBIND,
+	"for":   tFOR,
+	"in":    tIN,
+	"if":    tIF,
+	"then":  tTHEN,
+	"else":  tELSE,
 	"true":  tTRUE,
 	"false": tFALSE,
 	"nil":   tNIL,
@@ -222,11 +264,14 @@ type twoRuneMatch struct {
 	typ tokenType
 }
 
-var twoRuneTokens = map[rune]twoRuneMatch{
-	'=': {'=', tEE},
-	'!': {'=', tBE},
-	'<': {'=', tLE},
-	'>': {'=', tGE},
+var twoRuneTokens = map[rune][]twoRuneMatch{
+	'=': {{'=', tEE}},
+	'!': {{'=', tBE}},
+	'<': {{'=', tLE}, {'<', tSHL}},
+	'>': {{'=', tGE}, {'>', tSHR}},
+	'*': {{'*', tSTARSTAR}},
+	'/': {{'/', tSLASHSLASH}},
+	'?': {{'?', tQUESTQUEST}, {'.', tQUESTDOT}},
 }
 
 var oneRuneTokens = map[rune]tokenType{
@@ -235,15 +280,21 @@ var oneRuneTokens = map[rune]tokenType{
 	'}': tRCURLY,
 	'(': tLPAREN,
 	')': tRPAREN,
-	//'[': tLBRACKET,
-	//']': tRBRACKET,
+	'[': tLBRACKET,
+	']': tRBRACKET,
 	'<': tLT,
 	'>': tGT,
 	'+': tPLUS,
 	'-': tMINUS,
 	'*': tSTAR,
 	'/': tSLASH,
+	'%': tPERCENT,
+	'&': tAMP,
+	'|': tPIPE,
+	'^': tCARET,
 	':': tCOLON,
+	'.': tDOT,
+	'@': tAT,
 	';': tSEMICOLON,
 	',': tCOMMA,
 }
@@ -263,7 +314,7 @@ const (
 
 func lexStart(l *lexer) stateFn {
 
-	var r2st matchState[twoRuneMatch]
+	var r2st matchState[[]twoRuneMatch]
 	var r1st matchState[tokenType]
 
 	switch r := l.next(); {
@@ -276,20 +327,40 @@ func lexStart(l *lexer) stateFn {
 		return lexLineComment
 	case r == '"':
 		return lexQuote
+	case r == '`':
+		return lexRawQuote
+	case r == '<' && l.peek() == '<' && !l.afterOperand():
+		return lexHeredoc
 	case isAlpha(r) || r == '_':
 		return lexKeywordOrIdent
 	case isDigit(r):
 		return lexNumber
+	case r == '{' && len(l.interps) > 0:
+		l.interps[len(l.interps)-1]++
+		l.emit(tLCURLY)
+		return lexStart
+	case r == '}' && len(l.interps) > 0:
+		n := &l.interps[len(l.interps)-1]
+		if *n == 0 {
+			l.interps = l.interps[:len(l.interps)-1]
+			return lexQuote
+		}
+		*n--
+		l.emit(tRCURLY)
+		return lexStart
 	case r2st.matches(r, twoRuneTokens):
-		if l.next() == r2st.match.r2 {
-			l.emit(r2st.match.typ)
-			return lexStart
+		r2 := l.next()
+		for _, m := range r2st.match {
+			if r2 == m.r2 {
+				l.emit(m.typ)
+				return lexStart
+			}
 		}
 		l.backup()
 		if !r1st.matches(r, oneRuneTokens) {
 			return l.fail(
 				"expected char %q to start token %q", r,
-				fmt.Sprintf("%c%c", r, r2st.match.r2),
+				fmt.Sprintf("%c%c", r, r2st.match[0].r2),
 			)
 		}
 		fallthrough
@@ -352,10 +423,19 @@ func lexNumber(l *lexer) stateFn {
 	}
 	l.acceptRun(digits)
 	r := l.peek()
+	if r == '-' && l.pos-l.start == 4 && l.acceptTime() {
+		return lexTime
+	}
 	if r == '.' || r == 'e' || r == 'E' {
 		return lexFloat
 	}
-	if r == '"' || isAlpha(r) {
+	if r == 'd' {
+		return lexDecimal
+	}
+	if isUnitChar(r) {
+		return lexDuration
+	}
+	if r == '"' {
 		l.unbackup()
 		return l.fail("invalid syntax `%s`", l.current())
 	}
@@ -387,8 +467,12 @@ func lexFloat(l *lexer) stateFn {
 		if !ok {
 			return l.fail("need more digits for an exponent")
 		}
+	} else if r := l.peek(); r == 'd' {
+		return lexDecimal
+	} else if isUnitChar(r) {
+		return lexDuration
 	}
-	if r := l.peek(); r == '"' || isAlpha(r) {
+	if r := l.peek(); r == '"' || isUnitChar(r) {
 		l.unbackup()
 		return l.fail("invalid syntax `%s`", l.current())
 	}
@@ -396,6 +480,73 @@ func lexFloat(l *lexer) stateFn {
 	return lexStart
 }
 
+// lexDecimal scans the 'd' suffix of a decimal like 12.50d.
+func lexDecimal(l *lexer) stateFn {
+	l.next()
+	if r := l.peek(); r == '"' || isUnitChar(r) || isDigit(r) {
+		l.unbackup()
+		return l.fail("invalid syntax `%s`", l.current())
+	}
+	l.emit(tDECIMAL)
+	return lexStart
+}
+
+// lexDuration scans a duration like 30s, 1h30m or 1.5h, having its first
+// number already consumed. Units are the same as in Go's time package.
+func lexDuration(l *lexer) stateFn {
+	for {
+		l.acceptRunFunc(isUnitChar)
+		if !l.acceptRun(digits) {
+			break
+		}
+		if l.accept(".") && !l.acceptRun(digits) {
+			return l.fail("need more digits after a dot")
+		}
+	}
+	if r := l.peek(); r == '"' || r == '.' {
+		l.unbackup()
+		return l.fail("invalid syntax `%s`", l.current())
+	}
+	if _, err := time.ParseDuration(l.current()); err != nil {
+		return l.fail("invalid syntax `%s`", l.current())
+	}
+	l.emit(tDURATION)
+	return lexStart
+}
+
+// acceptTime consumes the rest of RFC3339 timestamp, after the year digits.
+// If it doesn't match, the input is restored, to be scanned as a number.
+func (l *lexer) acceptTime() bool {
+	mark := l.pos - l.start
+	ok := l.acceptPattern("-99-99T99:99:99")
+	if ok && l.accept(".") {
+		ok = l.acceptRun(digits)
+	}
+	if ok && !l.accept("Z") {
+		ok = l.accept("+-") && l.acceptPattern("99:99")
+	}
+	if !ok {
+		l.pos = l.start + mark
+	}
+	return ok
+}
+
+// lexTime finishes scanning the timestamp accepted by acceptTime.
+func lexTime(l *lexer) stateFn {
+	if r := l.peek(); r == '"' || isAlphaNum(r) {
+		l.unbackup()
+		return l.fail("invalid syntax `%s`", l.current())
+	}
+	if _, err := time.Parse(time.RFC3339, l.current()); err != nil {
+		return l.fail("invalid time `%s`", l.current())
+	}
+	l.emit(tTIME)
+	return lexStart
+}
+
+// lexQuote scans a quoted string, or its fragment when the string has
+// `${expr}` interpolations; then the fragments are delimited by these.
+// `\${` is an escape for the literal `${`, skipped here like other escapes.
 func lexQuote(l *lexer) stateFn {
 loop:
 	for {
@@ -407,6 +558,13 @@ loop:
 			fallthrough
 		case eof, '\n':
 			return l.fail("unterminated quoted string")
+		case '$':
+			if l.peek() == '{' {
+				l.next()
+				l.emit(tINTERP)
+				l.interps = append(l.interps, 0)
+				return lexStart
+			}
 		case '"':
 			break loop
 		}
@@ -415,6 +573,66 @@ loop:
 		l.unbackup()
 		return l.fail("invalid syntax `%s`", l.current())
 	}
-	l.emit(tSTR)
+	if l.input[l.start] == '}' {
+		l.emit(tINTERPEND)
+	} else {
+		l.emit(tSTR)
+	}
 	return lexStart
 }
+
+// lexRawQuote scans a raw string in backticks, which can span many lines;
+// there are no escapes and no interpolations.
+func lexRawQuote(l *lexer) stateFn {
+	for {
+		switch l.next() {
+		case eof:
+			return l.fail("unterminated raw string")
+		case '`':
+			if r := l.peek(); isAlphaNum(r) {
+				l.unbackup()
+				return l.fail("invalid syntax `%s`", l.current())
+			}
+			l.emit(tSTR)
+			return lexStart
+		}
+	}
+}
+
+// lexHeredoc scans a heredoc: `<<ID` or `<<-ID` ending the line,
+// then the body lines, up to the line with the sole ID, possibly indented.
+// The whole text goes into the token; the parser extracts the body.
+func lexHeredoc(l *lexer) stateFn {
+	l.next()
+	l.accept("-")
+	if r := l.next(); !isAlpha(r) && r != '_' {
+		l.backup()
+		return l.fail("expected heredoc identifier after `<<`")
+	}
+	l.acceptRunFunc(isIdentChar)
+	id := strings.TrimLeft(l.current(), "<-")
+	l.accept("\r")
+	if !l.accept("\n") {
+		return l.fail("expected end of line after heredoc identifier")
+	}
+
+	for {
+		lineStart := l.pos - l.start
+		r := l.next()
+		for r != '\n' && r != eof {
+			r = l.next()
+		}
+		if r == '\n' {
+			l.backup()
+		}
+		line := l.input[l.start+lineStart : l.pos]
+		if strings.TrimRight(strings.TrimLeft(line, " \t"), "\r") == id {
+			l.emit(tHEREDOC)
+			return lexStart
+		}
+		if r == eof {
+			return l.fail("unterminated heredoc, expected %s", id)
+		}
+		l.next()
+	}
+}
@@ -36,7 +36,7 @@ var lexTab = []struct {
 }{
 	{0, "", tt{teof(0)}},
 
-	{1, "@", tt{terrchar('@', 1), tfail(1)}},
+	{1, "~", tt{terrchar('~', 1), tfail(1)}},
 	{2, `"`, tt{{tERR, "", errUnterminatedQuote, 1}, tfail(1)}},
 	{3, "\"\n", tt{{tERR, "", errUnterminatedQuote, 2}, tfail(2)}},
 	{4, "\"\n", tt{{tERR, "", errUnterminatedQuote, 2}, tfail(2)}},
@@ -123,6 +123,171 @@ var lexTab = []struct {
 		{tINT, "1", nil, 10},
 		teof(10),
 	}},
+
+	{57, `"a${x}b"`, tt{
+		{tINTERP, `"a${`, nil, 4},
+		{tIDENT, "x", nil, 5},
+		{tINTERPEND, `}b"`, nil, 8},
+		teof(8),
+	}},
+	{58, `"${"q"}"`, tt{
+		{tINTERP, `"${`, nil, 3},
+		{tSTR, `"q"`, nil, 6},
+		{tINTERPEND, `}"`, nil, 8},
+		teof(8),
+	}},
+	{59, `"${a{}}"`, tt{
+		{tINTERP, `"${`, nil, 3},
+		{tIDENT, "a", nil, 4},
+		{tLCURLY, "{", nil, 5},
+		{tRCURLY, "}", nil, 6},
+		{tINTERPEND, `}"`, nil, 8},
+		teof(8),
+	}},
+	{60, `"\${x}$"`, tt{{tSTR, `"\${x}$"`, nil, 8}, teof(8)}},
+	{61, `"${x`, tt{
+		{tINTERP, `"${`, nil, 3},
+		{tIDENT, "x", nil, 4},
+		teof(4),
+	}},
+
+	{62, "`a\n\"${b}\\`", tt{{tSTR, "`a\n\"${b}\\`", nil, 10}, teof(10)}},
+	{63, "`a", tt{{tERR, "", fmt.Errorf("unterminated raw string"), 2}, tfail(2)}},
+	{64, "`a`b", tt{terrinvalid("`a`b", 4), tfail(4)}},
+	{65, "<<EOF\nx\nEOF", tt{{tHEREDOC, "<<EOF\nx\nEOF", nil, 11}, teof(11)}},
+	{66, "<<-E\n  x\n  E\n1", tt{
+		{tHEREDOC, "<<-E\n  x\n  E", nil, 12},
+		{tINT, "1", nil, 14},
+		teof(14),
+	}},
+	{67, "<<E\r\nE\r\n", tt{{tHEREDOC, "<<E\r\nE\r", nil, 7}, teof(8)}},
+	{68, "<<E\nEE\nE", tt{{tHEREDOC, "<<E\nEE\nE", nil, 8}, teof(8)}},
+	{69, "<<E\nx", tt{
+		{tERR, "", fmt.Errorf("unterminated heredoc, expected E"), 5},
+		tfail(5),
+	}},
+	{70, "<<E x\n", tt{
+		{tERR, "", fmt.Errorf("expected end of line after heredoc identifier"), 3},
+		tfail(3),
+	}},
+	{71, "<<1", tt{
+		{tERR, "", fmt.Errorf("expected heredoc identifier after `<<`"), 2},
+		tfail(2),
+	}},
+
+	{72, "30s", tt{{tDURATION, "30s", nil, 3}, teof(3)}},
+	{73, "1h30m", tt{{tDURATION, "1h30m", nil, 5}, teof(5)}},
+	{74, "1.5h-1", tt{
+		{tDURATION, "1.5h", nil, 4},
+		{tMINUS, "-", nil, 5},
+		{tINT, "1", nil, 6},
+		teof(6),
+	}},
+	{75, "10µs", tt{{tDURATION, "10µs", nil, 5}, teof(5)}},
+	{76, "1h.5m", tt{terrinvalid("1h.", 3), tfail(3)}},
+	{77, "1e3s", tt{terrinvalid("1e3s", 4), tfail(4)}},
+	{78, "2024-01-02T15:04:05Z", tt{
+		{tTIME, "2024-01-02T15:04:05Z", nil, 20},
+		teof(20),
+	}},
+	{79, "2024-01-02T15:04:05.123+02:00", tt{
+		{tTIME, "2024-01-02T15:04:05.123+02:00", nil, 29},
+		teof(29),
+	}},
+	{80, "2024-01-02", tt{
+		{tINT, "2024", nil, 4},
+		{tMINUS, "-", nil, 5},
+		{tINT, "01", nil, 7},
+		{tMINUS, "-", nil, 8},
+		{tINT, "02", nil, 10},
+		teof(10),
+	}},
+	{81, "2024-01-02T15", tt{
+		{tINT, "2024", nil, 4},
+		{tMINUS, "-", nil, 5},
+		{tINT, "01", nil, 7},
+		{tMINUS, "-", nil, 8},
+		terrinvalid("02T15", 13),
+		tfail(13),
+	}},
+	{82, "2024-13-01T00:00:00Z", tt{
+		{tERR, "", fmt.Errorf("invalid time `2024-13-01T00:00:00Z`"), 20},
+		tfail(20),
+	}},
+	{83, "2024-01-02T15:04:05Zx", tt{
+		terrinvalid("2024-01-02T15:04:05Zx", 21),
+		tfail(21),
+	}},
+
+	{84, "12.50d", tt{{tDECIMAL, "12.50d", nil, 6}, teof(6)}},
+	{85, "12d+1", tt{
+		{tDECIMAL, "12d", nil, 3},
+		{tPLUS, "+", nil, 4},
+		{tINT, "1", nil, 5},
+		teof(5),
+	}},
+	{86, "1dx", tt{terrinvalid("1dx", 3), tfail(3)}},
+	{87, "1e3d", tt{terrinvalid("1e3d", 4), tfail(4)}},
+	{88, "0x1d", tt{{tINT, "0x1d", nil, 4}, teof(4)}},
+
+	{89, "x @str", tt{
+		{tIDENT, "x", nil, 1},
+		{tAT, "@", nil, 3},
+		{tIDENT, "str", nil, 6},
+		teof(6),
+	}},
+
+	{90, "1<<2>>x", tt{
+		{tINT, "1", nil, 1},
+		{tSHL, "<<", nil, 3},
+		{tINT, "2", nil, 4},
+		{tSHR, ">>", nil, 6},
+		{tIDENT, "x", nil, 7},
+		teof(7),
+	}},
+	{91, "x <<EOF\n", tt{
+		{tIDENT, "x", nil, 1},
+		{tSHL, "<<", nil, 4},
+		{tIDENT, "EOF", nil, 7},
+		teof(8),
+	}},
+	{92, "=<<E\nE", tt{
+		{tEQ, "=", nil, 1},
+		{tHEREDOC, "<<E\nE", nil, 6},
+		teof(6),
+	}},
+	{93, "a**b//c%d", tt{
+		{tIDENT, "a", nil, 1},
+		{tSTARSTAR, "**", nil, 3},
+		{tIDENT, "b", nil, 4},
+		{tSLASHSLASH, "//", nil, 6},
+		{tIDENT, "c", nil, 7},
+		{tPERCENT, "%", nil, 8},
+		{tIDENT, "d", nil, 9},
+		teof(9),
+	}},
+	{94, "&|^", tt{
+		{tAMP, "&", nil, 1},
+		{tPIPE, "|", nil, 2},
+		{tCARET, "^", nil, 3},
+		teof(3),
+	}},
+	{95, "[a]<<2", tt{
+		{tLBRACKET, "[", nil, 1},
+		{tIDENT, "a", nil, 2},
+		{tRBRACKET, "]", nil, 3},
+		{tSHL, "<<", nil, 5},
+		{tINT, "2", nil, 6},
+		teof(6),
+	}},
+	{96, "a?.b??c", tt{
+		{tIDENT, "a", nil, 1},
+		{tQUESTDOT, "?.", nil, 3},
+		{tIDENT, "b", nil, 4},
+		{tQUESTQUEST, "??", nil, 6},
+		{tIDENT, "c", nil, 7},
+		teof(7),
+	}},
 }
 
 func TestLexerSingleInput(t *testing.T) {
@@ -158,12 +323,12 @@ func Example_lexer() {
 	runExample("0")
 	runExample("1")
 	runExample("-3.14")
-	runExample("@")
+	runExample("~")
 	// Output:
 	// {tINT "0" 1}{tEOF "" 1}
 	// {tINT "1" 1}{tEOF "" 1}
 	// {tMINUS "-" 1}{tFLOAT "3.14" 5}{tEOF "" 5}
-	// {tERR "unknown char U+0040 '@'" 1}{tFAIL "" 1}
+	// {tERR "unknown char U+007E '~'" 1}{tFAIL "" 1}
 }
 
 func runExample(s string) {
@@ -47,7 +47,11 @@ func (lc *lineCalc) lineAt(pos int) int {
 	return line
 }
 
+// format gives "line:column" for a given position, or "?" if it's unknown.
 func (lc *lineCalc) format(pos int) string {
+	if pos < 0 {
+		return "?"
+	}
 	l, p := lc.lineColAt(pos)
 	return fmt.Sprintf("%d:%d", l, p)
 }
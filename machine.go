@@ -3,11 +3,17 @@ package bcl
 import (
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
+	"time"
 )
 
-type vmConfig struct{ trace bool }
+type vmConfig struct {
+	trace bool
+
+	maxStack      int
+	maxBlockDepth int
+	maxSteps      int
+}
 
 func execute(p *Prog, cf vmConfig) ([]Block, Binding, execStats, error) {
 	vm := &vm{
@@ -15,6 +21,13 @@ func execute(p *Prog, cf vmConfig) ([]Block, Binding, execStats, error) {
 		trace:  cf.trace,
 		prog:   p,
 		pc:     0,
+
+		stack:      make([]value, 0, stackInitCap),
+		blockStack: make([]Block, 0, blockStackInitCap),
+
+		maxStack:      cf.maxStack,
+		maxBlockDepth: cf.maxBlockDepth,
+		maxSteps:      cf.maxSteps,
 	}
 	err := vm.run()
 
@@ -26,10 +39,14 @@ type vm struct {
 	prog  *Prog
 	pc    int
 	tos   int
-	stack [stackSize]value
+	stack []value
 
 	blockTos   int
-	blockStack [blockStackSize]Block
+	blockStack []Block
+
+	maxStack      int
+	maxBlockDepth int
+	maxSteps      int
 
 	output io.Writer
 	log    io.Writer
@@ -42,9 +59,13 @@ type vm struct {
 	stats execStats
 }
 
+// The stacks grow as needed, up to the limits given by the options.
 const (
-	stackSize      = 1024
-	blockStackSize = 16
+	stackInitCap      = 256
+	blockStackInitCap = 16
+
+	defaultMaxStack      = 1 << 16
+	defaultMaxBlockDepth = 256
 
 	bindMaxNBlocks = 64
 )
@@ -73,6 +94,17 @@ func (vm *vm) run() error {
 		vm.pc += 2
 		return int(x)
 	}
+	readU32 := func() int {
+		x := u32FromBytes(vm.prog.code[vm.pc : vm.pc+4])
+		vm.pc += 4
+		return int(x)
+	}
+	readJump := func(wide bool) int {
+		if wide {
+			return readU32()
+		}
+		return readU16()
+	}
 	readUvarint := func() int {
 		x, n := uvarintFromBytes(vm.prog.code[vm.pc:])
 		vm.pc += n
@@ -83,7 +115,11 @@ func (vm *vm) run() error {
 	}
 
 	push := func(v value) {
-		vm.stack[vm.tos] = v
+		if vm.tos == len(vm.stack) {
+			vm.stack = append(vm.stack, v)
+		} else {
+			vm.stack[vm.tos] = v
+		}
 		vm.tos++
 		vm.stats.tosMax = max(vm.stats.tosMax, vm.tos)
 	}
@@ -117,11 +153,32 @@ func (vm *vm) run() error {
 	setField := func(name string, v value) {
 		vm.blockStack[vm.blockTos-1].Fields[name] = v
 	}
+	pushBlock := func(b Block) error {
+		if vm.blockTos >= vm.maxBlockDepth {
+			return vm.runtimeError("blocks nested too deeply (max %d)", vm.maxBlockDepth)
+		}
+		if vm.blockTos == len(vm.blockStack) {
+			vm.blockStack = append(vm.blockStack, b)
+		} else {
+			vm.blockStack[vm.blockTos] = b
+		}
+		vm.blockTos++
+		vm.stats.blockTosMax = max(vm.stats.blockTosMax, vm.blockTos)
+		return nil
+	}
 
 	for {
+		// an instruction pushes at most one value more than it pops,
+		// so the check is done here, reporting the position of the last one
+		if vm.tos > vm.maxStack {
+			return vm.runtimeError("stack overflow (max %d)", vm.maxStack)
+		}
+		if vm.maxSteps > 0 && vm.stats.opsRead >= vm.maxSteps {
+			return vm.runtimeError("too many steps (max %d)", vm.maxSteps)
+		}
 		if vm.trace {
 			printStack(vm.output, vm.stack[:vm.tos])
-			vm.prog.disasmInstr(vm.pc)
+			vm.prog.disasmInstr(vm.output, vm.pc)
 		}
 
 		switch instr := readOp(); instr {
@@ -150,79 +207,157 @@ func (vm *vm) run() error {
 			// ( -- nil )
 			push(nil)
 
-		case opEQ, opLT, opGT, opADD, opSUB, opMUL, opDIV:
+		case opEQ, opLT, opGT, opADD, opSUB, opMUL, opDIV,
+			opMOD, opFLOORDIV, opPOW, opBAND, opBOR, opBXOR, opSHL, opSHR,
+			opNE, opLE, opGE:
 			// ( a b -- c )
-			switch {
-			case isNumber(peek(1)) && isNumber(peek(0)):
-				if b := peek(0); instr == opDIV && isInt(b) && b == 0 {
-					return vm.runtimeError("division by int zero")
-				}
-				b, a := pop(), pop()
-				push(binopNumeric(instr, a, b))
+			x, err := binop(instr, peek(1), peek(0))
+			if err != nil {
+				return vm.runtimeError("%s", err)
+			}
+			pop()
+			set(x)
 
-			case (instr == opLT || instr == opGT || instr == opADD) &&
-				isString(peek(1)) && isString(peek(0)):
-				b, a := pop().(string), pop().(string)
-				push(binopString(instr, a, b))
+		case opNEG, opUNPLUS, opTOSTR:
+			// ( a -- b )
+			x, err := unop(instr, peek(0))
+			if err != nil {
+				return vm.runtimeError("%s", err)
+			}
+			set(x)
 
-			case instr == opADD && isString(peek(1)) && isInt(peek(0)):
-				b, a := pop().(int), pop().(string)
-				push(a + strconv.Itoa(b))
+		case opNOT:
+			// ( a -- b )
+			set(isFalsey(peek(0)))
 
-			case instr == opADD && isString(peek(1)) && isFloat(peek(0)):
-				b, a := pop().(float64), pop().(string)
-				push(a + strconv.FormatFloat(b, 'f', -1, 64))
+		case opCONV:
+			// ( a -- b )
+			code := typecode(readByte())
+			x, err := convert(peek(0), code)
+			if err != nil {
+				return vm.runtimeError("@%s: %s", convTypeName(code), err)
+			}
+			set(x)
 
-			case instr == opADD && isString(peek(1)) && peek(0) == nil:
-				pop()
+		case opCALL:
+			// ( a1 ..aN -- x )
+			name := readConst().(string)
+			argc := readUvarint()
+			b, ok := builtins[name]
+			if !ok {
+				return vm.runtimeError("unknown function '%s'", name)
+			}
+			x, err := b.fn(vm.stack[vm.tos-argc : vm.tos])
+			if err != nil {
+				return vm.runtimeError("%s: %s", name, err)
+			}
+			vm.tos -= argc
+			push(x)
+
+		case opLIST:
+			// ( a1 ..aN -- list )
+			n := readUvarint()
+			list := make([]value, n)
+			copy(list, vm.stack[vm.tos-n:vm.tos])
+			vm.tos -= n
+			push(list)
+
+		case opMAP:
+			// ( k1 v1 ..kN vN -- map )
+			n := readUvarint()
+			m := make(map[string]value, n)
+			for i := vm.tos - 2*n; i < vm.tos; i += 2 {
+				k, ok := vm.stack[i].(string)
+				if !ok {
+					return vm.runtimeError("map key: invalid type: %s", vtype(vm.stack[i]))
+				}
+				m[k] = vm.stack[i+1]
+			}
+			vm.tos -= 2 * n
+			push(m)
 
-			case instr == opMUL && isString(peek(1)) && isInt(peek(0)):
-				b, a := pop().(int), pop().(string)
-				push(strings.Repeat(a, b))
+		case opJUMP, opJUMPW:
+			// ( -- )
+			vm.pc += readJump(instr == opJUMPW)
 
-			case instr == opEQ:
-				b, a := pop(), pop()
-				push(a == b)
+		case opLOOP, opLOOPW:
+			// ( -- )
+			vm.pc -= readJump(instr == opLOOPW)
 
-			default:
+		case opFORRANGE, opFORRANGEW:
+			// ( -- )
+			iterSlot, endSlot := readUvarint(), readUvarint()
+			jump := readJump(instr == opFORRANGEW)
+			i, ok1 := vm.stack[iterSlot].(int)
+			end, ok2 := vm.stack[endSlot].(int)
+			if !ok1 || !ok2 {
 				return vm.runtimeError(
-					"%s: invalid types: %s, %s", instr, vtype(peek(1)), vtype(peek(0)),
+					"range: invalid types: %s, %s, expected int",
+					vtype(vm.stack[iterSlot]), vtype(vm.stack[endSlot]),
 				)
 			}
-
-		case opNEG:
-			// ( a -- b )
-			if !isNumber(peek(0)) {
-				return vm.runtimeError("NEG: invalid type: %s, expected number", vtype(peek(0)))
-			}
-			set(unopNumeric(instr, peek(0)))
-
-		case opUNPLUS:
-			// ( a -- a )
-			if !isNumber(peek(0)) {
-				return vm.runtimeError("UNPLUS: invalid type: %s, expected number", vtype(peek(0)))
+			if i >= end {
+				vm.pc += jump
 			}
-			// do nothing
 
-		case opNOT:
-			// ( a -- b )
-			set(isFalsey(peek(0)))
-
-		case opJUMP:
+		case opFORSTEP, opFORSTEPW:
 			// ( -- )
-			vm.pc += readU16()
+			iterSlot, endSlot, stepSlot := readUvarint(), readUvarint(), readUvarint()
+			jump := readJump(instr == opFORSTEPW)
+			i, ok1 := vm.stack[iterSlot].(int)
+			end, ok2 := vm.stack[endSlot].(int)
+			step, ok3 := vm.stack[stepSlot].(int)
+			if !ok1 || !ok2 || !ok3 {
+				return vm.runtimeError(
+					"range: invalid types: %s, %s, %s, expected int",
+					vtype(vm.stack[iterSlot]), vtype(vm.stack[endSlot]),
+					vtype(vm.stack[stepSlot]),
+				)
+			}
+			if step == 0 {
+				return vm.runtimeError("range: zero step")
+			}
+			if step > 0 && i >= end || step < 0 && i <= end {
+				vm.pc += jump
+			}
 
-		case opLOOP:
-			// ( -- )
-			vm.pc -= readU16()
+		case opFORLIST, opFORLISTW:
+			// ( -- x ), or ( -- ) when jumping out
+			iterSlot, listSlot := readUvarint(), readUvarint()
+			jump := readJump(instr == opFORLISTW)
+			i, ok := vm.stack[iterSlot].(int)
+			if !ok {
+				return vm.runtimeError(
+					"for: invalid type of the index: %s, expected int",
+					vtype(vm.stack[iterSlot]),
+				)
+			}
+			list, ok := vm.stack[listSlot].([]value)
+			if !ok && vm.stack[listSlot] != nil {
+				return vm.runtimeError(
+					"for: cannot iterate over %s", vtype(vm.stack[listSlot]),
+				)
+			}
+			if i >= len(list) {
+				vm.pc += jump
+			} else {
+				push(list[i])
+			}
 
-		case opJFALSE:
+		case opJFALSE, opJFALSEW:
 			// ( a -- a )
-			jump := readU16()
+			jump := readJump(instr == opJFALSEW)
 			if isFalsey(peek(0)) {
 				vm.pc += jump
 			}
 
+		case opJNOTNIL, opJNOTNILW:
+			// ( a -- a )
+			jump := readJump(instr == opJNOTNILW)
+			if peek(0) != nil {
+				vm.pc += jump
+			}
+
 		case opPOP:
 			// ( a -- )
 			vm.tos--
@@ -233,7 +368,14 @@ func (vm *vm) run() error {
 
 		case opPRINT:
 			// ( a -- )
-			fmt.Fprintln(vm.output, pop())
+			x := pop()
+			switch t := x.(type) {
+			case time.Time:
+				x = t.Format(time.RFC3339Nano)
+			case []value, map[string]value:
+				x, _ = stringify(t)
+			}
+			fmt.Fprintln(vm.output, x)
 
 		case opGETLOCAL:
 			// ( -- x )
@@ -252,9 +394,28 @@ func (vm *vm) run() error {
 				Name:   readConst().(string),
 				Fields: map[string]any{},
 			}
-			vm.blockStack[vm.blockTos] = blk
-			vm.blockTos++
-			vm.stats.blockTosMax = max(vm.stats.blockTosMax, vm.blockTos)
+			if err := pushBlock(blk); err != nil {
+				return err
+			}
+
+		case opDYNBLOCK:
+			// ( name -- )
+			typ := readConst().(string)
+			name, ok := peek(0).(string)
+			if !ok {
+				return vm.runtimeError(
+					"block name: invalid type: %s, expected string", vtype(peek(0)),
+				)
+			}
+			pop()
+			err := pushBlock(Block{
+				Type:   typ,
+				Name:   name,
+				Fields: map[string]any{},
+			})
+			if err != nil {
+				return err
+			}
 
 		case opENDBLOCK:
 			// ( -- )
@@ -266,9 +427,8 @@ func (vm *vm) run() error {
 					parent = &vm.blockStack[i-1]
 					k      = child.key()
 				)
-				// note: good to have the following safety check, although
-				// with the current syntax and with the key=type.name,
-				// it is impossible to trigger it
+				// note: with the key=type.name, this can be triggered
+				// by a repeated child block, or by a block with dynamic name
 				if _, ok := parent.Fields[k]; ok {
 					return vm.runtimeError("child %s duplicate at parent", k)
 				}
@@ -288,11 +448,28 @@ func (vm *vm) run() error {
 			}
 			push(v)
 
+		case opTRYFIELD:
+			// ( -- x )
+			v, _ := getField(readConst().(string))
+			push(v)
+
 		case opSETFIELD:
 			// ( x -- x )
 			name := readConst().(string)
 			setField(name, peek(0))
 
+		case opGETPATH, opTRYPATH:
+			// ( -- x )
+			path := make([]string, readUvarint())
+			for i := range path {
+				path[i] = readConst().(string)
+			}
+			v, err := vm.getPath(path, instr == opTRYPATH)
+			if err != nil {
+				return err
+			}
+			push(v)
+
 		case opDEFUBIND:
 			// ( -- )
 			if vm.umbrellaOpen {
@@ -442,16 +619,84 @@ func (vm *vm) bind(bindCode byte, blockType string, subSelectors []value) error
 	return nil
 }
 
+// getPath resolves the path to a field of one of the completed blocks.
+// Block keys are matched as in [Block.key], trying the type.name pair
+// before the type alone; when a key is repeated, the latest block wins.
+// When optional, an unresolved path gives nil instead of an error.
+func (vm *vm) getPath(path []string, optional bool) (value, error) {
+	var typeFound bool
+
+	for i := len(vm.result) - 1; i >= 0; i-- {
+		b := &vm.result[i]
+		if b.Type != path[0] {
+			continue
+		}
+		typeFound = true
+
+		var v value
+		var ok bool
+		switch {
+		case len(path) > 1 && b.Name == path[1]:
+			v, ok = lookupPath(*b, path[2:])
+		case b.Name == "":
+			v, ok = lookupPath(*b, path[1:])
+		}
+		if !ok {
+			continue
+		}
+		if _, isBlock := v.(Block); isBlock {
+			return nil, vm.runtimeError(
+				"path '%s' refers to a block, expected field", strings.Join(path, "."),
+			)
+		}
+		return v, nil
+	}
+
+	if optional {
+		return nil, nil
+	}
+	if !typeFound {
+		return nil, vm.runtimeError("path '%s': no blocks of type %s",
+			strings.Join(path, "."), path[0],
+		)
+	}
+	return nil, vm.runtimeError("path '%s' not resolved as block field",
+		strings.Join(path, "."),
+	)
+}
+
+func lookupPath(b Block, path []string) (value, bool) {
+	if len(path) == 0 {
+		return b, true
+	}
+	if len(path) > 1 {
+		if child, ok := b.Fields[path[0]+"."+path[1]].(Block); ok {
+			if v, ok := lookupPath(child, path[2:]); ok {
+				return v, true
+			}
+		}
+	}
+	v, ok := b.Fields[path[0]]
+	if !ok || len(path) == 1 {
+		return v, ok
+	}
+	child, ok := v.(Block)
+	if !ok {
+		return nil, false
+	}
+	return lookupPath(child, path[1:])
+}
+
 func (vm *vm) runtimeError(format string, a ...any) error {
 	b := new(strings.Builder)
-	pos := vm.prog.positions[vm.pc-1]
+	pos := vm.prog.posAt(vm.pc - 1)
 	fmt.Fprintf(b, "runtime error: line %s: ", vm.prog.linePos.format(pos))
 	fmt.Fprintf(b, format, a...)
 	return &runtimeErr{b.String()}
 }
 
 func (vm *vm) warning(format string, a ...any) {
-	pos := vm.prog.positions[vm.pc-1]
+	pos := vm.prog.posAt(vm.pc - 1)
 	w := vm.prog.log
 	fmt.Fprintf(w, "WARNING: line %s: ", vm.prog.linePos.format(pos))
 	fmt.Fprintf(w, format+"\n", a...)
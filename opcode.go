@@ -47,6 +47,109 @@ const (
 	opBIND
 	opDEFUBIND
 	opENDUBIND
+
+	// since bytecode 2.1:
+	opGETPATH
+	opDYNBLOCK
+	opFORRANGE
+	opTOSTR
+	opCONV
+	opMOD
+	opFLOORDIV
+	opPOW
+	opBAND
+	opBOR
+	opBXOR
+	opSHL
+	opSHR
+	opCALL
+	opLIST
+	opMAP
+	opJNOTNIL
+	opTRYFIELD
+	opTRYPATH
+	opNE
+	opLE
+	opGE
+	opJUMPW
+	opLOOPW
+	opJFALSEW
+	opJNOTNILW
+	opFORRANGEW
+
+	// since bytecode 4.1:
+	opFORLIST
+	opFORLISTW
+
+	// since bytecode 4.2:
+	opFORSTEP
+	opFORSTEPW
 )
 
 //go:generate stringer -type opcode -trimprefix op
+
+// operand is the kind of an instruction operand, encoded after the opcode.
+type operand byte
+
+const (
+	argByte   operand = iota + 1 // a byte, like a type code
+	argNum                       // uvarint, like a slot or a count
+	argConst                     // uvarint index of a constant
+	argConsts                    // uvarint count, then as many constant indices
+	argJump                      // u16 forward jump from the end of the instruction
+	argLoop                      // u16 backward jump from the end of the instruction
+	argJumpW                     // u32 forward jump, for the wide variant of the op
+	argLoopW                     // u32 backward jump, for the wide variant of the op
+)
+
+// operands gives the operand layout of each opcode;
+// the opcodes missing here have no operands.
+var operands = map[opcode][]operand{
+	opSETLOCAL: {argNum},
+	opGETLOCAL: {argNum},
+	opDEFBLOCK: {argConst, argConst},
+	opSETFIELD: {argConst},
+	opGETFIELD: {argConst},
+	opCONST:    {argConst},
+	opJUMP:     {argJump},
+	opLOOP:     {argLoop},
+	opJFALSE:   {argJump},
+	opPOPN:     {argNum},
+	opBIND:     {argByte, argConst, argConsts},
+	opGETPATH:  {argConsts},
+	opDYNBLOCK: {argConst},
+	opFORRANGE: {argNum, argNum, argJump},
+	opCONV:     {argByte},
+	opCALL:     {argConst, argNum},
+	opLIST:     {argNum},
+	opMAP:      {argNum},
+	opJNOTNIL:  {argJump},
+	opTRYFIELD: {argConst},
+	opTRYPATH:  {argConsts},
+
+	opJUMPW:     {argJumpW},
+	opLOOPW:     {argLoopW},
+	opJFALSEW:   {argJumpW},
+	opJNOTNILW:  {argJumpW},
+	opFORRANGEW: {argNum, argNum, argJumpW},
+	opFORLIST:   {argNum, argNum, argJump},
+	opFORLISTW:  {argNum, argNum, argJumpW},
+	opFORSTEP:   {argNum, argNum, argNum, argJump},
+	opFORSTEPW:  {argNum, argNum, argNum, argJumpW},
+}
+
+// wideOps maps the jumping opcodes to their variants with u32 operands,
+// used when the jump doesn't fit in u16.
+var wideOps = map[opcode]opcode{
+	opJUMP:     opJUMPW,
+	opLOOP:     opLOOPW,
+	opJFALSE:   opJFALSEW,
+	opJNOTNIL:  opJNOTNILW,
+	opFORRANGE: opFORRANGEW,
+	opFORLIST:  opFORLISTW,
+	opFORSTEP:  opFORSTEPW,
+}
+
+func (op opcode) valid() bool {
+	return int(op) < len(_opcode_index)-1
+}
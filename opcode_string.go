@@ -41,11 +41,42 @@ func _() {
 	_ = x[opBIND-30]
 	_ = x[opDEFUBIND-31]
 	_ = x[opENDUBIND-32]
+	_ = x[opGETPATH-33]
+	_ = x[opDYNBLOCK-34]
+	_ = x[opFORRANGE-35]
+	_ = x[opTOSTR-36]
+	_ = x[opCONV-37]
+	_ = x[opMOD-38]
+	_ = x[opFLOORDIV-39]
+	_ = x[opPOW-40]
+	_ = x[opBAND-41]
+	_ = x[opBOR-42]
+	_ = x[opBXOR-43]
+	_ = x[opSHL-44]
+	_ = x[opSHR-45]
+	_ = x[opCALL-46]
+	_ = x[opLIST-47]
+	_ = x[opMAP-48]
+	_ = x[opJNOTNIL-49]
+	_ = x[opTRYFIELD-50]
+	_ = x[opTRYPATH-51]
+	_ = x[opNE-52]
+	_ = x[opLE-53]
+	_ = x[opGE-54]
+	_ = x[opJUMPW-55]
+	_ = x[opLOOPW-56]
+	_ = x[opJFALSEW-57]
+	_ = x[opJNOTNILW-58]
+	_ = x[opFORRANGEW-59]
+	_ = x[opFORLIST-60]
+	_ = x[opFORLISTW-61]
+	_ = x[opFORSTEP-62]
+	_ = x[opFORSTEPW-63]
 }
 
-const _opcode_name = "NOPRETPRINTSETLOCALGETLOCALDEFBLOCKENDBLOCKSETFIELDGETFIELDCONSTNILZEROONETRUEFALSENOTEQLTGTADDSUBMULDIVNEGUNPLUSJUMPLOOPJFALSEPOPPOPNBINDDEFUBINDENDUBIND"
+const _opcode_name = "NOPRETPRINTSETLOCALGETLOCALDEFBLOCKENDBLOCKSETFIELDGETFIELDCONSTNILZEROONETRUEFALSENOTEQLTGTADDSUBMULDIVNEGUNPLUSJUMPLOOPJFALSEPOPPOPNBINDDEFUBINDENDUBINDGETPATHDYNBLOCKFORRANGETOSTRCONVMODFLOORDIVPOWBANDBORBXORSHLSHRCALLLISTMAPJNOTNILTRYFIELDTRYPATHNELEGEJUMPWLOOPWJFALSEWJNOTNILWFORRANGEWFORLISTFORLISTWFORSTEPFORSTEPW"
 
-var _opcode_index = [...]uint8{0, 3, 6, 11, 19, 27, 35, 43, 51, 59, 64, 67, 71, 74, 78, 83, 86, 88, 90, 92, 95, 98, 101, 104, 107, 113, 117, 121, 127, 130, 134, 138, 146, 154}
+var _opcode_index = [...]uint16{0, 3, 6, 11, 19, 27, 35, 43, 51, 59, 64, 67, 71, 74, 78, 83, 86, 88, 90, 92, 95, 98, 101, 104, 107, 113, 117, 121, 127, 130, 134, 138, 146, 154, 161, 169, 177, 182, 186, 189, 197, 200, 204, 207, 211, 214, 217, 221, 225, 228, 235, 243, 250, 252, 254, 256, 261, 266, 273, 281, 290, 297, 305, 312, 320}
 
 func (i opcode) String() string {
 	if i >= opcode(len(_opcode_index)-1) {
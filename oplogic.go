@@ -1,6 +1,21 @@
 package bcl
 
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binopNumeric gives nil when the operation is not supported.
 func binopNumeric(op opcode, a, b value) value {
+	if isDecimal(a) || isDecimal(b) {
+		return binopDecimal(op, a, b)
+	}
+
 	switch va := a.(type) {
 	case int:
 		switch vb := b.(type) {
@@ -21,6 +36,26 @@ func binopNumeric(op opcode, a, b value) value {
 				return va * vb
 			case opDIV:
 				return va / vb
+			case opFLOORDIV:
+				return floorDivInt(va, vb)
+			case opMOD:
+				return va - floorDivInt(va, vb)*vb
+			case opPOW:
+				if vb < 0 {
+					return math.Pow(float64(va), float64(vb))
+				}
+				return powInt(va, vb)
+
+			case opBAND:
+				return va & vb
+			case opBOR:
+				return va | vb
+			case opBXOR:
+				return va ^ vb
+			case opSHL:
+				return va << vb
+			case opSHR:
+				return va >> vb
 			}
 		case float64:
 			ca := float64(va)
@@ -40,6 +75,12 @@ func binopNumeric(op opcode, a, b value) value {
 				return ca * vb
 			case opDIV:
 				return ca / vb
+			case opFLOORDIV:
+				return math.Floor(ca / vb)
+			case opMOD:
+				return floorModFloat(ca, vb)
+			case opPOW:
+				return math.Pow(ca, vb)
 			}
 
 		}
@@ -67,6 +108,12 @@ func binopNumeric(op opcode, a, b value) value {
 			return va * cb
 		case opDIV:
 			return va / cb
+		case opFLOORDIV:
+			return math.Floor(va / cb)
+		case opMOD:
+			return floorModFloat(va, cb)
+		case opPOW:
+			return math.Pow(va, cb)
 		}
 
 	}
@@ -74,6 +121,113 @@ func binopNumeric(op opcode, a, b value) value {
 	return nil
 }
 
+// floorDivInt rounds the quotient towards negative infinity,
+// so that together with the modulo it's consistent for negative numbers.
+func floorDivInt(a, b int) int {
+	q := a / b
+	if (a%b != 0) && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// floorModFloat gives the modulo with the sign of the divisor,
+// like floorDivInt.
+func floorModFloat(a, b float64) float64 {
+	m := math.Mod(a, b)
+	if m != 0 && (m < 0) != (b < 0) {
+		m += b
+	}
+	return m
+}
+
+// powInt works for non-negative exponent; it overflows silently,
+// like int multiplication.
+func powInt(a, b int) int {
+	x := 1
+	for ; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			x *= a
+		}
+		a *= a
+	}
+	return x
+}
+
+// binopDecimal works on decimals, with int promoted to decimal.
+// Float is not promoted, to not lose the exactness; it can be only compared.
+func binopDecimal(op opcode, a, b value) value {
+	if isFloat(a) || isFloat(b) {
+		c, ok := cmpExact(a, b)
+		if !ok {
+			return nil
+		}
+		switch op {
+		case opEQ:
+			return c == 0
+		case opLT:
+			return c < 0
+		case opGT:
+			return c > 0
+		}
+		return nil
+	}
+
+	if n, ok := b.(int); ok && op == opPOW {
+		return toDecimal(a).pow(n)
+	}
+
+	da, db := toDecimal(a), toDecimal(b)
+	switch op {
+	case opEQ:
+		return da.cmp(db) == 0
+	case opLT:
+		return da.cmp(db) < 0
+	case opGT:
+		return da.cmp(db) > 0
+
+	case opADD:
+		return da.add(db)
+	case opSUB:
+		return da.sub(db)
+	case opMUL:
+		return da.mul(db)
+	case opDIV:
+		return da.quo(db)
+	case opFLOORDIV:
+		return da.floorQuo(db)
+	case opMOD:
+		return da.sub(db.mul(da.floorQuo(db)))
+	}
+	return nil
+}
+
+func toDecimal(a value) Decimal {
+	if x, ok := a.(int); ok {
+		return decimalFromInt(x)
+	}
+	return a.(Decimal)
+}
+
+// cmpExact compares a decimal with a float by their exact values;
+// infinities and NaN can't be compared.
+func cmpExact(a, b value) (int, bool) {
+	toRat := func(v value) *big.Rat {
+		switch x := v.(type) {
+		case float64:
+			return new(big.Rat).SetFloat64(x)
+		case Decimal:
+			return x.Rat()
+		}
+		return nil
+	}
+	ra, rb := toRat(a), toRat(b)
+	if ra == nil || rb == nil {
+		return 0, false
+	}
+	return ra.Cmp(rb), true
+}
+
 func unopNumeric(op opcode, a value) value {
 	switch va := a.(type) {
 	case int:
@@ -86,10 +240,219 @@ func unopNumeric(op opcode, a value) value {
 		case opNEG:
 			return -va
 		}
+	case Decimal:
+		switch op {
+		case opNEG:
+			return va.neg()
+		}
+	case time.Duration:
+		switch op {
+		case opNEG:
+			return -va
+		}
+	}
+	return nil
+}
+
+// binopTime handles durations and times, mixed with each other or with
+// numbers where it makes sense; nil means the types are not supported.
+func binopTime(op opcode, a, b value) value {
+	switch va := a.(type) {
+	case time.Duration:
+		switch vb := b.(type) {
+		case time.Duration:
+			switch op {
+			case opEQ:
+				return va == vb
+			case opLT:
+				return va < vb
+			case opGT:
+				return va > vb
+
+			case opADD:
+				return va + vb
+			case opSUB:
+				return va - vb
+			}
+		case time.Time:
+			switch op {
+			case opADD:
+				return vb.Add(va)
+			}
+		case int:
+			switch op {
+			case opMUL:
+				return va * time.Duration(vb)
+			case opDIV:
+				return va / time.Duration(vb)
+			}
+		case float64:
+			switch op {
+			case opMUL:
+				return time.Duration(float64(va) * vb)
+			case opDIV:
+				return time.Duration(float64(va) / vb)
+			}
+		}
+
+	case time.Time:
+		switch vb := b.(type) {
+		case time.Time:
+			switch op {
+			case opEQ:
+				return va.Equal(vb)
+			case opLT:
+				return va.Before(vb)
+			case opGT:
+				return va.After(vb)
+
+			case opSUB:
+				return va.Sub(vb)
+			}
+		case time.Duration:
+			switch op {
+			case opADD:
+				return va.Add(vb)
+			case opSUB:
+				return va.Add(-vb)
+			}
+		}
+
+	case int:
+		if vb, ok := b.(time.Duration); ok && op == opMUL {
+			return time.Duration(va) * vb
+		}
+	case float64:
+		if vb, ok := b.(time.Duration); ok && op == opMUL {
+			return time.Duration(va * float64(vb))
+		}
+	}
+
+	if op == opEQ {
+		return false
 	}
 	return nil
 }
 
+// binop evaluates the binary operation the way the VM does,
+// the error being the message of the runtime error.
+func binop(op opcode, a, b value) (value, error) {
+	invalid := func() (value, error) {
+		return nil, fmt.Errorf("%s: invalid types: %s, %s", op, vtype(a), vtype(b))
+	}
+
+	switch op {
+	case opNE, opLE, opGE:
+		// negations of EQ, GT, LT, with the errors of those
+		x, err := binop(negatedOps[op], a, b)
+		if err != nil {
+			return nil, err
+		}
+		return isFalsey(x), nil
+	}
+
+	switch {
+	case isNumber(a) && isNumber(b):
+		switch {
+		case (op == opDIV || op == opFLOORDIV) && !isFloat(b) && isFalsey(b):
+			return nil, fmt.Errorf("division by %s zero", vtype(b))
+		case op == opMOD && !isFloat(b) && isFalsey(b):
+			return nil, fmt.Errorf("modulo by %s zero", vtype(b))
+		case (op == opSHL || op == opSHR) && isInt(b) && b.(int) < 0:
+			return nil, fmt.Errorf("negative shift count: %d", b)
+		case op == opPOW && isDecimal(a) && isFalsey(a) && isInt(b) && b.(int) < 0:
+			return nil, fmt.Errorf("division by decimal zero")
+		case op == opPOW && isDecimal(a) && isInt(b) &&
+			(b.(int) > maxDecimalExp || b.(int) < -maxDecimalExp):
+			return nil, fmt.Errorf("decimal exponent out of range: %d", b)
+		}
+		x := binopNumeric(op, a, b)
+		if x == nil {
+			return invalid()
+		}
+		return x, nil
+
+	case op == opADD && isString(a) && isString(b) &&
+		len(a.(string))+len(b.(string)) > maxStringLen:
+		return nil, errStringTooLong
+
+	case (op == opLT || op == opGT || op == opADD) && isString(a) && isString(b):
+		return binopString(op, a.(string), b.(string)), nil
+
+	case op == opADD && isString(a) && isInt(b):
+		return a.(string) + strconv.Itoa(b.(int)), nil
+
+	case op == opADD && isString(a) && isFloat(b):
+		return a.(string) + strconv.FormatFloat(b.(float64), 'f', -1, 64), nil
+
+	case op == opADD && isString(a) && b == nil:
+		return a, nil
+
+	case op == opADD && isString(a) && (isDecimal(b) || isDuration(b) || isTime(b)):
+		s, _ := stringify(b)
+		return a.(string) + s, nil
+
+	case isDuration(a) || isTime(a) || isDuration(b) || isTime(b):
+		if op == opDIV && isNumber(b) && isFalsey(b) {
+			return nil, fmt.Errorf("division by %s zero", vtype(b))
+		}
+		x := binopTime(op, a, b)
+		if x == nil {
+			return invalid()
+		}
+		return x, nil
+
+	case op == opMUL && isString(a) && isInt(b):
+		s, n := a.(string), b.(int)
+		switch {
+		case n < 0:
+			return nil, fmt.Errorf("negative repeat count: %d", n)
+		case n > 0 && len(s) > maxStringLen/n:
+			return nil, errStringTooLong
+		}
+		return strings.Repeat(s, n), nil
+
+	case op == opEQ:
+		return equal(a, b), nil
+	}
+	return invalid()
+}
+
+// The limits keeping the values from eating up the memory.
+const (
+	maxStringLen  = 1 << 26
+	maxDecimalExp = 1 << 12
+)
+
+var errStringTooLong = fmt.Errorf("string too long, max %d bytes", maxStringLen)
+
+var negatedOps = map[opcode]opcode{opNE: opEQ, opLE: opGT, opGE: opLT}
+
+// unop evaluates the unary operation the way the VM does.
+func unop(op opcode, a value) (value, error) {
+	switch op {
+	case opNEG, opUNPLUS:
+		if !isNumber(a) && !isDuration(a) {
+			return nil, fmt.Errorf("%s: invalid type: %s, expected number", op, vtype(a))
+		}
+		if op == opNEG {
+			return unopNumeric(op, a), nil
+		}
+		return a, nil
+
+	case opNOT:
+		return isFalsey(a), nil
+
+	case opTOSTR:
+		s, ok := stringify(a)
+		if !ok {
+			return nil, fmt.Errorf("TOSTR: invalid type: %s", vtype(a))
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s: not a unary operation", op)
+}
+
 func binopString(op opcode, a, b string) value {
 	switch op {
 	case opLT:
@@ -103,3 +466,239 @@ func binopString(op opcode, a, b string) value {
 
 	return nil
 }
+
+// convTypes are the targets of the `@type` conversion.
+var convTypes = map[string]typecode{
+	"int":      typeINT,
+	"float":    typeFLOAT,
+	"str":      typeSTR,
+	"bool":     typeBOOL,
+	"decimal":  typeDECIMAL,
+	"duration": typeDURATION,
+	"time":     typeTIME,
+}
+
+func convTypeName(code typecode) string {
+	for name, c := range convTypes {
+		if c == code {
+			return name
+		}
+	}
+	return code.String()
+}
+
+// convert does the explicit `@type` conversion.
+func convert(a value, code typecode) (value, error) {
+	invalid := func() (value, error) {
+		return nil, fmt.Errorf("invalid type: %s", vtype(a))
+	}
+	syntaxErr := func(s string) (value, error) {
+		return nil, fmt.Errorf("invalid syntax: %q", s)
+	}
+
+	switch code {
+	case typeINT:
+		switch x := a.(type) {
+		case int:
+			return x, nil
+		case float64:
+			if math.IsNaN(x) || x < math.MinInt || x >= math.MaxInt {
+				return nil, fmt.Errorf("out of range: %v", x)
+			}
+			return int(x), nil
+		case Decimal:
+			n := new(big.Int).Quo(x.coef, pow10(x.scale))
+			if !n.IsInt64() {
+				return nil, fmt.Errorf("out of range: %v", x)
+			}
+			return int(n.Int64()), nil
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 0)
+			if err != nil {
+				return syntaxErr(x)
+			}
+			return int(n), nil
+		case bool:
+			if x {
+				return 1, nil
+			}
+			return 0, nil
+		case time.Duration:
+			return int(x / time.Second), nil
+		}
+
+	case typeFLOAT:
+		switch x := a.(type) {
+		case int:
+			return float64(x), nil
+		case float64:
+			return x, nil
+		case Decimal:
+			f, _ := x.Rat().Float64()
+			return f, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return syntaxErr(x)
+			}
+			return f, nil
+		case bool:
+			if x {
+				return 1.0, nil
+			}
+			return 0.0, nil
+		case time.Duration:
+			return x.Seconds(), nil
+		}
+
+	case typeSTR:
+		if s, ok := stringify(a); ok {
+			return s, nil
+		}
+
+	case typeBOOL:
+		if s, ok := a.(string); ok && s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return syntaxErr(s)
+			}
+			return b, nil
+		}
+		return isTruthy(a), nil
+
+	case typeDECIMAL:
+		switch x := a.(type) {
+		case int:
+			return decimalFromInt(x), nil
+		case float64:
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				return nil, fmt.Errorf("out of range: %v", x)
+			}
+			d, _ := parseDecimal(strconv.FormatFloat(x, 'f', -1, 64))
+			return d, nil
+		case Decimal:
+			return x, nil
+		case string:
+			d, err := parseDecimal(x)
+			if err != nil {
+				return syntaxErr(x)
+			}
+			return d, nil
+		}
+
+	case typeDURATION:
+		switch x := a.(type) {
+		case int:
+			if x > math.MaxInt64/int(time.Second) || x < math.MinInt64/int(time.Second) {
+				return nil, fmt.Errorf("out of range: %v", x)
+			}
+			return time.Duration(x) * time.Second, nil
+		case float64:
+			ns := math.Round(x * float64(time.Second))
+			if math.IsNaN(ns) || ns < math.MinInt64 || ns >= math.MaxInt64 {
+				return nil, fmt.Errorf("out of range: %v", x)
+			}
+			return time.Duration(ns), nil
+		case string:
+			d, err := time.ParseDuration(x)
+			if err != nil {
+				return syntaxErr(x)
+			}
+			return d, nil
+		case time.Duration:
+			return x, nil
+		}
+
+	case typeTIME:
+		switch x := a.(type) {
+		case string:
+			t, err := time.Parse(time.RFC3339, x)
+			if err != nil {
+				return syntaxErr(x)
+			}
+			return t, nil
+		case time.Time:
+			return x, nil
+		}
+	}
+
+	return invalid()
+}
+
+// stringify converts the value to string the same way as the string+x
+// concatenation does, with nil being the empty string.
+func stringify(a value) (string, bool) {
+	switch x := a.(type) {
+	case string:
+		return x, true
+	case int:
+		return strconv.Itoa(x), true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case Decimal:
+		return x.String(), true
+	case bool:
+		return strconv.FormatBool(x), true
+	case time.Duration:
+		return x.String(), true
+	case time.Time:
+		return x.Format(time.RFC3339Nano), true
+	case []value:
+		return formatList(x), true
+	case map[string]value:
+		return formatMap(x), true
+	case nil:
+		return "", true
+	}
+	return "", false
+}
+
+// formatList gives the list like `["a", 1, nil]`.
+func formatList(list []value) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range list {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(formatElem(x))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// formatMap gives the map like `{"a": 1, "b": nil}`, with sorted keys.
+func formatMap(m map[string]value) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(k))
+		b.WriteString(": ")
+		b.WriteString(formatElem(m[k]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatElem(x value) string {
+	switch x := x.(type) {
+	case string:
+		return strconv.Quote(x)
+	case nil:
+		return "nil"
+	}
+	s, _ := stringify(x)
+	return s
+}
+
+func sortedKeys(m map[string]value) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,335 @@
+package bcl
+
+import (
+	"math"
+	"time"
+)
+
+// The optimizer rewrites the decoded instructions of a parsed Prog,
+// at the levels described by OptOptimize, and encodes them again.
+
+// foldMaxRepeat is the limit of the string repetition size being folded,
+// to not inflate the constants.
+const foldMaxRepeat = 1024
+
+type optimizer struct {
+	prog   *Prog
+	code   []*instr
+	consts map[any]int // by constKey
+}
+
+func (p *Prog) optimize(level int) {
+	if level <= 0 {
+		return
+	}
+	code, err := decodeCode(p, nil)
+	if err != nil {
+		panic(err) // the parsed code is always valid
+	}
+	o := &optimizer{prog: p, code: code}
+
+	for changed := true; changed; {
+		changed = o.fuseOps()
+		if level >= 2 {
+			changed = o.foldConsts() || changed
+			changed = o.foldJumps() || changed
+			changed = o.dropUnreachable() || changed
+		}
+		changed = o.dropDeadJumps() || changed
+	}
+	if level >= 2 {
+		o.compactConsts()
+	}
+	p.code, p.positions = encodeCode(o.code)
+}
+
+func (o *optimizer) fuseOps() (changed bool) {
+	fused := map[opcode]opcode{opEQ: opNE, opGT: opLE, opLT: opGE}
+
+	return o.rewrite(func(code []*instr, i int) int {
+		op, ok := fused[code[i].op]
+		if !ok || !o.next(i, 1, opNOT) {
+			return 0
+		}
+		code[i].op = op
+		return 1
+	})
+}
+
+func (o *optimizer) foldConsts() bool {
+	return o.rewrite(func(code []*instr, i int) int {
+		a, ok := o.constValue(code[i])
+		if !ok {
+			return 0
+		}
+
+		if i+2 < len(code) && code[i+2].jumpedTo == 0 {
+			if b, ok := o.constValue(code[i+1]); ok && code[i+1].jumpedTo == 0 {
+				if op := code[i+2].op; isBinop(op) {
+					x, err := binop(op, a, b)
+					if s, ok := x.(string); ok && op == opMUL && len(s) > foldMaxRepeat {
+						return 0 // leave the long repetition to the runtime
+					}
+					if err == nil && isConstType(x) {
+						o.setConst(code[i], x, code[i+2].pos)
+						return 2
+					}
+				}
+			}
+		}
+
+		if i+1 < len(code) && code[i+1].jumpedTo == 0 {
+			var x value
+			var err error
+			switch op := code[i+1].op; op {
+			case opNEG, opUNPLUS, opNOT, opTOSTR:
+				x, err = unop(op, a)
+			case opCONV:
+				x, err = convert(a, typecode(code[i+1].args[0]))
+			case opPOP:
+				if code[i].jumpedTo == 0 {
+					return -2
+				}
+				return 0
+			default:
+				return 0
+			}
+			if err == nil && isConstType(x) {
+				o.setConst(code[i], x, code[i+1].pos)
+				return 1
+			}
+		}
+		return 0
+	})
+}
+
+// foldJumps resolves the conditional jumps following a constant:
+// they either become unconditional, or are removed.
+func (o *optimizer) foldJumps() bool {
+	return o.rewrite(func(code []*instr, i int) int {
+		v, ok := o.constValue(code[i])
+		if !ok || i+1 == len(code) || code[i+1].jumpedTo > 0 {
+			return 0
+		}
+		var jumps bool
+		switch j := code[i+1]; j.op {
+		case opJFALSE:
+			jumps = isFalsey(v)
+		case opJNOTNIL:
+			jumps = v != nil
+		default:
+			return 0
+		}
+		if jumps {
+			code[i+1].op = opJUMP
+			return -1 // just to mark the change
+		}
+		code[i+1].target.jumpedTo--
+		return 1
+	})
+}
+
+// dropUnreachable removes the code after JUMP, LOOP or RET,
+// up to the next jump target.
+func (o *optimizer) dropUnreachable() (changed bool) {
+	code := o.code[:0]
+	var unreachable bool
+	for _, in := range o.code {
+		if in.jumpedTo > 0 {
+			unreachable = false
+		}
+		if unreachable {
+			if in.target != nil {
+				in.target.jumpedTo--
+			}
+			changed = true
+			continue
+		}
+		code = append(code, in)
+		switch in.op {
+		case opJUMP, opLOOP, opRET:
+			unreachable = true
+		}
+	}
+	o.code = code
+	return changed
+}
+
+// dropDeadJumps removes the jumps to the next instruction.
+func (o *optimizer) dropDeadJumps() (changed bool) {
+	code := o.code[:0]
+	for i, in := range o.code {
+		switch in.op {
+		case opJUMP, opJFALSE, opJNOTNIL:
+			if i+1 < len(o.code) && in.target == o.code[i+1] {
+				in.target.jumpedTo--
+				changed = true
+				continue
+			}
+		}
+		code = append(code, in)
+	}
+	o.code = code
+	return changed
+}
+
+// rewrite applies f at each instruction; f returns how many instructions
+// following the current one it replaced, so they are removed,
+// or -1 for the change of just the current one, or -2 for removing
+// both the current and the next one. The removed ones can't be jump targets.
+func (o *optimizer) rewrite(f func(code []*instr, i int) int) (changed bool) {
+	code := o.code[:0]
+	for i := 0; i < len(o.code); i++ {
+		switch n := f(o.code, i); {
+		case n == -2:
+			i++
+			changed = true
+		case n == -1:
+			code = append(code, o.code[i])
+			changed = true
+		default:
+			code = append(code, o.code[i])
+			i += n
+			changed = changed || n > 0
+		}
+	}
+	o.code = code
+	return changed
+}
+
+// next tells if the instruction at i+k has the opcode and can be removed.
+func (o *optimizer) next(i, k int, op opcode) bool {
+	return i+k < len(o.code) && o.code[i+k].op == op && o.code[i+k].jumpedTo == 0
+}
+
+func (o *optimizer) constValue(in *instr) (value, bool) {
+	switch in.op {
+	case opCONST:
+		return o.prog.constants[in.args[0]], true
+	case opNIL:
+		return nil, true
+	case opZERO:
+		return 0, true
+	case opONE:
+		return 1, true
+	case opTRUE:
+		return true, true
+	case opFALSE:
+		return false, true
+	}
+	return nil, false
+}
+
+func (o *optimizer) setConst(in *instr, x value, pos int) {
+	in.pos = pos
+	in.args = nil
+	switch x {
+	case nil:
+		in.op = opNIL
+	case 0:
+		in.op = opZERO
+	case 1:
+		in.op = opONE
+	case true:
+		in.op = opTRUE
+	case false:
+		in.op = opFALSE
+	default:
+		in.op = opCONST
+		in.args = []int{o.constIndex(x)}
+	}
+}
+
+// constIndex finds the constant or adds it to the Prog.
+func (o *optimizer) constIndex(x value) int {
+	if o.consts == nil {
+		o.consts = make(map[any]int, len(o.prog.constants))
+		for i, c := range o.prog.constants {
+			if _, ok := o.consts[constKey(c)]; !ok {
+				o.consts[constKey(c)] = i
+			}
+		}
+	}
+	if i, ok := o.consts[constKey(x)]; ok {
+		return i
+	}
+	i := o.prog.addConst(x)
+	o.consts[constKey(x)] = i
+	return i
+}
+
+type (
+	decimalKey string
+	floatKey   uint64
+)
+
+// constKey gives the key of the constant for finding the equal ones:
+// a decimal, holding a pointer, is keyed by its string form, keeping
+// the scale, and a float by its bits, so that -0.0 is not 0.0.
+func constKey(x value) any {
+	switch x := x.(type) {
+	case Decimal:
+		return decimalKey(x.String())
+	case float64:
+		return floatKey(math.Float64bits(x))
+	}
+	return x
+}
+
+// compactConsts drops the unused constants, renumbering the used ones.
+func (o *optimizer) compactConsts() {
+	used := make([]bool, len(o.prog.constants))
+	o.eachConstArg(func(idx *int) { used[*idx] = true })
+
+	renum := make([]int, len(used))
+	var consts []value
+	for i, u := range used {
+		if u {
+			renum[i] = len(consts)
+			consts = append(consts, o.prog.constants[i])
+		}
+	}
+	o.eachConstArg(func(idx *int) { *idx = renum[*idx] })
+	o.prog.constants = consts
+	o.consts = nil
+}
+
+func (o *optimizer) eachConstArg(f func(idx *int)) {
+	for _, in := range o.code {
+		k := 0
+		for _, kind := range operands[in.op] {
+			switch kind {
+			case argByte, argNum:
+				k++
+			case argConst:
+				f(&in.args[k])
+				k++
+			case argConsts:
+				n := in.args[k]
+				for j := k + 1; j <= k+n; j++ {
+					f(&in.args[j])
+				}
+				k += 1 + n
+			}
+		}
+	}
+}
+
+func isBinop(op opcode) bool {
+	switch op {
+	case opEQ, opLT, opGT, opNE, opLE, opGE,
+		opADD, opSUB, opMUL, opDIV, opMOD, opFLOORDIV, opPOW,
+		opBAND, opBOR, opBXOR, opSHL, opSHR:
+		return true
+	}
+	return false
+}
+
+// isConstType tells if the value can be a constant of a Prog.
+func isConstType(x value) bool {
+	switch x.(type) {
+	case int, float64, string, bool, Decimal, time.Duration, time.Time, nil:
+		return true
+	}
+	return false
+}
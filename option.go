@@ -1,6 +1,7 @@
 package bcl
 
 import (
+	"crypto/ed25519"
 	"io"
 	"os"
 )
@@ -11,6 +12,17 @@ type config struct {
 	disasm bool
 	trace  bool
 	stats  bool
+
+	strictScope bool
+	optimize    int
+
+	maxStack      int
+	maxBlockDepth int
+	maxSteps      int
+
+	meta       map[string]string
+	verifyKeys []ed25519.PublicKey
+
 	output io.Writer
 	logw   io.Writer
 }
@@ -19,6 +31,9 @@ func makeConfig(oo []Option) (cf config) {
 	cf = config{
 		output: os.Stdout,
 		logw:   os.Stderr,
+
+		maxStack:      defaultMaxStack,
+		maxBlockDepth: defaultMaxBlockDepth,
 	}
 
 	for _, o := range oo {
@@ -46,3 +61,66 @@ func OptOutput(w io.Writer) Option {
 func OptLogger(w io.Writer) Option {
 	return func(cf *config) { cf.logw = w }
 }
+
+// OptOptimize sets the optimization level of the parsed Prog, 0 by default.
+// Level 1 uses the dedicated ops for `!=`, `<=`, `>=` and removes
+// the needless jumps; level 2 also folds the constant expressions
+// and removes the unreachable code.
+func OptOptimize(level int) Option {
+	return func(cf *config) { cf.optimize = level }
+}
+
+// OptMaxStack sets the maximum size of the VM stack, 65536 by default;
+// n <= 0 keeps the default.
+// As the variables live on the stack, it also limits how many of them
+// can be visible at once, which is checked by the parser.
+func OptMaxStack(n int) Option {
+	return func(cf *config) {
+		if n > 0 {
+			cf.maxStack = n
+		}
+	}
+}
+
+// OptMaxBlockDepth sets how deeply the blocks can be nested, 256 by default;
+// n <= 0 keeps the default.
+// It is checked both by the parser and the VM.
+func OptMaxBlockDepth(n int) Option {
+	return func(cf *config) {
+		if n > 0 {
+			cf.maxBlockDepth = n
+		}
+	}
+}
+
+// OptMaxSteps sets how many instructions the VM can execute, so that
+// a loop can't run arbitrarily long; n <= 0, the default, means no limit.
+// The input from an untrusted source needs it, as `range` can be huge.
+func OptMaxSteps(n int) Option {
+	return func(cf *config) { cf.maxSteps = n }
+}
+
+// OptStrictScope makes the parser reject an unprefixed assignment inside
+// a block when it would update a variable from the outer scope;
+// the explicit `var.x = ...` or `field.x = ...` is required then.
+func OptStrictScope(x bool) Option {
+	return func(cf *config) { cf.strictScope = x }
+}
+
+// OptMeta adds the key-value pair to the metadata of the parsed Prog,
+// which is stored in its dump and given by [Prog.Info].
+func OptMeta(key, value string) Option {
+	return func(cf *config) {
+		if cf.meta == nil {
+			cf.meta = make(map[string]string)
+		}
+		cf.meta[key] = value
+	}
+}
+
+// OptVerifyKey makes LoadProg accept only the dumps signed with the key
+// matching the public one, see [Prog.DumpSigned]. It can be given more than
+// once, then any of the keys is accepted.
+func OptVerifyKey(pub ed25519.PublicKey) Option {
+	return func(cf *config) { cf.verifyKeys = append(cf.verifyKeys, pub) }
+}
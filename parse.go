@@ -1,19 +1,32 @@
 package bcl
 
 import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
-func parse(inputs <-chan string, name string, w writers) (
+func parse(inputs <-chan string, name string, w writers, cf parseConfig) (
 	_ *Prog,
 	pstats parseStats, _ error,
 ) {
 	linePos := newLineCalc()
+	source := sha256.New()
+	onInput := func(s string, prefix int) {
+		io.WriteString(source, s)
+		linePos.add(s, prefix)
+	}
 
 	p := &parser{
 		linePos: linePos,
-		lexer:   newLexer(inputs, linePos.add),
+		lexer:   newLexer(inputs, onInput),
 		prog:    newProg(name, w),
+		cf:      cf,
 
 		identRefs: make(map[string]int, 8),
 		// identRefs are for reusing block types & fields and selected consts
@@ -38,14 +51,26 @@ func parse(inputs <-chan string, name string, w writers) (
 		return p.prog, p.stats, errCombined{"parse"}
 	}
 	p.end()
+	if len(p.farJumps) > 0 {
+		p.prog.widenJumps(p.farJumps)
+	}
+	copy(p.prog.header.source[:], source.Sum(nil))
 
 	return p.prog, p.stats, nil
 }
 
+type parseConfig struct {
+	strictScope bool
+
+	maxLocals     int
+	maxBlockDepth int
+}
+
 type parser struct {
 	lexer   *lexer
 	prog    *Prog
 	linePos *lineCalc
+	cf      parseConfig
 
 	prev, current token
 	hadError      bool
@@ -54,23 +79,28 @@ type parser struct {
 
 	identRefs map[string]int // map ident names to const indices
 
-	scope *scopeCompiler
+	scope      *scopeCompiler
+	blockScope int // scope depth of the innermost block, 0 when outside
+	blockDepth int
+
+	farJumps map[int]int // jumps not fitting in u16: operand offset -> target
 
 	stats parseStats
 	log   logger
 }
 
 type scopeCompiler struct {
-	locals     [localsMaxSize]local
+	locals     []local
 	localCount int
 	depth      int
 }
 
-const localsMaxSize = stackSize
-
 type local struct {
 	name  string
 	depth int
+
+	isConst bool
+	folded  []byte // code giving the value of a const, when known
 }
 
 type parseStats struct {
@@ -89,7 +119,13 @@ func (stats *parseStats) finish(prog *Prog) {
 
 func decl(p *parser) {
 	if p.match(tVAR) {
-		varDecl(p)
+		if p.check(tDOT) {
+			varExprStmt(p)
+		} else {
+			varDecl(p)
+		}
+	} else if p.match(tCONST) {
+		constDecl(p)
 	} else {
 		stmt(p)
 	}
@@ -116,6 +152,61 @@ func varDecl(p *parser) {
 	p.defVar()
 }
 
+// constDecl parses `const x = expr`, declaring a variable which can't be
+// assigned again. When its value is a literal or another such const,
+// the references to it are replaced with that constant.
+func constDecl(p *parser) {
+	p.consume(tIDENT, "expected constant name")
+	if p.panicMode {
+		return
+	}
+
+	p.declVar()
+	p.consume(tEQ, "expected '=' after constant name")
+	if p.panicMode {
+		return
+	}
+
+	start := p.currentProg().count()
+	expr(p)
+	if p.panicMode {
+		return
+	}
+	local := &p.scope.locals[p.scope.localCount-1]
+	local.isConst = true
+	if code := p.currentProg().code[start:]; isConstCode(code) {
+		local.folded = slices.Clone(code)
+	}
+
+	p.defVar()
+}
+
+// isConstCode tells if the code is a single instruction pushing a constant.
+func isConstCode(code []byte) bool {
+	if len(code) == 0 {
+		return false
+	}
+	switch opcode(code[0]) {
+	case opNIL, opZERO, opONE, opTRUE, opFALSE:
+		return len(code) == 1
+	case opCONST:
+		_, n := uvarintFromBytes(code[1:])
+		return len(code) == 1+n
+	}
+	return false
+}
+
+// varExprStmt is an expression statement starting with `var.x`,
+// where the `var` keyword has been already consumed by decl.
+func varExprStmt(p *parser) {
+	if p.blockScope == 0 {
+		p.error("expected statement")
+		return
+	}
+	p.parsePrefixed(precAssign)
+	p.emitOp(opPOP)
+}
+
 func stmt(p *parser) {
 	switch {
 	case p.match(tPRINT):
@@ -126,7 +217,11 @@ func stmt(p *parser) {
 		blockStmt(p)
 	case p.match(tBIND):
 		bindStmt(p)
-	case p.scope.depth > 0:
+	case p.match(tFOR):
+		forStmt(p)
+	case p.match(tIF):
+		ifStmt(p)
+	case p.blockScope > 0:
 		exprStmt(p)
 	default:
 		p.errorAtCurrent("expected statement")
@@ -142,18 +237,51 @@ func blockStmt(p *parser) {
 	blockType := p.prev.val
 
 	var blockName string
-	if p.match(tSTR) {
-		blockName, _ = strconv.Unquote(p.prev.val)
+	var dynName bool
+	switch {
+	case p.check(tLCURLY):
+		// nameless block
+	case p.match(tSTR):
+		if p.check(tLCURLY) {
+			blockName = p.unquote(p.prev.val)
+			break
+		}
+		p.parsePrefixed(precOr)
+		dynName = true
+	default:
+		// block name is an expression evaluated at runtime
+		p.parsePrecedence(precOr)
+		dynName = true
 	}
 
 	p.consume(tLCURLY, "expected '{'")
 
-	p.defBlock(p.identConst(blockType), p.identConst(blockName))
+	if p.blockDepth >= p.cf.maxBlockDepth {
+		p.error(fmt.Sprintf("blocks nested too deeply (max %d)", p.cf.maxBlockDepth))
+		p.panicMode = false // not a syntax error, the parsing can go on
+	}
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	if dynName {
+		p.defDynBlock(p.identConst(blockType))
+	} else {
+		p.defBlock(p.identConst(blockType), p.identConst(blockName))
+	}
 	defer p.endBlock()
 
 	p.beginScope()
 	defer p.endScope()
 
+	outerBlockScope := p.blockScope
+	p.blockScope = p.scope.depth
+	defer func() { p.blockScope = outerBlockScope }()
+
+	bodyDecls(p)
+}
+
+// bodyDecls parses declarations until the closing curly brace.
+func bodyDecls(p *parser) {
 	for !p.check(tRCURLY) && !p.checkEnd() {
 		decl(p)
 		if p.panicMode {
@@ -169,6 +297,144 @@ func blockStmt(p *parser) {
 	p.consume(tRCURLY, "expected '}'")
 }
 
+// ifStmt parses `if cond {...}` with optional `else {...}`
+// or `else if ...` chain.
+func ifStmt(p *parser) {
+	expr(p)
+	p.consume(tLCURLY, "expected '{' after condition")
+	if p.panicMode {
+		return
+	}
+
+	elseJump := p.emitJump(opJFALSE)
+	p.emitOp(opPOP)
+	scopedBody(p)
+
+	endJump := p.emitJump(opJUMP)
+	p.patchJump(elseJump)
+	p.emitOp(opPOP)
+
+	if p.match(tELSE) {
+		switch {
+		case p.match(tIF):
+			ifStmt(p)
+		case p.match(tLCURLY):
+			scopedBody(p)
+		default:
+			p.errorAtCurrent("expected '{' or 'if' after 'else'")
+		}
+	}
+	p.patchJump(endJump)
+}
+
+// scopedBody parses declarations in a new scope, after the opening curly brace.
+func scopedBody(p *parser) {
+	p.beginScope()
+	defer p.endScope()
+
+	bodyDecls(p)
+}
+
+// forStmt parses `for x in range(start, end) {...}`, where start is optional
+// and defaults to zero, or `for x in list {...}`, where list is an expression
+// giving a list; nil is iterated as an empty list.
+//
+// The bounds, or the list and the index, are kept in hidden locals;
+// x is a fresh local in each iteration, so assigning to it does not change
+// the iteration.
+func forStmt(p *parser) {
+	p.consume(tIDENT, "expected loop variable name")
+	if p.panicMode {
+		return
+	}
+	name := p.prev.val
+
+	p.consume(tIN, "expected 'in'")
+
+	p.beginScope()
+	defer p.endScope()
+
+	loopOp := opFORLIST
+	iterSlot, endSlot, stepSlot := 0, 0, -1
+	if p.match(tIDENT) {
+		if p.prev.val == "range" && p.check(tLPAREN) {
+			loopOp = opFORRANGE
+			iterSlot, endSlot, stepSlot = rangeBounds(p)
+			if stepSlot >= 0 {
+				loopOp = opFORSTEP
+			}
+		} else {
+			p.parsePrefixed(precAssign)
+		}
+	} else {
+		expr(p)
+	}
+	if loopOp == opFORLIST {
+		endSlot = p.addHiddenLocal(" list")
+		p.emitOp(opZERO)
+		iterSlot = p.addHiddenLocal(" iter")
+	}
+	p.consume(tLCURLY, "expected '{'")
+	if p.panicMode {
+		return
+	}
+
+	loopStart := p.currentProg().count()
+	exitJump := p.emitForLoop(loopOp, iterSlot, endSlot, stepSlot)
+
+	p.beginScope()
+	if loopOp != opFORLIST {
+		p.emitOp(opGETLOCAL)
+		p.emitUvarint(iterSlot)
+	} // FORLIST pushes the element itself
+	p.addLocal(name)
+	p.markInitialized()
+
+	bodyDecls(p)
+	p.endScope()
+
+	p.emitOp(opGETLOCAL)
+	p.emitUvarint(iterSlot)
+	if loopOp == opFORSTEP {
+		p.emitOp(opGETLOCAL)
+		p.emitUvarint(stepSlot)
+	} else {
+		p.emitOp(opONE)
+	}
+	p.emitOp(opADD)
+	p.emitOp(opSETLOCAL)
+	p.emitUvarint(iterSlot)
+	p.emitOp(opPOP)
+
+	p.emitLoop(loopStart)
+	p.patchJump(exitJump)
+}
+
+// rangeBounds parses `(end)`, `(start, end)` or `(start, end, step)`
+// after `range` into hidden locals, giving their slots;
+// stepSlot is -1 without the step.
+func rangeBounds(p *parser) (iterSlot, endSlot, stepSlot int) {
+	p.advance() // tLPAREN
+
+	expr(p)
+	if p.match(tCOMMA) {
+		iterSlot = p.addHiddenLocal(" iter")
+		expr(p)
+		endSlot = p.addHiddenLocal(" end")
+	} else {
+		endSlot = p.addHiddenLocal(" end")
+		p.emitOp(opZERO)
+		iterSlot = p.addHiddenLocal(" iter")
+	}
+	stepSlot = -1
+	if p.match(tCOMMA) {
+		expr(p)
+		stepSlot = p.addHiddenLocal(" step")
+	}
+	p.consume(tRPAREN, "expected ')' after range arguments")
+	return iterSlot, endSlot, stepSlot
+}
+
 func bindStmt(p *parser) {
 	if p.match(tLCURLY) {
 
@@ -216,14 +482,14 @@ func bindpartStmt(p *parser) {
 			selector = bindOne
 
 		case p.match(tSTR):
-			name, _ := strconv.Unquote(p.prev.val)
+			name := p.unquote(p.prev.val)
 			selBlockNames = append(selBlockNames, name)
 
 			if p.match(tCOMMA) {
 				target = bindSlice
 				selector = bindNamedBlocks
 				for p.match(tSTR) {
-					name, _ = strconv.Unquote(p.prev.val)
+					name = p.unquote(p.prev.val)
 					selBlockNames = append(selBlockNames, name)
 					if p.match(tCOMMA) {
 						continue
@@ -298,14 +564,20 @@ type precedence int
 const (
 	precNone precedence = iota
 	precAssign
+	precCoalesce
 	precOr
 	precAnd
 	precNot
 	precEq
 	precCmp
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
 	precTerm
 	precFactor
 	precUnary
+	precPower
 	precCall
 	precPrimary
 )
@@ -315,10 +587,12 @@ var rules [tMAX]parseRule
 func init() {
 	rules = [...]parseRule{
 
-		tLPAREN: {parens, nil, precNone},
-		tRPAREN: {nil, nil, precNone},
-		tLCURLY: {nil, nil, precNone},
-		tRCURLY: {nil, nil, precNone},
+		tLPAREN:   {parens, nil, precNone},
+		tRPAREN:   {nil, nil, precNone},
+		tLCURLY:   {mapLit, nil, precNone},
+		tRCURLY:   {nil, nil, precNone},
+		tLBRACKET: {listLit, nil, precNone},
+		tRBRACKET: {nil, nil, precNone},
 
 		tEQ: {nil, nil, precNone},
 
@@ -327,6 +601,8 @@ func init() {
 		tSLASH: {nil, binary, precFactor},
 		tSTAR:  {nil, binary, precFactor},
 
+		tQUESTQUEST: {nil, coalesce, precCoalesce},
+
 		tOR:  {nil, boolOr, precOr},
 		tAND: {nil, boolAnd, precAnd},
 		tNOT: {boolNot, nil, precNone},
@@ -338,20 +614,48 @@ func init() {
 		tLT: {nil, binary, precCmp},
 		tLE: {nil, binary, precCmp},
 
+		tSLASHSLASH: {nil, binary, precFactor},
+		tPERCENT:    {nil, binary, precFactor},
+		tSTARSTAR:   {nil, power, precPower},
+
+		tPIPE:  {nil, binary, precBitOr},
+		tCARET: {nil, binary, precBitXor},
+		tAMP:   {nil, binary, precBitAnd},
+		tSHL:   {nil, binary, precShift},
+		tSHR:   {nil, binary, precShift},
+
 		tIDENT: {identRef, nil, precNone},
 		tSTR:   {stringLit, nil, precNone},
 		tINT:   {intLit, nil, precNone},
 		tFLOAT: {floatLit, nil, precNone},
 
+		tDECIMAL:  {decimalLit, nil, precNone},
+		tDURATION: {durationLit, nil, precNone},
+		tTIME:     {timeLit, nil, precNone},
+
+		tAT: {nil, conversion, precCall},
+
+		tINTERP:    {interpolation, nil, precNone},
+		tINTERPEND: {nil, nil, precNone},
+		tHEREDOC:   {heredocLit, nil, precNone},
+
 		tFALSE: {boolLit, nil, precNone},
 		tTRUE:  {boolLit, nil, precNone},
 
 		tNIL: {nilLit, nil, precNone},
 
-		tVAR: {nil, nil, precNone},
+		tVAR: {varRef, nil, precNone},
+		tFOR: {nil, nil, precNone},
+		tIN:  {nil, nil, precNone},
+
+		tIF:   {ifExpr, nil, precNone},
+		tTHEN: {nil, nil, precNone},
+		tELSE: {nil, nil, precNone},
 
 		tSEMICOLON: {nil, nil, precNone},
 		tCOMMA:     {nil, nil, precNone},
+		tDOT:       {nil, nil, precNone},
+		tQUESTDOT:  {nil, nil, precNone},
 
 		tERR:  {nil, nil, precNone},
 		tEOF:  {nil, nil, precNone},
@@ -362,7 +666,223 @@ func init() {
 func getRule(t tokenType) parseRule { return rules[t] }
 
 func identRef(p *parser, canAssign bool) {
-	p.resolveIdent(p.prev.val, canAssign)
+	dotted := p.check(tDOT) || p.check(tQUESTDOT)
+	switch {
+	case p.prev.val == "field" && dotted:
+		fieldRef(p, canAssign)
+	case dotted && p.resolveLocal(p.scope, p.prev.val) >= 0:
+		keyRef(p, p.resolveLocal(p.scope, p.prev.val))
+	case dotted:
+		pathRef(p)
+	case p.check(tLPAREN):
+		call(p)
+	default:
+		p.resolveIdent(p.prev.val, canAssign)
+	}
+}
+
+// varRef parses `var.x`, which can only refer to a variable.
+func varRef(p *parser, canAssign bool) {
+	p.consume(tDOT, "expected '.' after 'var'")
+	p.consume(tIDENT, "expected variable name after 'var.'")
+	if p.panicMode {
+		return
+	}
+
+	idx := p.resolveLocal(p.scope, p.prev.val)
+	if idx < 0 {
+		p.error("undefined variable")
+		return
+	}
+	p.localRef(idx, canAssign)
+}
+
+// fieldRef parses `field.x`, which can only refer to a block field.
+// Note that it takes precedence over a path starting with a block type
+// named "field".
+// The optional `field?.x` gives nil when the field is not defined,
+// so it can't be assigned.
+func fieldRef(p *parser, canAssign bool) {
+	p.advance() // tDOT or tQUESTDOT
+	optional := p.prev.typ == tQUESTDOT
+	p.consume(tIDENT, "expected field name after 'field.'")
+	if p.panicMode {
+		return
+	}
+
+	if p.blockScope == 0 {
+		p.error("field reference outside of a block")
+		return
+	}
+	if optional {
+		p.emitOp(opTRYFIELD)
+		p.emitUvarint(p.identConst(p.prev.val))
+		return
+	}
+	p.namedRef(opGETFIELD, opSETFIELD, p.identConst(p.prev.val), canAssign)
+}
+
+// pathRef parses a dotted path to a field of a block defined earlier,
+// like `tunnel.prod.host` or `tunnel."prod-1".host`.
+// Segments are resolved at runtime, so the path is read-only.
+// With any `?.` in place of a dot, like `tunnel?.prod.host`, the path
+// is optional and gives nil when it can't be resolved.
+func pathRef(p *parser) {
+	path := []int{p.identConst(p.prev.val)}
+	op := opGETPATH
+
+	for p.match(tDOT) || p.match(tQUESTDOT) {
+		if p.prev.typ == tQUESTDOT {
+			op = opTRYPATH
+		}
+		switch {
+		case p.match(tIDENT):
+			path = append(path, p.identConst(p.prev.val))
+		case p.match(tSTR):
+			path = append(path, p.identConst(p.unquote(p.prev.val)))
+		default:
+			p.errorAtCurrent("expected block name or field after '.'")
+			return
+		}
+	}
+
+	p.emitOp(op)
+	p.emitUvarint(len(path))
+	for _, idx := range path {
+		p.emitUvarint(idx)
+	}
+}
+
+// keyRef parses a dotted path starting with a variable, like `m.a."b-c"`,
+// as the successive elements of nested maps; it works like `get(m, "a")`.
+// With any `?.` in place of a dot, a missing key gives nil.
+func keyRef(p *parser, idx int) {
+	var keys []string
+	optional := false
+
+	for p.match(tDOT) || p.match(tQUESTDOT) {
+		if p.prev.typ == tQUESTDOT {
+			optional = true
+		}
+		switch {
+		case p.match(tIDENT):
+			keys = append(keys, p.prev.val)
+		case p.match(tSTR):
+			keys = append(keys, p.unquote(p.prev.val))
+		default:
+			p.errorAtCurrent("expected key after '.'")
+			return
+		}
+	}
+
+	p.localRef(idx, false)
+	argc := 2
+	if optional {
+		argc = 3
+	}
+	for _, key := range keys {
+		p.emitConst(key)
+		if optional {
+			p.emitOp(opNIL)
+		}
+		p.emitOp(opCALL)
+		p.emitUvarint(p.identConst("get"))
+		p.emitUvarint(argc)
+	}
+}
+
+// call parses `name(args...)`, a call of a builtin function.
+func call(p *parser) {
+	nameTok := p.prev
+	name := nameTok.val
+	p.advance() // tLPAREN
+
+	argc := 0
+	if !p.check(tRPAREN) {
+		for {
+			expr(p)
+			argc++
+			if !p.match(tCOMMA) {
+				break
+			}
+		}
+	}
+	p.consume(tRPAREN, "expected ')' after arguments")
+	if p.panicMode {
+		return
+	}
+	// the arguments are parsed anyway, so that the error doesn't cascade
+	b, ok := builtins[name]
+	if !ok {
+		p.errorAt(&nameTok, fmt.Sprintf("unknown function '%s'", name))
+		return
+	}
+	if !b.accepts(argc) {
+		p.error(fmt.Sprintf("%s() expects %s, got %d", name, b.arity(), argc))
+		return
+	}
+
+	p.emitOp(opCALL)
+	p.emitUvarint(p.identConst(name))
+	p.emitUvarint(argc)
+}
+
+// listLit parses `[a, b, ...]`; a trailing comma is allowed.
+func listLit(p *parser, _ bool) {
+	n := 0
+	for !p.check(tRBRACKET) && !p.checkEnd() {
+		expr(p)
+		n++
+		if !p.match(tCOMMA) {
+			break
+		}
+	}
+	if p.hadLexFail {
+		return
+	}
+	p.consume(tRBRACKET, "expected ']' after list elements")
+
+	p.emitOp(opLIST)
+	p.emitUvarint(n)
+}
+
+// mapLit parses `{key: a, "other-key": b, ...}`; the keys are names
+// or plain strings, and a trailing comma is allowed.
+func mapLit(p *parser, _ bool) {
+	keys := map[string]bool{}
+	n := 0
+	for !p.check(tRCURLY) && !p.checkEnd() {
+		var key string
+		switch {
+		case p.match(tIDENT):
+			key = p.prev.val
+		case p.match(tSTR):
+			key = p.unquote(p.prev.val)
+		default:
+			p.errorAtCurrent("expected map key")
+			return
+		}
+		if keys[key] {
+			p.error("duplicate map key")
+		}
+		keys[key] = true
+		p.emitOp(opCONST)
+		p.emitUvarint(p.identConst(key))
+
+		p.consume(tCOLON, "expected ':' after map key")
+		expr(p)
+		n++
+		if !p.match(tCOMMA) {
+			break
+		}
+	}
+	if p.hadLexFail {
+		return
+	}
+	p.consume(tRCURLY, "expected '}' after map entries")
+
+	p.emitOp(opMAP)
+	p.emitUvarint(n)
 }
 
 func parens(p *parser, _ bool) {
@@ -398,7 +918,66 @@ func binary(p *parser, _ bool) {
 		p.emitOp(opMUL)
 	case tSLASH:
 		p.emitOp(opDIV)
+	case tSLASHSLASH:
+		p.emitOp(opFLOORDIV)
+	case tPERCENT:
+		p.emitOp(opMOD)
+
+	case tAMP:
+		p.emitOp(opBAND)
+	case tPIPE:
+		p.emitOp(opBOR)
+	case tCARET:
+		p.emitOp(opBXOR)
+	case tSHL:
+		p.emitOp(opSHL)
+	case tSHR:
+		p.emitOp(opSHR)
+	}
+}
+
+// power parses the right-associative `**`; its right side can be unary,
+// as in `2 ** -1`, while `-2 ** 2` is `-(2 ** 2)`.
+func power(p *parser, _ bool) {
+	p.parsePrecedence(precUnary)
+	p.emitOp(opPOW)
+}
+
+// conversion parses the postfix `@type`, converting the value before it.
+func conversion(p *parser, _ bool) {
+	if !p.match(tIDENT) {
+		p.errorAtCurrent("expected type name after '@'")
+		return
+	}
+	code, ok := convTypes[p.prev.val]
+	if !ok {
+		p.error("unknown type for the conversion")
+		return
+	}
+	p.emitOp(opCONV)
+	p.emitBytes(byte(code))
+}
+
+// ifExpr parses `if cond then a else b`; the else branch is required
+// and it extends as far as possible, like the right side of an assignment.
+func ifExpr(p *parser, _ bool) {
+	expr(p)
+	p.consume(tTHEN, "expected 'then' after condition")
+
+	elseJump := p.emitJump(opJFALSE)
+	p.emitOp(opPOP)
+	expr(p)
+
+	endJump := p.emitJump(opJUMP)
+	p.patchJump(elseJump)
+	p.emitOp(opPOP)
+
+	p.consume(tELSE, "expected 'else' in conditional expression")
+	if p.panicMode {
+		return
 	}
+	expr(p)
+	p.patchJump(endJump)
 }
 
 func boolAnd(p *parser, _ bool) {
@@ -430,6 +1009,17 @@ func boolOr(p *parser, _ bool) {
 	p.patchJump(endJump)
 }
 
+// coalesce parses `a ?? b`, giving b only when a is nil; unlike `or`,
+// it keeps the falsey values like 0 or "". It's right-associative.
+func coalesce(p *parser, _ bool) {
+	endJump := p.emitJump(opJNOTNIL)
+
+	p.emitOp(opPOP)
+	p.parsePrecedence(precCoalesce)
+
+	p.patchJump(endJump)
+}
+
 func boolNot(p *parser, _ bool) {
 	opType := p.prev.typ
 
@@ -458,7 +1048,8 @@ func unary(p *parser, _ bool) {
 func intLit(p *parser, _ bool) {
 	v, err := strconv.ParseInt(p.prev.val, 0, 0)
 	if err != nil {
-		panic(err)
+		p.literalError("int", err)
+		return
 	}
 	switch v {
 	case 0:
@@ -473,17 +1064,124 @@ func intLit(p *parser, _ bool) {
 func floatLit(p *parser, _ bool) {
 	v, err := strconv.ParseFloat(p.prev.val, 64)
 	if err != nil {
-		panic(err)
+		p.literalError("float", err)
+		return
 	}
 	p.emitConst(v)
 }
 
-func stringLit(p *parser, _ bool) {
-	s, err := strconv.Unquote(p.prev.val)
+func decimalLit(p *parser, _ bool) {
+	v, err := parseDecimal(strings.TrimSuffix(p.prev.val, "d"))
+	if err != nil {
+		p.literalError("decimal", err)
+		return
+	}
+	p.emitConst(v)
+}
+
+func durationLit(p *parser, _ bool) {
+	v, err := time.ParseDuration(p.prev.val)
 	if err != nil {
-		panic(err)
+		p.literalError("duration", err)
+		return
+	}
+	p.emitConst(v)
+}
+
+func timeLit(p *parser, _ bool) {
+	v, err := time.Parse(time.RFC3339, p.prev.val)
+	if err != nil {
+		p.literalError("time", err)
+		return
+	}
+	p.emitConst(v)
+}
+
+// literalError reports the literal which was lexed but can't be converted,
+// like an int out of range.
+func (p *parser) literalError(kind string, err error) {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+	p.error(fmt.Sprintf("invalid %s literal: %v", kind, err))
+}
+
+func stringLit(p *parser, _ bool) {
+	p.emitConst(p.unquote(p.prev.val))
+}
+
+func heredocLit(p *parser, _ bool) {
+	p.emitConst(heredocBody(p.prev.val))
+}
+
+// heredocBody extracts the body from the heredoc token, that is the lines
+// between the `<<ID` and the closing ID, each one ending with a newline.
+// In the `<<-ID` form, the indentation common to non-blank lines is removed.
+func heredocBody(s string) string {
+	lines := strings.Split(s, "\n")
+	dedent := strings.HasPrefix(lines[0], "<<-")
+	lines = lines[1 : len(lines)-1]
+
+	indent := -1
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		lines[i] = line
+		if !dedent || strings.TrimLeft(line, " \t") == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if indent > 0 {
+			line = line[min(indent, len(line)-len(strings.TrimLeft(line, " \t"))):]
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// interpolation parses a string with embedded `${expr}` parts.
+// Lexer gives it as a sequence: tINTERP (expr tINTERP)* expr tINTERPEND,
+// where tokens other than expr are the literal fragments.
+// Each expr is converted to string and all the parts are concatenated.
+func interpolation(p *parser, _ bool) {
+	head := p.fragment(p.prev.val, 1, 2)
+	if head != "" {
+		p.emitConst(head)
+	}
+	hasAcc := head != ""
+
+	for {
+		expr(p)
+		p.emitOp(opTOSTR)
+		if hasAcc {
+			p.emitOp(opADD)
+		}
+		hasAcc = true
+
+		switch {
+		case p.match(tINTERP):
+			if s := p.fragment(p.prev.val, 1, 2); s != "" {
+				p.emitConst(s)
+				p.emitOp(opADD)
+			}
+		case p.match(tINTERPEND):
+			if s := p.fragment(p.prev.val, 1, 1); s != "" {
+				p.emitConst(s)
+				p.emitOp(opADD)
+			}
+			return
+		default:
+			p.errorAtCurrent("expected '}' ending the string interpolation")
+			return
+		}
 	}
-	p.emitConst(s)
 }
 
 func boolLit(p *parser, _ bool) {
@@ -560,7 +1258,7 @@ func (p *parser) sync() {
 
 	for !p.checkEnd() {
 		switch p.current.typ {
-		case tVAR, tDEF, tPRINT, tEVAL: // tokens delimiting a statement
+		case tVAR, tCONST, tDEF, tPRINT, tEVAL, tFOR, tIF: // tokens delimiting a statement
 			return
 		}
 		p.advance()
@@ -569,6 +1267,12 @@ func (p *parser) sync() {
 
 func (p *parser) parsePrecedence(prec precedence) {
 	p.advance()
+	p.parsePrefixed(prec)
+}
+
+// parsePrefixed continues parsing when the token starting
+// the expression is already consumed.
+func (p *parser) parsePrefixed(prec precedence) {
 	prefixRule := getRule(p.prev.typ).prefix
 	if prefixRule == nil {
 		p.error("expected expression")
@@ -641,18 +1345,26 @@ func (p *parser) declVar() {
 }
 
 func (p *parser) addLocal(name string) {
-	if p.scope.localCount == localsMaxSize {
-		p.error("too many local variables")
+	if p.scope.localCount >= p.cf.maxLocals {
+		p.error(fmt.Sprintf("too many local variables (max %d)", p.cf.maxLocals))
 		return
 	}
 
-	local := &p.scope.locals[p.scope.localCount]
-	local.name = name
-	local.depth = -1
+	p.scope.locals = append(p.scope.locals[:p.scope.localCount],
+		local{name: name, depth: -1},
+	)
 	p.scope.localCount++
 	p.stats.localMax = max(p.stats.localMax, p.scope.localCount)
 }
 
+// addHiddenLocal adds an initialized local which can't be referenced
+// by name, returning its slot.
+func (p *parser) addHiddenLocal(name string) int {
+	p.addLocal(name)
+	p.markInitialized()
+	return p.scope.localCount - 1
+}
+
 func (p *parser) markInitialized() {
 	p.scope.locals[p.scope.localCount-1].depth = p.scope.depth
 }
@@ -667,6 +1379,11 @@ func (p *parser) defBlock(typeIdx, nameIdx int) {
 	p.emitUvarint(nameIdx)
 }
 
+func (p *parser) defDynBlock(typeIdx int) {
+	p.emitOp(opDYNBLOCK)
+	p.emitUvarint(typeIdx)
+}
+
 func (p *parser) endBlock() {
 	p.emitOp(opENDBLOCK)
 }
@@ -676,10 +1393,22 @@ func (p *parser) resolveIdent(name string, canAssign bool) {
 	var idx int
 
 	idx = p.resolveLocal(p.scope, name)
+	if idx >= 0 && p.scope.locals[idx].isConst {
+		p.localRef(idx, canAssign)
+		return
+	}
 	if idx >= 0 {
 		setOp, getOp = opSETLOCAL, opGETLOCAL
+
+		if p.cf.strictScope && canAssign && p.check(tEQ) &&
+			p.scope.locals[idx].depth < p.blockScope {
+			p.errorAtCurrent(
+				"ambiguous assignment to a variable from outer scope;" +
+					" use var. or field. prefix",
+			)
+		}
 	} else {
-		if p.scope.depth == 0 {
+		if p.blockScope == 0 {
 			p.error("undefined variable")
 			return
 		}
@@ -689,6 +1418,30 @@ func (p *parser) resolveIdent(name string, canAssign bool) {
 		setOp, getOp = opSETFIELD, opGETFIELD
 	}
 
+	p.namedRef(getOp, setOp, idx, canAssign)
+}
+
+// localRef refers to a local variable, checking if it's not a const
+// being assigned; a const with a known value becomes that value.
+func (p *parser) localRef(idx int, canAssign bool) {
+	local := &p.scope.locals[idx]
+	switch {
+	case !local.isConst:
+		p.namedRef(opGETLOCAL, opSETLOCAL, idx, canAssign)
+	case canAssign && p.check(tEQ):
+		p.error("cannot assign to a constant")
+		p.panicMode = false // not a syntax error, the parsing can go on
+		p.advance()         // tEQ
+		expr(p)
+	case local.folded != nil:
+		p.emitBytes(local.folded...)
+	default:
+		p.emitOp(opGETLOCAL)
+		p.emitUvarint(idx)
+	}
+}
+
+func (p *parser) namedRef(getOp, setOp opcode, idx int, canAssign bool) {
 	if canAssign && p.match(tEQ) {
 		expr(p)
 		p.emitOp(setOp)
@@ -752,7 +1505,10 @@ func (p *parser) emitConst(v value) {
 	p.emitUvarint(idx)
 }
 
-const jumpByteLength = 2
+const (
+	jumpByteLength     = 2
+	wideJumpByteLength = 4
+)
 
 func (p *parser) emitJump(op opcode) int {
 	p.emitOp(op)
@@ -761,13 +1517,40 @@ func (p *parser) emitJump(op opcode) int {
 	return p.currentProg().count() - jumpByteLength
 }
 
+// emitForLoop emits FORRANGE, FORLIST or FORSTEP;
+// the step slot is used only by FORSTEP.
+func (p *parser) emitForLoop(op opcode, iterSlot, endSlot, stepSlot int) int {
+	p.emitOp(op)
+	p.emitUvarint(iterSlot)
+	p.emitUvarint(endSlot)
+	if op == opFORSTEP {
+		p.emitUvarint(stepSlot)
+	}
+	p.emitBytes(0xff, 0xff)
+	return p.currentProg().count() - jumpByteLength
+}
+
+func (p *parser) emitLoop(loopStart int) {
+	p.emitOp(opLOOP)
+
+	jump := p.currentProg().count() - loopStart + jumpByteLength
+	if jump > 1<<(8*jumpByteLength)-1 {
+		p.addFarJump(p.currentProg().count(), loopStart)
+		jump = 0
+	}
+
+	var b [jumpByteLength]byte
+	u16ToBytes(b[:], uint16(jump))
+	p.emitBytes(b[:]...)
+}
+
 func (p *parser) patchJump(offset int) {
 	prog := p.currentProg()
 	jump := prog.count() - offset - jumpByteLength
 
 	if jump > 1<<(8*jumpByteLength)-1 {
-		p.error("jump too long")
-		return
+		p.addFarJump(offset, prog.count())
+		jump = 0
 	}
 	if jump < 0 {
 		p.error("negative jump")
@@ -777,6 +1560,55 @@ func (p *parser) patchJump(offset int) {
 	u16ToBytes(prog.code[offset:], uint16(jump))
 }
 
+// addFarJump records the jump not fitting in u16;
+// such jumps get the wide ops when the parsing ends.
+func (p *parser) addFarJump(offset, target int) {
+	if p.farJumps == nil {
+		p.farJumps = make(map[int]int)
+	}
+	p.farJumps[offset] = target
+}
+
+// unquote gives the value of a string literal,
+// where `\${` is an escaped `${`, not starting an interpolation.
+func (p *parser) unquote(s string) string {
+	if s[0] != '`' {
+		s = unescapeInterp(s)
+	}
+	u, err := strconv.Unquote(s)
+	if err != nil {
+		p.error("invalid string literal")
+	}
+	return u
+}
+
+// unescapeInterp turns `\${` into `${`, leaving the other escapes
+// to strconv.Unquote, which doesn't know this one.
+func unescapeInterp(s string) string {
+	if !strings.Contains(s, `\${`) {
+		return s
+	}
+	b := new(strings.Builder)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if strings.HasPrefix(s[i+1:], "${") {
+				i++
+			} else {
+				b.WriteByte(s[i])
+				i++
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// fragment unquotes a part of the interpolated string, after cutting
+// the delimiters: `"` or `}` on the left, `${` or `"` on the right.
+func (p *parser) fragment(s string, left, right int) string {
+	return p.unquote(`"` + s[left:len(s)-right] + `"`)
+}
+
 func (p *parser) identConst(name string) int {
 	idx, ok := p.identRefs[name]
 	if !ok {
@@ -0,0 +1,124 @@
+package bcl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// posTable maps the code offsets to the source positions.
+// Consecutive code bytes with the same position make a run, and the runs
+// are delta-encoded, one after another, as a pair:
+//
+//	uvarint: offset of the run minus offset of the previous one
+//	uvarint: position of the run minus position of the previous one,
+//	         zigzag-encoded, as it can be negative
+//
+// where the first run starts at offset 0, relative to position 0.
+// Every posMarkEvery-th run is also marked, to find the run
+// of an offset with a binary search and then a few steps of decoding.
+type posTable struct {
+	runs  []byte
+	marks []posMark
+	count int     // runs
+	size  int     // code bytes covered
+	last  posMark // the last run
+}
+
+// posMark is a decoded run, with the index in the runs just past it.
+type posMark struct {
+	offset, pos, next int
+}
+
+const posMarkEvery = 16
+
+func newPosTable(codeCap int) *posTable {
+	return &posTable{runs: make([]byte, 0, codeCap/2)}
+}
+
+// add gives the position of the next code byte.
+func (t *posTable) add(pos int) {
+	if t.count > 0 && pos == t.last.pos {
+		t.size++
+		return
+	}
+	var b [18]byte
+	n := uvarintToBytes(b[:], uint64(t.size-t.last.offset))
+	n += uvarintToBytes(b[n:], zigzag(pos-t.last.pos))
+	t.runs = append(t.runs, b[:n]...)
+
+	t.last = posMark{offset: t.size, pos: pos, next: len(t.runs)}
+	if t.count%posMarkEvery == 0 {
+		t.marks = append(t.marks, t.last)
+	}
+	t.count++
+	t.size++
+}
+
+// at gives the position of the code byte at the offset.
+func (t *posTable) at(offset int) int {
+	i := sort.Search(len(t.marks), func(i int) bool {
+		return t.marks[i].offset > offset
+	})
+	m := t.marks[i-1]
+
+	for m.next < len(t.runs) {
+		d, n := uvarintFromBytes(t.runs[m.next:])
+		if m.offset+int(d) > offset {
+			break
+		}
+		dpos, k := uvarintFromBytes(t.runs[m.next+n:])
+		m = posMark{offset: m.offset + int(d), pos: m.pos + unzigzag(dpos), next: m.next + n + k}
+	}
+	return m.pos
+}
+
+// posTableFromRuns makes the table of the loaded runs, checking them.
+func posTableFromRuns(runs []byte, size int) (*posTable, error) {
+	t := &posTable{}
+	for k := 0; k < len(runs); {
+		d, n, ok := uvarintFromCode(runs[k:])
+		if !ok {
+			return nil, fmt.Errorf("position run %d: truncated", t.count)
+		}
+		dpos, m, ok := uvarintFromCode(runs[k+n:])
+		if !ok {
+			return nil, fmt.Errorf("position run %d: truncated", t.count)
+		}
+		k += n + m
+
+		offset, pos := uint64(t.last.offset)+d, t.last.pos+unzigzag(dpos)
+		switch {
+		case t.count == 0 && d != 0:
+			return nil, fmt.Errorf("position run 0: offset %d, expected 0", d)
+		case t.count > 0 && d == 0:
+			return nil, fmt.Errorf("position run %d: empty", t.count)
+		case d >= uint64(size) || offset >= uint64(size):
+			return nil, fmt.Errorf("position run %d: offset out of range: %d", t.count, offset)
+		case pos < 0 || pos > maxSourcePos:
+			return nil, fmt.Errorf("position run %d: position out of range: %d", t.count, pos)
+		}
+
+		t.last = posMark{offset: int(offset), pos: int(pos), next: k}
+		if t.count%posMarkEvery == 0 {
+			t.marks = append(t.marks, t.last)
+		}
+		t.count++
+	}
+	if t.count == 0 && size > 0 {
+		return nil, fmt.Errorf("no positions for %d bytes", size)
+	}
+	t.runs, t.size = runs, size
+	return t, nil
+}
+
+func zigzag(x int) uint64 {
+	return uint64(x<<1) ^ uint64(x>>63)
+}
+
+func unzigzag(u uint64) int {
+	return int(u>>1) ^ -int(u&1)
+}
+
+// maxSourcePos bounds the loaded positions, so that the deltas
+// between them never overflow.
+const maxSourcePos = 1<<48 - 1
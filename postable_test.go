@@ -0,0 +1,111 @@
+package bcl
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func posTableOf(positions []int) *posTable {
+	t := newPosTable(len(positions))
+	for _, pos := range positions {
+		t.add(pos)
+	}
+	return t
+}
+
+func TestPosTable(t *testing.T) {
+	var tab = [][]int{
+		{},
+		{0},
+		{5},
+		{1, 1, 1},
+		{0, 1, 2, 3},
+		{9, 9, 3, 3, 3, 7, 100000, 100000, 2},
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []int{posMarkEvery - 1, posMarkEvery, 10 * posMarkEvery, 5000} {
+		xx := make([]int, n)
+		pos := 0
+		for i := range xx {
+			if rnd.Intn(3) == 0 {
+				pos += rnd.Intn(200) - 50
+				pos = max(pos, 0)
+			}
+			xx[i] = pos
+		}
+		tab = append(tab, xx)
+	}
+
+	for i, positions := range tab {
+		pt := posTableOf(positions)
+		pt2, err := posTableFromRuns(pt.runs, len(positions))
+		if err != nil {
+			t.Errorf("tc#%d: runs not loaded: %v", i, err)
+			continue
+		}
+		if pt2.count != pt.count || len(pt2.marks) != len(pt.marks) {
+			t.Errorf("tc#%d: loaded table differs", i)
+		}
+
+		for offset, want := range positions {
+			if have := pt.at(offset); have != want {
+				t.Errorf("tc#%d: offset %d: have %d, want %d", i, offset, have, want)
+			}
+			if have := pt2.at(offset); have != want {
+				t.Errorf("tc#%d: loaded, offset %d: have %d, want %d", i, offset, have, want)
+			}
+		}
+		if pt.size != len(positions) || pt2.size != len(positions) {
+			t.Errorf("tc#%d: size mismatch", i)
+		}
+	}
+}
+
+func TestPosTableRuns(t *testing.T) {
+	// one run per distinct position in a row
+	pt := posTableOf([]int{7, 7, 7, 8, 8, 7})
+	if pt.count != 3 {
+		t.Errorf("runs: have %d, want 3", pt.count)
+	}
+	if want := []byte{0, 14, 3, 2, 2, 1}; string(pt.runs) != string(want) {
+		t.Errorf("encoded runs: have %v, want %v", pt.runs, want)
+	}
+}
+
+func TestPosTableFromRunsErrors(t *testing.T) {
+	var tab = []struct {
+		runs     []byte
+		size     int
+		errMatch string
+	}{
+		{[]byte{}, 1, "no positions for 1 bytes"},
+		{[]byte{1, 0}, 2, "run 0: offset 1, expected 0"},
+		{[]byte{0, 0, 0, 2}, 2, "run 1: empty"},
+		{[]byte{0, 0, 2, 2}, 2, "run 1: offset out of range: 2"},
+		{[]byte{0, 0, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0}, 2,
+			"run 1: offset out of range: 18446744073709551615",
+		},
+		{[]byte{0, 1}, 1, "run 0: position out of range: -1"},
+		{[]byte{0}, 1, "run 0: truncated"},
+		{[]byte{0xF5}, 1, "run 0: truncated"},
+	}
+	for i, tc := range tab {
+		_, err := posTableFromRuns(tc.runs, tc.size)
+		if err == nil || !strings.HasSuffix(err.Error(), tc.errMatch) {
+			t.Errorf("tc#%d: error mismatch\nhave: %v\nwant matching: %s", i, err, tc.errMatch)
+		}
+	}
+}
+
+func BenchmarkPosTableAt(b *testing.B) {
+	positions := make([]int, 1<<16)
+	for i := range positions {
+		positions[i] = i / 3
+	}
+	pt := posTableOf(positions)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pt.at(i & (1<<16 - 1))
+	}
+}
@@ -2,20 +2,137 @@ package bcl
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"fmt"
 	"io"
+	"math"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 )
 
 type Prog struct {
 	name      string
 	code      []byte
 	constants []value
-	positions []int
+	positions *posTable // nil when loaded from a stripped dump
 	linePos   *lineCalc
 
+	header    progHeader
+	checksum  [sha256.Size]byte
+	signature []byte
+
 	output, log io.Writer
 }
 
+// progHeader is what the dump says about the Prog, besides its name.
+type progHeader struct {
+	version  [2]uint8
+	compiler string
+	created  time.Time
+	source   [sha256.Size]byte
+	meta     map[string]string
+}
+
+// ProgInfo describes where a Prog comes from; see [Prog.Info].
+type ProgInfo struct {
+	Name     string
+	Version  string // bytecode version, like "4.2"
+	Compiler string // version of bcl which parsed the source
+	Created  time.Time
+
+	Source   [sha256.Size]byte // SHA-256 of the BCL source
+	Checksum [sha256.Size]byte // SHA-256 of the dump, zero before Dump or Load
+	Signed   bool              // if the dump is signed
+	Stripped bool              // if loaded without the source positions
+
+	Meta map[string]string
+}
+
+// Info gives the name, versions, timestamp, digests and metadata of the Prog.
+// The metadata map is a copy.
+func (p *Prog) Info() ProgInfo {
+	info := ProgInfo{
+		Name:     p.name,
+		Version:  fmt.Sprintf("%d.%d", p.header.version[0], p.header.version[1]),
+		Compiler: p.header.compiler,
+		Created:  p.header.created,
+		Source:   p.header.source,
+		Checksum: p.checksum,
+		Signed:   len(p.signature) > 0,
+		Stripped: p.positions == nil,
+	}
+	if len(p.header.meta) > 0 {
+		info.Meta = make(map[string]string, len(p.header.meta))
+		for k, v := range p.header.meta {
+			info.Meta[k] = v
+		}
+	}
+	return info
+}
+
+// SourceMatches tells if the Prog was parsed from the given source,
+// so that a dump cached from it is not stale.
+func (info ProgInfo) SourceMatches(source []byte) bool {
+	return sha256.Sum256(source) == info.Source
+}
+
+// Name gives the name of the Prog, which is the name of its source.
+func (p *Prog) Name() string {
+	return p.name
+}
+
+// Constants gives a copy of the constants of the Prog, which the operands
+// of the instructions refer to by their index. A constant is nil or one of:
+// int, float64, string, bool, time.Duration, time.Time, [Decimal].
+func (p *Prog) Constants() []any {
+	cc := make([]any, len(p.constants))
+	for i, v := range p.constants {
+		cc[i] = v
+	}
+	return cc
+}
+
+// Instruction is a decoded instruction of the Prog; see [Prog.Instructions].
+type Instruction struct {
+	Offset   int    // in the code
+	Op       string // opcode name, like "CONST" or "JUMPW"
+	Operands []int  // as encoded; a list of constants is its length followed by the indices
+	Target   int    // offset of the jump target, -1 if not a jump
+
+	Line, Col int // source position, 0 if not known
+}
+
+// Instructions gives an iterator over the decoded instructions of the Prog,
+// in the order of the code. It has the form of iter.Seq, so that with
+// Go 1.23 it can be ranged over.
+func (p *Prog) Instructions() func(yield func(Instruction) bool) {
+	return func(yield func(Instruction) bool) {
+		code, _ := decodeCode(p, nil) // the code is verified or made by the parser
+		for _, in := range code {
+			x := Instruction{
+				Offset:   in.offset,
+				Op:       in.raw.String(),
+				Operands: in.args,
+				Target:   -1,
+			}
+			if in.target != nil {
+				x.Target = in.target.offset
+			}
+			if in.pos >= 0 {
+				x.Line, x.Col = p.linePos.lineColAt(in.pos)
+			}
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
 func newProg(name string, w writers) *Prog {
 	return &Prog{
 		name:   name,
@@ -30,13 +147,51 @@ func (p *Prog) initForParse() {
 		constantsInitCap = 8
 	)
 	p.code = make([]byte, 0, codeInitCap)
-	p.positions = make([]int, 0, codeInitCap)
+	p.positions = newPosTable(codeInitCap)
 	p.constants = make([]value, 0, constantsInitCap)
+
+	p.header = progHeader{
+		version:  [2]uint8{bytecodeMajor, bytecodeMinor},
+		compiler: compilerVersion(),
+		created:  time.Unix(time.Now().Unix(), 0),
+	}
 }
 
+func (p *Prog) setMeta(meta map[string]string) {
+	if len(meta) == 0 {
+		return
+	}
+	p.header.meta = make(map[string]string, len(meta))
+	for k, v := range meta {
+		p.header.meta[k] = v
+	}
+}
+
+const modulePath = "github.com/wkhere/bcl"
+
+// compilerVersion gives the version of this module from the build info,
+// which is "(devel)" when it can't be known.
+var compilerVersion = sync.OnceValue(func() string {
+	v := ""
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Path == modulePath {
+			v = bi.Main.Version
+		}
+		for _, m := range bi.Deps {
+			if m.Path == modulePath {
+				v = m.Version
+			}
+		}
+	}
+	if v == "" {
+		v = "(devel)"
+	}
+	return "bcl " + v
+})
+
 func (p *Prog) write(b byte, pos int) {
 	p.code = append(p.code, b)
-	p.positions = append(p.positions, pos)
+	p.positions.add(pos)
 }
 
 func (p *Prog) addConst(v value) (idx int) {
@@ -46,32 +201,115 @@ func (p *Prog) addConst(v value) (idx int) {
 
 func (p *Prog) count() int { return len(p.code) }
 
+// posAt gives the source position of the code byte, or -1 if it's unknown.
+func (p *Prog) posAt(offset int) int {
+	if p.positions == nil {
+		return -1
+	}
+	return p.positions.at(offset)
+}
+
 // prog dump format:
 //
 // 2B: bytecode magic, then version: 1B: major, 1B: minor
+// 1B: flags: dumpSigned, dumpStripped
 // uvarint + n bytes: prog name
+// uvarint + n bytes: compiler version
+// varint: creation time, unix seconds
+// 32B: SHA-256 of the source
+// uvarint + n pairs of (uvarint + n bytes) strings: metadata, sorted by key
 // uvarint + n bytes: code
 // uvarint + n values: constants
-// uvarint + n uvarints: positions
-// uvarint + n uvarints: linepos (lfs)
+// uvarint + n bytes: position runs (see posTable), unless dumpStripped
+// uvarint + n uvarints: linepos (lfs), unless dumpStripped
+// 32B: SHA-256 of all the above
+// 64B: if dumpSigned, ed25519 signature of the above SHA-256
 
+// The minor version grows with new opcodes, the major one
+// when the existing ones or the dump layout change.
 const (
 	bytecodeMagic       = "\xFC\x6C"
-	bytecodeMajor uint8 = 2
-	bytecodeMinor uint8 = 0
+	bytecodeMajor uint8 = 4
+	bytecodeMinor uint8 = 2
 )
 
+const (
+	dumpSigned = 1 << iota
+	dumpStripped
+)
+
+// DumpOptions control how the Prog is dumped by [Prog.DumpWith].
+type DumpOptions struct {
+	// StripDebug omits the source positions, which take a good part
+	// of the dump; the errors and the disassembly of the loaded Prog
+	// show "?" in place of the line numbers.
+	StripDebug bool
+
+	// SignKey signs the dump with the ed25519 key, if set.
+	// LoadProg with [OptVerifyKey] accepts only such signed dumps.
+	SignKey ed25519.PrivateKey
+}
+
 func (prog *Prog) Dump(dest io.Writer) error {
-	w := bufio.NewWriterSize(dest, 4096)
-	w.Write(append([]byte(bytecodeMagic), bytecodeMajor, bytecodeMinor))
+	return prog.DumpWith(dest, DumpOptions{})
+}
+
+// DumpSigned dumps the Prog like Dump, signed with the ed25519 key.
+// LoadProg with [OptVerifyKey] accepts only such signed dumps.
+func (prog *Prog) DumpSigned(dest io.Writer, key ed25519.PrivateKey) error {
+	return prog.DumpWith(dest, DumpOptions{SignKey: key})
+}
+
+// DumpWith dumps the Prog like Dump, with the options.
+// The Prog loaded from a stripped dump is always dumped stripped.
+func (prog *Prog) DumpWith(dest io.Writer, opts DumpOptions) error {
+	key := opts.SignKey
+	if key != nil && len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid signing key")
+	}
+
+	var flags byte
+	if key != nil {
+		flags |= dumpSigned
+	}
+	strip := opts.StripDebug || prog.positions == nil
+	if strip {
+		flags |= dumpStripped
+	}
+	prog.signature = nil
+
+	h := sha256.New()
+	w := bufio.NewWriterSize(io.MultiWriter(dest, h), 4096)
+	w.Write(append([]byte(bytecodeMagic), bytecodeMajor, bytecodeMinor, flags))
 
 	var b [96]byte
 	var p = b[:]
 	var n int
 
-	n = uvarintToBytes(p, uint64(len(prog.name)))
+	writeString := func(s string) {
+		n = uvarintToBytes(p, uint64(len(s)))
+		w.Write(p[:n])
+		w.WriteString(s)
+	}
+
+	writeString(prog.name)
+	writeString(prog.header.compiler)
+
+	n = varintToBytes(p, prog.header.created.Unix())
+	w.Write(p[:n])
+	w.Write(prog.header.source[:])
+
+	keys := make([]string, 0, len(prog.header.meta))
+	for k := range prog.header.meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	n = uvarintToBytes(p, uint64(len(keys)))
 	w.Write(p[:n])
-	w.Write([]byte(prog.name))
+	for _, k := range keys {
+		writeString(k)
+		writeString(prog.header.meta[k])
+	}
 
 	n = uvarintToBytes(p, uint64(len(prog.code)))
 	w.Write(p[:n])
@@ -80,35 +318,63 @@ func (prog *Prog) Dump(dest io.Writer) error {
 	n = uvarintToBytes(p, uint64(len(prog.constants)))
 	w.Write(p[:n])
 	for _, v := range prog.constants {
-		// all but string can fit in a fixed buffer
-		if s, ok := v.(string); ok {
-			if 2+len(s) > len(p) {
-				p = make([]byte, 2+len(s))
-			}
+		// all but string and decimal can fit in a fixed buffer;
+		// these need a type byte and up to 9 bytes of length
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = x
+		case Decimal:
+			s = x.String()
+		}
+		if 10+len(s) > len(p) {
+			p = make([]byte, 10+len(s))
 		}
 		n = valueToBytes(p, v)
 		w.Write(p[:n])
 	}
 
-	n = uvarintToBytes(p, uint64(len(prog.positions)))
-	w.Write(p[:n])
-	for _, x := range prog.positions {
-		n = uvarintToBytes(p, uint64(x))
+	if !strip {
+		n = uvarintToBytes(p, uint64(len(prog.positions.runs)))
 		w.Write(p[:n])
-	}
+		w.Write(prog.positions.runs)
 
-	n = uvarintToBytes(p, uint64(len(prog.linePos.lfs)))
-	w.Write(p[:n])
-	for _, x := range prog.linePos.lfs {
-		n = uvarintToBytes(p, uint64(x))
+		n = uvarintToBytes(p, uint64(len(prog.linePos.lfs)))
 		w.Write(p[:n])
+		for _, x := range prog.linePos.lfs {
+			n = uvarintToBytes(p, uint64(x))
+			w.Write(p[:n])
+		}
 	}
 
-	return w.Flush()
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+	copy(prog.checksum[:], h.Sum(nil))
+	_, err = dest.Write(prog.checksum[:])
+	if err != nil || key == nil {
+		return err
+	}
+	prog.signature = ed25519.Sign(key, prog.checksum[:])
+	_, err = dest.Write(prog.signature)
+	return err
+}
+
+func (prog *Prog) Load(src io.Reader) error {
+	return prog.load(src, nil)
 }
 
-func (prog *Prog) Load(src io.Reader) (err error) {
-	r := bufio.NewReaderSize(src, 4096)
+// load reads the dump; with any keys given, the dump has to be signed
+// with one of them.
+func (prog *Prog) load(src io.Reader, keys []ed25519.PublicKey) (err error) {
+	// The whole dump is read first, to check the trailing checksum
+	// and signature right after the version.
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(bytes.NewReader(data), 4096)
 
 	var b [2]byte
 	var n int
@@ -125,80 +391,195 @@ func (prog *Prog) Load(src io.Reader) (err error) {
 	if n != 2 {
 		return fmt.Errorf("missing bcode major/minor version")
 	}
-	if b[0] != bytecodeMajor {
-		return fmt.Errorf("invalid bcode major version: have %d.x, want %d.x", b[0], bytecodeMajor)
+	if err := checkVersion(b[0], b[1]); err != nil {
+		return err
 	}
-	if b[1] > bytecodeMinor {
-		return fmt.Errorf("invalid bcode minor version: have %d.%d, want <=%d.%d",
-			b[0], b[1], b[0], bytecodeMinor,
-		)
+
+	prog.header.version = [2]uint8{b[0], b[1]}
+
+	const start = 5
+	if len(data) < start {
+		return fmt.Errorf("missing flags")
+	}
+	flags := data[4]
+	if flags&^(dumpSigned|dumpStripped) != 0 {
+		return fmt.Errorf("invalid flags: %#x", flags)
 	}
 
-	m, err = uvarintFromBuf(r)
+	end := len(data) - sha256.Size
+	if flags&dumpSigned != 0 {
+		end -= ed25519.SignatureSize
+	}
+	if end < start {
+		return fmt.Errorf("missing checksum")
+	}
+	if sha256.Sum256(data[:end]) != [sha256.Size]byte(data[end:end+sha256.Size]) {
+		return fmt.Errorf("checksum mismatch")
+	}
+	copy(prog.checksum[:], data[end:])
+	if flags&dumpSigned != 0 {
+		prog.signature = data[end+sha256.Size:]
+	}
+
+	if len(keys) > 0 {
+		if prog.signature == nil {
+			return fmt.Errorf("bytecode not signed")
+		}
+		if !slices.ContainsFunc(keys, func(k ed25519.PublicKey) bool {
+			return len(k) == ed25519.PublicKeySize &&
+				ed25519.Verify(k, prog.checksum[:], prog.signature)
+		}) {
+			return fmt.Errorf("invalid signature")
+		}
+	}
+
+	r = bufio.NewReaderSize(bytes.NewReader(data[start:end]), 4096)
+
+	prog.name, err = stringFromBuf(r)
 	if err != nil {
-		return fmt.Errorf("name size: %w", err)
+		return fmt.Errorf("name: %w", err)
 	}
-	p, err := r.Peek(int(m))
+	err = prog.header.load(r)
 	if err != nil {
-		return fmt.Errorf("name too short: %w", err)
+		return err
 	}
-	r.Discard(int(m))
-	prog.name = string(p)
 
-	m, err = uvarintFromBuf(r)
+	// The sizes are not trusted for the allocations,
+	// the slices grow as the data is actually read.
+
+	prog.code, err = bytesFromBuf(r, "code")
 	if err != nil {
-		return fmt.Errorf("code size: %w", err)
-	}
-	prog.code = make([]byte, m)
-	n, err = io.ReadFull(r, prog.code)
-	if n < int(m) {
-		if err != nil {
-			return fmt.Errorf("code too short: %w", err)
-		}
-		return fmt.Errorf("code too short")
+		return err
 	}
 
 	m, err = uvarintFromBuf(r)
 	if err != nil {
 		return fmt.Errorf("constants size: %w", err)
 	}
-	prog.constants = make([]value, int(m))
-	for i := 0; i < int(m); i++ {
-		prog.constants[i], err = valueFromBuf(r)
+	prog.constants = make([]value, 0, min(m, loadInitCap))
+	for i := uint64(0); i < m; i++ {
+		v, err := valueFromBuf(r)
 		if err != nil {
 			return fmt.Errorf("constant[%d]: %w", i, err)
 		}
+		prog.constants = append(prog.constants, v)
 	}
 
-	m, err = uvarintFromBuf(r)
+	prog.positions, prog.linePos = nil, &lineCalc{}
+	if flags&dumpStripped == 0 {
+		runs, err := bytesFromBuf(r, "positions")
+		if err != nil {
+			return err
+		}
+		prog.positions, err = posTableFromRuns(runs, len(prog.code))
+		if err != nil {
+			return err
+		}
+		prog.linePos.lfs, err = intsFromBuf(r, "lfs")
+		if err != nil {
+			return err
+		}
+		// the lines are looked up by a binary search
+		lfs := prog.linePos.lfs
+		for i := 1; i < len(lfs); i++ {
+			if lfs[i] <= lfs[i-1] {
+				return fmt.Errorf("lfs[%d]: invalid line feed offset %d", i, lfs[i])
+			}
+		}
+	}
+
+	if _, err := r.ReadByte(); err != io.EOF {
+		return fmt.Errorf("trailing data before checksum")
+	}
+	return prog.verify()
+}
+
+// checkVersion checks if the bytecode of the given version can be run.
+func checkVersion(major, minor uint8) error {
+	if major != bytecodeMajor {
+		return fmt.Errorf("invalid bcode major version: have %d.x, want %d.x", major, bytecodeMajor)
+	}
+	if minor > bytecodeMinor {
+		return fmt.Errorf("invalid bcode minor version: have %d.%d, want <=%d.%d",
+			major, minor, major, bytecodeMinor,
+		)
+	}
+	return nil
+}
+
+func (h *progHeader) load(r *bufio.Reader) (err error) {
+	h.compiler, err = stringFromBuf(r)
 	if err != nil {
-		return fmt.Errorf("positions size: %w", err)
+		return fmt.Errorf("compiler: %w", err)
 	}
-	prog.positions = make([]int, int(m))
-	for i := 0; i < int(m); i++ {
-		x, err := uvarintFromBuf(r)
+
+	x, err := uvarintFromBuf(r)
+	if err != nil {
+		return fmt.Errorf("creation time: %w", err)
+	}
+	h.created = time.Unix(u64ToI64(x), 0)
+
+	_, err = io.ReadFull(r, h.source[:])
+	if err != nil {
+		return fmt.Errorf("source digest: %w", err)
+	}
+
+	m, err := uvarintFromBuf(r)
+	if err != nil {
+		return fmt.Errorf("metadata size: %w", err)
+	}
+	for i := uint64(0); i < m; i++ {
+		k, err := stringFromBuf(r)
+		if err != nil {
+			return fmt.Errorf("metadata[%d]: %w", i, err)
+		}
+		v, err := stringFromBuf(r)
 		if err != nil {
-			return fmt.Errorf("position[%d]: %w", i, err)
+			return fmt.Errorf("metadata[%s]: %w", k, err)
 		}
-		prog.positions[i] = int(x)
+		if h.meta == nil {
+			h.meta = make(map[string]string)
+		}
+		h.meta[k] = v
 	}
+	return nil
+}
 
-	m, err = uvarintFromBuf(r)
+const loadInitCap = 1024
+
+// bytesFromBuf reads the size and then as many bytes.
+func bytesFromBuf(r *bufio.Reader, what string) ([]byte, error) {
+	m, err := uvarintFromBuf(r)
 	if err != nil {
-		return fmt.Errorf("lfs size: %w", err)
+		return nil, fmt.Errorf("%s size: %w", what, err)
 	}
-	prog.linePos = &lineCalc{lfs: make([]int, int(m))}
-	for i := 0; i < int(m); i++ {
-		x, err := uvarintFromBuf(r)
+	buf := new(bytes.Buffer)
+	k, err := io.Copy(buf, io.LimitReader(r, int64(min(m, math.MaxInt64))))
+	if uint64(k) < m {
 		if err != nil {
-			return fmt.Errorf("lfs[%d]: %w", i, err)
+			return nil, fmt.Errorf("%s too short: %w", what, err)
 		}
-		prog.linePos.lfs[i] = int(x)
+		return nil, fmt.Errorf("%s too short", what)
 	}
+	return buf.Bytes(), nil
+}
 
-	_, err = r.Read(b[:1])
-	if err == io.EOF {
-		return nil
+// intsFromBuf reads the count and then as many non-negative ints.
+func intsFromBuf(r *bufio.Reader, what string) ([]int, error) {
+	m, err := uvarintFromBuf(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s size: %w", what, err)
 	}
-	return err
+	xx := make([]int, 0, min(m, loadInitCap))
+	for i := uint64(0); i < m; i++ {
+		x, err := uvarintFromBuf(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", what, i, err)
+		}
+		if x > math.MaxInt {
+			return nil, fmt.Errorf("%s[%d]: out of range: %d", what, i, x)
+		}
+		xx = append(xx, int(x))
+	}
+	return xx, nil
 }
@@ -2,9 +2,13 @@ package bcl_test
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/wkhere/bcl"
 )
@@ -38,6 +42,384 @@ func TestBasicDumpLoad(t *testing.T) {
 	testDumpLoad(basicInput, t)
 }
 
+func TestValuesDumpLoad(t *testing.T) {
+	testDumpLoad([]byte(`
+		def b {
+			timeout = 1h30m
+			at = 2024-01-02T15:04:05.5+02:00
+			text = "`+strings.Repeat("long string ", 100)+`"
+		}
+	`), t)
+}
+
+func TestLongJumpsDumpLoad(t *testing.T) {
+	testDumpLoad([]byte(
+		"var a = 1; print a and ("+strings.Repeat("a + ", 12000)+"a)",
+	), t)
+}
+
+// formInputs are the progs written in the textual forms, asm and JSON,
+// and read back.
+var formInputs = []struct {
+	name  string
+	input []byte
+	opts  []bcl.Option
+}{
+	{"basic", basicInput, nil},
+	{"basic-O2", basicInput, []bcl.Option{bcl.OptOptimize(2), bcl.OptMeta("env", "prod")}},
+	{"values", []byte(`
+		def b {
+			timeout = 1h30m
+			tiny = -9223372036854775807ns - 1ns
+			at = 2024-01-02T15:04:05.5+02:00
+			price = 1.10d
+			ratio = 0.1 + 1e300
+			big = 1e300 * 1e300
+			nan = "NaN" @float
+			text = "quote \" tab \t ; not a comment <\u2028>"
+			flags = [true, false, nil]
+		}
+		print b.ratio @int; print "1" @float
+	`), []bcl.Option{bcl.OptOptimize(2)}},
+	{"long-jumps", []byte(
+		"var a = 1; print a and (" + strings.Repeat("a + ", 12000) + "a)",
+	), nil},
+	{"long-loop", []byte(
+		"for i in range(3) { print " + strings.Repeat("i + ", 12000) + "i }",
+	), nil},
+	{"list-loop", []byte(
+		"for x in [1, 2] { print " + strings.Repeat("x + ", 24000) + "x }",
+	), nil},
+}
+
+// testForms parses each of formInputs and checks its round trip.
+func testForms(roundTrip func(*bcl.Prog, *testing.T), t *testing.T) {
+	for _, tc := range formInputs {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append(tc.opts[:len(tc.opts):len(tc.opts)], bcl.OptOutput(io.Discard))
+			prog, err := bcl.Parse(tc.input, "input", opts...)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			roundTrip(prog, t)
+		})
+	}
+}
+
+// strippedProg gives the prog loaded from the stripped dump of the input.
+func strippedProg(input string, t *testing.T) *bcl.Prog {
+	t.Helper()
+
+	prog, err := bcl.Parse([]byte(input), "input", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	prog.DumpWith(b, bcl.DumpOptions{StripDebug: true})
+	prog, err = bcl.LoadProg(b, "input", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+func TestProgInfo(t *testing.T) {
+	input := []byte("def a { x = 1 }")
+	prog, err := bcl.Parse(input, "a.bcl",
+		bcl.OptOutput(io.Discard), bcl.OptMeta("env", "prod"), bcl.OptMeta("k", ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := prog.Info()
+	switch {
+	case info.Name != "a.bcl":
+		t.Errorf("name: %q", info.Name)
+	case info.Version != "4.2":
+		t.Errorf("version: %q", info.Version)
+	case !strings.HasPrefix(info.Compiler, "bcl "):
+		t.Errorf("compiler: %q", info.Compiler)
+	case time.Since(info.Created) > time.Minute:
+		t.Errorf("created: %v", info.Created)
+	case info.Source != sha256.Sum256(input):
+		t.Errorf("source digest: %x", info.Source)
+	case info.Checksum != [32]byte{} || info.Signed:
+		t.Errorf("checksum before dump: %x", info.Checksum)
+	case !reflect.DeepEqual(info.Meta, map[string]string{"env": "prod", "k": ""}):
+		t.Errorf("meta: %v", info.Meta)
+	}
+	if !info.SourceMatches(input) || info.SourceMatches(append(input, ' ')) {
+		t.Errorf("source match failed")
+	}
+
+	b := new(bytes.Buffer)
+	if err = prog.Dump(b); err != nil {
+		t.Fatal(err)
+	}
+	dump := b.Bytes()
+	prog2, err := bcl.LoadProg(bytes.NewReader(dump), "x", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info2 := prog2.Info()
+	if info2.Checksum != sha256.Sum256(dump[:len(dump)-32]) {
+		t.Errorf("checksum: %x", info2.Checksum)
+	}
+	if info.Checksum = info2.Checksum; !reflect.DeepEqual(info2, info) {
+		t.Errorf("info mismatch\nhave: %+v\nwant: %+v", info2, info)
+	}
+
+	info2.Meta["env"] = "dev"
+	if prog2.Info().Meta["env"] != "prod" {
+		t.Errorf("meta not copied")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	b := new(bytes.Buffer)
+	prog, _ := bcl.Parse(basicInput, "input", bcl.OptOutput(io.Discard))
+	prog.Dump(b)
+	dump := b.Bytes()
+
+	corrupt := func(i int, x byte) []byte {
+		d := bytes.Clone(dump)
+		d[i] = x
+		return d
+	}
+
+	var tab = []struct {
+		dump     []byte
+		errMatch string
+	}{
+		{dump[:1], "missing magic header"},
+		{corrupt(0, 'x'), "invalid magic header"},
+		{dump[:3], "missing bcode major/minor version"},
+		{corrupt(2, 3), "invalid bcode major version: have 3.x, want 4.x"},
+		{corrupt(3, 3), "invalid bcode minor version: have 4.3, want <=4.2"},
+		{dump[:4], "missing flags"},
+		{corrupt(4, 4), "invalid flags: 0x4"},
+		{corrupt(4, 1), "checksum mismatch"},
+		{dump[:20], "missing checksum"},
+		{dump[:len(dump)-1], "checksum mismatch"},
+		{corrupt(len(dump)/2, dump[len(dump)/2]+1), "checksum mismatch"},
+		{append(bytes.Clone(dump), 0), "checksum mismatch"},
+	}
+
+	for i, tc := range tab {
+		_, err := bcl.LoadProg(bytes.NewReader(tc.dump), "input", bcl.OptOutput(io.Discard))
+		if err == nil || err.Error() != tc.errMatch {
+			t.Errorf("tc#%d: error mismatch\nhave: %v\nwant: %s", i, err, tc.errMatch)
+		}
+	}
+}
+
+func TestSignedDump(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	pub := key.Public().(ed25519.PublicKey)
+	otherPub := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+
+	prog, err := bcl.Parse(basicInput, "input", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	prog.Dump(b)
+	unsigned := bytes.Clone(b.Bytes())
+	b.Reset()
+	if err = prog.DumpSigned(b, key); err != nil {
+		t.Fatal(err)
+	}
+	signed := b.Bytes()
+	if !prog.Info().Signed {
+		t.Errorf("prog not signed after the dump")
+	}
+
+	tamper := func(i int) []byte {
+		d := bytes.Clone(signed)
+		d[i] ^= 1
+		return d
+	}
+
+	var tab = []struct {
+		dump     []byte
+		keys     []ed25519.PublicKey
+		errMatch string
+	}{
+		{signed, nil, ""},
+		{signed, []ed25519.PublicKey{pub}, ""},
+		{signed, []ed25519.PublicKey{otherPub, pub}, ""},
+		{unsigned, nil, ""},
+		{signed, []ed25519.PublicKey{otherPub}, "invalid signature"},
+		{signed, []ed25519.PublicKey{pub[:8]}, "invalid signature"},
+		{unsigned, []ed25519.PublicKey{pub}, "bytecode not signed"},
+		{tamper(len(signed) - 1), []ed25519.PublicKey{pub}, "invalid signature"},
+		{tamper(len(signed) - 70), []ed25519.PublicKey{pub}, "checksum mismatch"},
+		{tamper(len(signed) / 2), []ed25519.PublicKey{pub}, "checksum mismatch"},
+		{signed[:len(signed)-1], []ed25519.PublicKey{pub}, "checksum mismatch"},
+	}
+
+	for i, tc := range tab {
+		opts := []bcl.Option{bcl.OptOutput(io.Discard)}
+		for _, k := range tc.keys {
+			opts = append(opts, bcl.OptVerifyKey(k))
+		}
+		prog2, err := bcl.LoadProg(bytes.NewReader(tc.dump), "input", opts...)
+
+		switch {
+		case err != nil && tc.errMatch == "":
+			t.Errorf("tc#%d: unexpected error: %v", i, err)
+		case err == nil && tc.errMatch != "":
+			t.Errorf("tc#%d: no error when expecting one", i)
+		case err != nil && err.Error() != tc.errMatch:
+			t.Errorf("tc#%d: error mismatch\nhave: %v\nwant: %s", i, err, tc.errMatch)
+		case err == nil && bytes.Equal(tc.dump, signed) && !reflect.DeepEqual(prog2, prog):
+			t.Errorf("tc#%d: progs not equal", i)
+		}
+	}
+
+	if err = prog.DumpSigned(io.Discard, key[:10]); err == nil {
+		t.Errorf("no error for the invalid signing key")
+	}
+}
+
+func TestStrippedDump(t *testing.T) {
+	prog, err := bcl.Parse([]byte("var x = 0\nprint 1\nprint 1 / x"), "input",
+		bcl.OptOutput(io.Discard),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, stripped := new(bytes.Buffer), new(bytes.Buffer)
+	prog.Dump(full)
+	if err = prog.DumpWith(stripped, bcl.DumpOptions{StripDebug: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	disasm := new(bytes.Buffer)
+	prog2, err := bcl.LoadProg(bytes.NewReader(stripped.Bytes()), "input",
+		bcl.OptDisasm(true), bcl.OptOutput(disasm),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prog2.Info().Stripped || prog.Info().Stripped {
+		t.Errorf("stripped info mismatch")
+	}
+	want := "== input ==\n" +
+		"0000      ?  ZERO\n" +
+		"0001      ?  ONE\n" +
+		"0002      ?  PRINT\n" +
+		"0003      ?  ONE\n" +
+		"0004      ?  GETLOCAL      0\n" +
+		"0006      ?  DIV\n" +
+		"0007      ?  PRINT\n" +
+		"0008      ?  POP\n" +
+		"0009      ?  RET\n"
+	if disasm.String() != want {
+		t.Errorf("disasm mismatch\nhave:\n%s\nwant:\n%s", disasm, want)
+	}
+
+	_, _, err = bcl.Execute(prog2, bcl.OptOutput(io.Discard))
+	if err == nil || err.Error() != "runtime error: line ?: division by int zero" {
+		t.Errorf("error mismatch: %v", err)
+	}
+
+	again := new(bytes.Buffer)
+	prog2.Dump(again)
+	if !bytes.Equal(again.Bytes(), stripped.Bytes()) {
+		t.Errorf("loaded stripped prog not dumped the same way")
+	}
+
+	big, _ := bcl.Parse(basicInput, "input", bcl.OptOutput(io.Discard))
+	full.Reset()
+	stripped.Reset()
+	big.Dump(full)
+	big.DumpWith(stripped, bcl.DumpOptions{StripDebug: true})
+	if k, n := stripped.Len(), full.Len(); k >= n {
+		t.Errorf("stripped dump too big: %d of %d bytes", k, n)
+	}
+}
+
+func TestProgIntrospection(t *testing.T) {
+	const input = "var x\nprint x ?? \"a\"\ndef b { y = 2 }\nprint b.y"
+	disasm := new(bytes.Buffer)
+	prog, err := bcl.Parse([]byte(input), "input",
+		bcl.OptDisasm(true), bcl.OptOutput(disasm),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prog.Name() != "input" {
+		t.Errorf("name mismatch: %s", prog.Name())
+	}
+	if cc := prog.Constants(); !reflect.DeepEqual(cc, []any{"a", "b", "", "y", 2}) {
+		t.Errorf("constants mismatch: %#v", cc)
+	}
+	p2, _ := bcl.Parse([]byte("print [1.5, 1.5d, 1s, 2024-01-02T15:04:05Z]"), "input")
+	cc := p2.Constants()
+	if _, ok := cc[1].(bcl.Decimal); !ok || len(cc) != 4 {
+		t.Errorf("constant types mismatch: %#v", cc)
+	}
+
+	var code []bcl.Instruction
+	prog.Instructions()(func(in bcl.Instruction) bool {
+		code = append(code, in)
+		return true
+	})
+	want := []bcl.Instruction{
+		{0, "NIL", nil, -1, 1, 6},
+		{1, "GETLOCAL", []int{0}, -1, 2, 8},
+		{3, "JNOTNIL", nil, 9, 2, 11},
+		{6, "POP", nil, -1, 2, 11},
+		{7, "CONST", []int{0}, -1, 2, 15},
+		{9, "PRINT", nil, -1, 2, 15},
+		{10, "DEFBLOCK", []int{1, 2}, -1, 3, 8},
+		{13, "CONST", []int{4}, -1, 3, 14},
+		{15, "SETFIELD", []int{3}, -1, 3, 14},
+		{17, "POP", nil, -1, 3, 14},
+		{18, "ENDBLOCK", nil, -1, 3, 16},
+		{19, "GETPATH", []int{2, 1, 3}, -1, 4, 10},
+		{23, "PRINT", nil, -1, 4, 10},
+		{24, "POP", nil, -1, 4, 10},
+		{25, "RET", nil, -1, 4, 10},
+	}
+	if !reflect.DeepEqual(code, want) {
+		t.Errorf("instructions mismatch\nhave: %+v\nwant: %+v", code, want)
+	}
+
+	n := 0
+	prog.Instructions()(func(bcl.Instruction) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("iteration not stopped, got %d instructions", n)
+	}
+
+	b := new(bytes.Buffer)
+	if err := prog.Disasm(b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != disasm.String() {
+		t.Errorf("disasm mismatch\nhave:\n%s\nwant:\n%s", b, disasm)
+	}
+
+	b.Reset()
+	prog.DumpWith(b, bcl.DumpOptions{StripDebug: true})
+	prog, err = bcl.LoadProg(b, "input", bcl.OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog.Instructions()(func(in bcl.Instruction) bool {
+		if in.Line != 0 || in.Col != 0 {
+			t.Errorf("position of the stripped instruction: %+v", in)
+		}
+		return true
+	})
+}
+
 func benchDumpLoad(input []byte, b *testing.B) {
 	prog, _ := bcl.Parse(input, "input", bcl.OptOutput(io.Discard))
 
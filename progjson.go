@@ -0,0 +1,303 @@
+package bcl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// The JSON form of the Prog is meant for the VMs written in other languages,
+// which can run it without decoding the bytecode; see the README.
+
+type progJSON struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Compiler  string            `json:"compiler"`
+	Created   time.Time         `json:"created"`
+	Source    string            `json:"source"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	Constants []typedJSON       `json:"constants"`
+	Code      []instrJSON       `json:"code"`
+	Lines     []int             `json:"lines,omitempty"`
+}
+
+type typedJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type instrJSON struct {
+	Offset int    `json:"offset"`
+	Op     string `json:"op"`
+	Args   []int  `json:"args,omitempty"`
+	Target *int   `json:"target,omitempty"`
+	Pos    *int   `json:"pos,omitempty"`
+}
+
+// MarshalJSON gives the Prog in its JSON form, with the decoded instructions.
+func (p *Prog) MarshalJSON() ([]byte, error) {
+	code, err := decodeCode(p, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pj := progJSON{
+		Name:      p.name,
+		Version:   fmt.Sprintf("%d.%d", p.header.version[0], p.header.version[1]),
+		Compiler:  p.header.compiler,
+		Created:   p.header.created.UTC(),
+		Source:    hex.EncodeToString(p.header.source[:]),
+		Meta:      p.header.meta,
+		Constants: make([]typedJSON, len(p.constants)),
+		Code:      make([]instrJSON, len(code)),
+	}
+	if p.linePos != nil {
+		pj.Lines = p.linePos.lfs
+	}
+
+	for i, v := range p.constants {
+		pj.Constants[i], err = constJSON(v)
+		if err != nil {
+			return nil, fmt.Errorf("constant %d: %w", i, err)
+		}
+	}
+
+	for i, in := range code {
+		ij := instrJSON{
+			Offset: in.offset,
+			Op:     in.raw.String(),
+			Args:   in.args,
+		}
+		if in.target != nil {
+			ij.Target = &in.target.offset
+		}
+		if pos := in.pos; pos >= 0 {
+			ij.Pos = &pos
+		}
+		pj.Code[i] = ij
+	}
+	return json.Marshal(pj)
+}
+
+// UnmarshalJSON sets the Prog from its JSON form, encoding the instructions
+// back into the bytecode, which is then verified like the one loaded with
+// [LoadProg]. The output and the log of the Prog, when not set,
+// are the standard output and error.
+func (p *Prog) UnmarshalJSON(data []byte) error {
+	var pj progJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+
+	q := &Prog{name: pj.Name, output: p.output, log: p.log}
+	if q.output == nil || q.log == nil {
+		cf := makeConfig(nil)
+		q.output, q.log = cf.output, cf.logw
+	}
+	if err := q.headerFromJSON(&pj); err != nil {
+		return err
+	}
+
+	q.constants = make([]value, len(pj.Constants))
+	for i, c := range pj.Constants {
+		v, err := constFromJSON(c)
+		if err != nil {
+			return fmt.Errorf("constant %d: %s: %w", i, c.Type, err)
+		}
+		q.constants[i] = v
+	}
+
+	q.linePos = newLineCalc()
+	for i, lf := range pj.Lines {
+		if lf < 0 || i > 0 && lf <= pj.Lines[i-1] {
+			return fmt.Errorf("invalid line feed offset %d", lf)
+		}
+	}
+	q.linePos.lfs = append(q.linePos.lfs, pj.Lines...)
+
+	if err := q.codeFromJSON(pj.Code); err != nil {
+		return err
+	}
+	if err := q.verify(); err != nil {
+		return err
+	}
+	*p = *q
+	return nil
+}
+
+func (p *Prog) headerFromJSON(pj *progJSON) error {
+	var major, minor uint8
+	if _, err := fmt.Sscanf(pj.Version, "%d.%d", &major, &minor); err != nil {
+		return fmt.Errorf("invalid bcode version: %q", pj.Version)
+	}
+	if err := checkVersion(major, minor); err != nil {
+		return err
+	}
+
+	source, err := hex.DecodeString(pj.Source)
+	if err != nil || len(source) != sha256.Size {
+		return fmt.Errorf("invalid source digest: %q", pj.Source)
+	}
+
+	p.header = progHeader{
+		version:  [2]uint8{major, minor},
+		compiler: pj.Compiler,
+		created:  time.Unix(pj.Created.Unix(), 0),
+	}
+	copy(p.header.source[:], source)
+	p.setMeta(pj.Meta)
+	return nil
+}
+
+func (p *Prog) codeFromJSON(cj []instrJSON) error {
+	if len(cj) == 0 {
+		return fmt.Errorf("invalid code: empty")
+	}
+
+	code := make([]*instr, len(cj))
+	at := make(map[int]*instr, len(cj))
+	for i, ij := range cj {
+		op, ok := opcodesByName[ij.Op]
+		if !ok {
+			return fmt.Errorf("code[%d]: unknown instruction %q", i, ij.Op)
+		}
+		code[i] = &instr{op: op, args: ij.Args}
+		at[ij.Offset] = code[i]
+	}
+
+	hasPos := cj[0].Pos != nil
+	for i, ij := range cj {
+		in := code[i]
+		if err := checkOperands(in, ij.Target != nil); err != nil {
+			return fmt.Errorf("code[%d]: %s: %w", i, in.op, err)
+		}
+		if ij.Target != nil {
+			if in.target = at[*ij.Target]; in.target == nil {
+				return fmt.Errorf("code[%d]: %s: jump to %04d, not to an instruction",
+					i, in.op, *ij.Target,
+				)
+			}
+		}
+		switch {
+		case (ij.Pos != nil) != hasPos:
+			return fmt.Errorf("code[%d]: %s: positions given only for some instructions",
+				i, in.op,
+			)
+		case hasPos && (*ij.Pos < 0 || *ij.Pos > maxSourcePos):
+			return fmt.Errorf("code[%d]: %s: position out of range: %d", i, in.op, *ij.Pos)
+		case hasPos:
+			in.pos = *ij.Pos
+		}
+	}
+
+	p.code, p.positions = encodeCode(code)
+	if !hasPos {
+		p.positions = nil
+	}
+	for i, in := range code {
+		if in.offset != cj[i].Offset {
+			return fmt.Errorf("code[%d]: %s: offset %04d, expected %04d",
+				i, in.op, cj[i].Offset, in.offset,
+			)
+		}
+	}
+	return nil
+}
+
+// checkOperands checks that the instruction has the operands its opcode
+// takes and that they fit the encoding.
+func checkOperands(in *instr, hasTarget bool) error {
+	k, jumps := 0, false
+	arg := func(limit int) error {
+		switch {
+		case k >= len(in.args):
+			return fmt.Errorf("missing operand")
+		case in.args[k] < 0 || in.args[k] > limit:
+			return fmt.Errorf("operand out of range: %d", in.args[k])
+		}
+		k++
+		return nil
+	}
+
+	for _, kind := range operands[in.op] {
+		var err error
+		switch kind {
+		case argByte:
+			err = arg(math.MaxUint8)
+		case argNum, argConst:
+			err = arg(math.MaxInt32)
+		case argConsts:
+			n := len(in.args) - k - 1
+			if err = arg(n); err == nil {
+				for j := in.args[k-1]; j > 0 && err == nil; j-- {
+					err = arg(math.MaxInt32)
+				}
+			}
+		case argJump, argLoop, argJumpW, argLoopW:
+			jumps = true
+		}
+		if err != nil {
+			return err
+		}
+	}
+	switch {
+	case k < len(in.args):
+		return fmt.Errorf("unexpected operand: %d", in.args[k])
+	case jumps && !hasTarget:
+		return fmt.Errorf("missing target")
+	case !jumps && hasTarget:
+		return fmt.Errorf("unexpected target")
+	}
+	return nil
+}
+
+// constJSON gives the constant with its type; the values which JSON
+// can't hold are strings, as in the assembly.
+func constJSON(v value) (c typedJSON, err error) {
+	c.Type = vtype(v)
+	switch x := v.(type) {
+	case nil:
+		return c, nil
+	case int, bool:
+		c.Value = json.RawMessage(constValue(x))
+	case float64:
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			c.Value, err = json.Marshal(constValue(x))
+		} else {
+			c.Value = json.RawMessage(constValue(x))
+		}
+	case string:
+		c.Value, err = json.Marshal(x)
+	default:
+		c.Value, err = json.Marshal(constValue(x))
+	}
+	return c, err
+}
+
+func constFromJSON(c typedJSON) (value, error) {
+	if c.Type == "nil" {
+		if len(c.Value) != 0 {
+			return nil, fmt.Errorf("unexpected value")
+		}
+		return nil, nil
+	}
+	if len(c.Value) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	s := string(c.Value)
+	if c.Value[0] == '"' {
+		if err := json.Unmarshal(c.Value, &s); err != nil {
+			return nil, err
+		}
+		if c.Type == "string" {
+			return s, nil
+		}
+	} else if c.Type != "int" && c.Type != "float" && c.Type != "bool" {
+		return nil, fmt.Errorf("expected string value, got %s", s)
+	}
+	return parseConst(c.Type, []string{s})
+}
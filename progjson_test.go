@@ -0,0 +1,127 @@
+package bcl_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wkhere/bcl"
+)
+
+func testJSONRoundTrip(prog *bcl.Prog, t *testing.T) {
+	t.Helper()
+
+	data, err := json.Marshal(prog)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	var prog2 bcl.Prog
+	if err := json.Unmarshal(data, &prog2); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	data2, _ := json.Marshal(&prog2)
+	if !bytes.Equal(data2, data) {
+		t.Errorf("json mismatch\nhave: %s\nwant: %s", data2, data)
+	}
+
+	b1, b2 := new(bytes.Buffer), new(bytes.Buffer)
+	prog.Dump(b1)
+	prog2.Dump(b2)
+	if !bytes.Equal(b1.Bytes(), b2.Bytes()) {
+		t.Errorf("unmarshaled prog dumped differently")
+	}
+}
+
+func TestJSONForms(t *testing.T) {
+	testForms(testJSONRoundTrip, t)
+}
+
+func TestStrippedJSON(t *testing.T) {
+	prog := strippedProg("var x = 0\nprint 1 / x", t)
+	testJSONRoundTrip(prog, t)
+
+	data, _ := json.Marshal(prog)
+	if bytes.Contains(data, []byte(`"pos"`)) || bytes.Contains(data, []byte(`"lines"`)) {
+		t.Errorf("positions in the stripped json: %s", data)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	const header = `"name": "input", "version": "4.2", "source": "` +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" + `"`
+	prog := func(rest string) string { return "{" + header + ", " + rest + "}" }
+	ret := `{"offset": 0, "op": "RET"}`
+
+	var tab = []struct {
+		input    string
+		errMatch string
+	}{
+		{`[]`, "cannot unmarshal array"},
+		{prog(`"version": "3.0"`), "invalid bcode major version: have 3.x, want 4.x"},
+		{prog(`"version": "4.9"`), "invalid bcode minor version: have 4.9, want <=4.2"},
+		{prog(`"version": "four"`), `invalid bcode version: "four"`},
+		{prog(`"source": "12"`), `invalid source digest: "12"`},
+		{prog(`"code": []`), "invalid code: empty"},
+		{prog(`"code": [{"offset": 0, "op": "NOPE"}]`), `code[0]: unknown instruction "NOPE"`},
+		{prog(`"code": [{"offset": 0, "op": "POPN"}]`), "code[0]: POPN: missing operand"},
+		{prog(`"code": [{"offset": 0, "op": "CONV", "args": [256]}]`),
+			"code[0]: CONV: operand out of range: 256",
+		},
+		{prog(`"code": [{"offset": 0, "op": "RET", "args": [1]}]`),
+			"code[0]: RET: unexpected operand: 1",
+		},
+		{prog(`"code": [{"offset": 0, "op": "GETPATH", "args": [3, 0]}]`),
+			"code[0]: GETPATH: operand out of range: 3",
+		},
+		{prog(`"code": [{"offset": 0, "op": "JUMP"}]`), "code[0]: JUMP: missing target"},
+		{prog(`"code": [{"offset": 0, "op": "RET", "target": 0}]`),
+			"code[0]: RET: unexpected target",
+		},
+		{prog(`"code": [{"offset": 0, "op": "JUMP", "target": 2}, ` + ret + `]`),
+			"code[0]: JUMP: jump to 0002, not to an instruction",
+		},
+		{prog(`"code": [{"offset": 0, "op": "ZERO", "pos": 0}, {"offset": 1, "op": "POP"}]`),
+			"code[1]: POP: positions given only for some instructions",
+		},
+		{prog(`"code": [{"offset": 0, "op": "RET", "pos": -1}]`),
+			"code[0]: RET: position out of range: -1",
+		},
+		{prog(`"code": [{"offset": 0, "op": "ZERO"}, {"offset": 2, "op": "POP"}, ` + ret + `]`),
+			"code[1]: POP: offset 0002, expected 0001",
+		},
+		{prog(`"constants": [{"type": "int", "value": "x"}], "code": [` + ret + `]`),
+			`constant 0: int: strconv.ParseInt: parsing "x"`,
+		},
+		{prog(`"constants": [{"type": "string", "value": 1}], "code": [` + ret + `]`),
+			"constant 0: string: expected string value, got 1",
+		},
+		{prog(`"constants": [{"type": "int"}], "code": [` + ret + `]`),
+			"constant 0: int: missing value",
+		},
+		{prog(`"constants": [{"type": "nil", "value": 1}], "code": [` + ret + `]`),
+			"constant 0: nil: unexpected value",
+		},
+		{prog(`"constants": [{"type": "list", "value": "[]"}], "code": [` + ret + `]`),
+			"constant 0: list: unknown type list",
+		},
+		{prog(`"lines": [5, 3], "code": [` + ret + `]`), "invalid line feed offset 3"},
+		{prog(`"code": [{"offset": 0, "op": "ZERO"}, {"offset": 1, "op": "RET"}]`),
+			"invalid code at 0001: RET: stack not empty at the end",
+		},
+	}
+
+	for i, tc := range tab {
+		var p bcl.Prog
+		err := json.Unmarshal([]byte(tc.input), &p)
+		switch {
+		case err == nil:
+			t.Errorf("tc#%d: no error when expecting one", i)
+		case !strings.Contains(err.Error(), tc.errMatch):
+			t.Errorf("tc#%d: error mismatch\nhave: %s\nwant matching: %s",
+				i, err, tc.errMatch,
+			)
+		}
+	}
+}
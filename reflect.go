@@ -1,6 +1,7 @@
 package bcl
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
 	"strings"
@@ -125,6 +126,16 @@ func copyBlock(v reflect.Value, block Block) error {
 			x = float64(vx.Int())
 			vx = reflect.ValueOf(x)
 
+		case t == reflect.TypeOf(Decimal{}):
+			return setDecimal(v.Field(namei), x.(Decimal), f, name)
+
+		case t == reflect.TypeOf([]value{}) && f.Type.Kind() == reflect.Slice:
+			return setList(v.Field(namei), x.([]value), f, name)
+
+		case t == reflect.TypeOf(map[string]value{}) && f.Type.Kind() == reflect.Map &&
+			f.Type.Key().Kind() == reflect.String:
+			return setMap(v.Field(namei), x.(map[string]value), f, name)
+
 		case !t.AssignableTo(f.Type):
 			return fmt.Errorf(
 				"type mismatch for the mapped field: struct.%s has %s, block.%s has %s",
@@ -153,6 +164,79 @@ fields:
 	return nil
 }
 
+// setDecimal puts the decimal into a Decimal field, a field of a type
+// implementing encoding.TextUnmarshaler, or into a string field.
+func setDecimal(fv reflect.Value, d Decimal, f reflect.StructField, name string) error {
+	if f.Type == reflect.TypeOf(d) {
+		fv.Set(reflect.ValueOf(d))
+		return nil
+	}
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		err := u.UnmarshalText([]byte(d.String()))
+		if err != nil {
+			return fmt.Errorf("struct.%s: %w", f.Name, err)
+		}
+		return nil
+	}
+	if f.Type.Kind() == reflect.String {
+		fv.SetString(d.String())
+		return nil
+	}
+	return fmt.Errorf(
+		"type mismatch for the mapped field: struct.%s has %s, block.%s has decimal",
+		f.Name, f.Type, name,
+	)
+}
+
+// setList puts the list into a slice field, converting the elements
+// the same way as the fields: ints can go into a float64 slice.
+func setList(fv reflect.Value, list []value, f reflect.StructField, name string) error {
+	s := reflect.MakeSlice(f.Type, len(list), len(list))
+	for i, x := range list {
+		ex, ok := elemValue(x, f.Type.Elem())
+		if !ok {
+			return fmt.Errorf(
+				"type mismatch for the mapped field: struct.%s has %s, block.%s[%d] has %s",
+				f.Name, f.Type, name, i, vtype(x),
+			)
+		}
+		s.Index(i).Set(ex)
+	}
+	fv.Set(s)
+	return nil
+}
+
+// setMap puts the map into a field being a map with string keys.
+func setMap(fv reflect.Value, m map[string]value, f reflect.StructField, name string) error {
+	mv := reflect.MakeMapWithSize(f.Type, len(m))
+	for k, x := range m {
+		ex, ok := elemValue(x, f.Type.Elem())
+		if !ok {
+			return fmt.Errorf(
+				"type mismatch for the mapped field: struct.%s has %s, block.%s[%q] has %s",
+				f.Name, f.Type, name, k, vtype(x),
+			)
+		}
+		mv.SetMapIndex(reflect.ValueOf(k).Convert(f.Type.Key()), ex)
+	}
+	fv.Set(mv)
+	return nil
+}
+
+func elemValue(x value, et reflect.Type) (reflect.Value, bool) {
+	if x == nil {
+		return reflect.Zero(et), true
+	}
+	vx := reflect.ValueOf(x)
+	switch t := vx.Type(); {
+	case t.Kind() == reflect.Int && et.Kind() == reflect.Float64:
+		return reflect.ValueOf(float64(vx.Int())).Convert(et), true
+	case !t.AssignableTo(et):
+		return vx, false
+	}
+	return vx, true
+}
+
 type fieldMappingErr string
 
 func (e fieldMappingErr) Error() string { return string(e) }
@@ -1,10 +1,13 @@
 package bcl
 
 import (
+	"errors"
 	"io"
+	"math/big"
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 )
 
 type Struct1 struct {
@@ -123,6 +126,35 @@ type S5 struct {
 	I int
 }
 
+type S6 struct {
+	Timeout time.Duration
+	At      time.Time
+}
+
+type S7 struct {
+	Price Amount
+	Text  string
+	Count int
+	Exact Decimal
+}
+
+type S8 struct {
+	Tags    []string
+	Weights []float64
+	Limits  map[string]int
+	Labels  map[string]any
+}
+
+type Amount string
+
+func (a *Amount) UnmarshalText(b []byte) error {
+	if string(b) == "0" {
+		return errors.New("zero amount")
+	}
+	*a = Amount("$" + string(b))
+	return nil
+}
+
 var reflectTab = []reflecttc{
 
 	rerror(``, nil, "no binding"),
@@ -324,6 +356,48 @@ var reflectTab = []reflecttc{
 	rvalid(`def s5{x=2.2}; bind s5`, &S5{}, &S5{X: 2.2}),
 	rvalid(`def s5{i=3}; bind s5`, &S5{}, &S5{I: 3}),
 	rerror(`def s5{i=3.2}; bind s5`, &S5{}, `type mismatch.+struct.I has int, block.i has float64`),
+	rvalid(`def s6{timeout=1m30s}; bind s6`, &S6{}, &S6{Timeout: 90 * time.Second}),
+	rvalid(`def s6{at=2024-01-02T15:04:05Z+1h}; bind s6`, &S6{},
+		&S6{At: time.Date(2024, 1, 2, 16, 4, 5, 0, time.UTC)},
+	),
+	rerror(`def s6{timeout=90}; bind s6`, &S6{},
+		`type mismatch.+struct.Timeout has time.Duration, block.timeout has int`,
+	),
+
+	// 95
+	rerror(`def s6{at="2024-01-02T15:04:05Z"}; bind s6`, &S6{},
+		`type mismatch.+struct.At has time.Time, block.at has string`,
+	),
+	rvalid(`def s7{price=12.50d}; bind s7`, &S7{}, &S7{Price: "$12.50"}),
+	rvalid(`def s7{text=0.1d+0.2d}; bind s7`, &S7{}, &S7{Text: "0.3"}),
+	rerror(`def s7{count=1d}; bind s7`, &S7{},
+		`type mismatch.+struct.Count has int, block.count has decimal`,
+	),
+	rerror(`def s7{price=0d}; bind s7`, &S7{}, `struct.Price: zero amount`),
+
+	// 100
+	rvalid(`def s8{tags=split("a,b", ",")}; bind s8`, &S8{},
+		&S8{Tags: []string{"a", "b"}},
+	),
+	rvalid(`def s8{tags=split("", ",")}; bind s8`, &S8{}, &S8{Tags: []string{}}),
+	rerror(`def s8{weights=split("1", ",")}; bind s8`, &S8{},
+		`type mismatch.+struct.Weights has \[\]float64, block.weights\[0\] has string`,
+	),
+	rerror(`def s8{tags="a"}; bind s8`, &S8{},
+		`type mismatch.+struct.Tags has \[\]string, block.tags has string`,
+	),
+	rvalid(`def s8{weights=[1, 2.5]; limits={cpu: 2, "mem-gb": 4}}; bind s8`, &S8{},
+		&S8{Weights: []float64{1, 2.5}, Limits: map[string]int{"cpu": 2, "mem-gb": 4}},
+	),
+
+	// 105
+	rvalid(`def s8{labels={a: "x", b: nil, c: 1}}; bind s8`, &S8{},
+		&S8{Labels: map[string]any{"a": "x", "b": nil, "c": 1}},
+	),
+	rerror(`def s8{limits={cpu: 0.5}}; bind s8`, &S8{},
+		`type mismatch.+struct.Limits has map\[string\]int, block.limits\["cpu"\] has float`,
+	),
+	rvalid(`def s7{exact=12.50d}; bind s7`, &S7{}, &S7{Exact: Decimal{big.NewInt(1250), 2}}),
 }
 
 func TestReflect(t *testing.T) {
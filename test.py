@@ -119,8 +119,9 @@ tests = [
     ['58',   'eval (1', '',     "err: expected ')'"],
     ['59',   'def 1', '',         "err: at '1': expected block type"],
     ['60',   'def b {', '',       "err: at end: expected '}'"],
-    ['61.1', 'def b x {}', '',    "err: at 'x': expected '{'"],
-    ['61.2', 'def b 0 {}', '',    "err: at '0': expected '{'"],
+    ['61.1', 'def b x {}', '',    "err: at 'x': undefined variable"],
+    ['61.2', 'def b 0 {}', '',    "err: block name: invalid type: int, expected string"],
+    ['61.3', 'def b "x" y {}', '', "err: at 'y': expected '{'"],
     ['62.1', 'def b "x" {', '',   "err: at end: expected '}'"],
     ['62.2', 'def b "x" { z', '', "err: at end: expected '}'"],
     ['63.1', 'eval 1 =', '',      "err: at '=': invalid assignment target"],
@@ -637,6 +638,692 @@ tests = [
     ['133.4', 'def x{}; bind {x:"a",}', '',  'err: block x:"a" not found'],
     ['133.5', 'bind{bind}',             '',  'err: expected block type'],
     ['133.6', 'bind{bind{}}',           '',  'err: expected block type'],
+
+    ['134.1', 'def t "p" {x=1}; print t.p.x',     '1'],
+    ['134.2', 'def t "p" {x=1}; print t."p".x',   '1'],
+    ['134.3', 'def t {x=1}; print t.x',           '1'],
+    ['134.4', 'def t "p-1" {x=1}; def c {y=t."p-1".x+1; print y}', '2'],
+    ['134.5', 'def t {def u "v" {x=1}}; print t.u.v.x',   '1'],
+    ['134.6', 'def t {def u "v" {x=1}}; print t.u."v".x', '1'],
+    ['134.7', 'def t {x=1}; def t {x=2}; print t.x',      '2'],
+    ['134.8', 'def t "p" {x=1}; def t "q" {x=2}; print t.p.x+t.q.x', '3'],
+    ['134.9', 'def t "p" {x=1}; eval t.p.x',
+        "== /dev/stdin ==\n"
+        "0000   1:12  DEFBLOCK      0 't'\t   1 'p'\n"
+        "0003   1:15  ONE\n"
+        "0004      |  SETFIELD      2 'x'\n"
+        "0006      |  POP\n"
+        "0007   1:16  ENDBLOCK\n"
+        "0008   1:28  GETPATH       3#\t   0 't'\t   1 'p'\t   2 'x'\n"
+        "0013      |  POP\n"
+        "0014      |  RET",
+        'disasm'
+    ],
+
+    ['135.1', 'print t.x', '',                    "err: path 't.x': no blocks of type t"],
+    ['135.2', 'def t {}; print t.x', '',          "err: path 't.x' not resolved as block field"],
+    ['135.3', 'def t "p" {}; print t.q.x', '',    "err: path 't.q.x' not resolved as block field"],
+    ['135.4', 'def t "p" {}; print t.p', '',      "err: path 't.p' refers to a block"],
+    ['135.5', 'def t {x=1}; eval t.x=2', '',      "err: at '=': invalid assignment target"],
+    ['135.6', 'def t {x=1}; print t.', '',        "err: expected block name or field after '.'"],
+    ['135.7', 'def t {x=1}; print t.1', '',       "err: at '1': expected block name or field"],
+    ['135.8', 'def t {print t.x}', '',            "err: path 't.x': no blocks of type t"],
+
+    ['136.1', 'var x=1; def b {var.x=2; print x}; print x',      '2\n2'],
+    ['136.2', 'var x=1; def b {field.x=2; print x}; print x',    '1\n1'],
+    ['136.3', 'var x=1; def b {field.x=2; print field.x}',       '2'],
+    ['136.4', 'var x=1; def b {x=2}; print x',                   '2'],
+    ['136.5', 'def b {var x=1; var.x=x+1; print var.x}',         '2'],
+    ['136.6', 'var x; eval var.x=5; print var.x',                '5'],
+    ['136.7', 'def b {x=1; def c {print field.x}}',              '1'],
+    ['136.8', 'def b {field=1; print field}',                    '1'],
+    ['136.9', 'def b {print var.x}', '',         "err: at 'x': undefined variable"],
+    ['136.10', 'print field.x', '',              "err: at 'x': field reference outside of a block"],
+    ['136.11', 'var.x=1', '',                    "err: at 'var': expected statement"],
+    ['136.12', 'def b {var x; print var x}', '', "err: at 'x': expected '.' after 'var'"],
+    ['136.13', 'def b {field.1=1}', '',          "err: at '1': expected field name after 'field.'"],
+    ['136.14', 'def b {x=1}; print field.x', '', "err: field reference outside of a block"],
+    ['136.15', 'def b {print field.x}', '',      "err: 'x' not resolved as var or field"],
+
+    ['137.1', 'for i in range(3) {print i}',      '0\n1\n2'],
+    ['137.2', 'for i in range(1, 3) {print i}',   '1\n2'],
+    ['137.3', 'for i in range(3, 1) {print i}',   ''],
+    ['137.4', 'for i in range(0) {print i}',      ''],
+    ['137.5', 'var n=2; for i in range(n) {for j in range(i, n) {print i*10+j}}', '0\n1\n11'],
+    ['137.6', 'for i in range(3) {eval i=10; print i}',   '10\n10\n10'],
+    ['137.7', 'for i in range(2) {var x=i+1; print x}',   '1\n2'],
+    ['137.8', 'def b {for i in range(3) {f=i}; print f}', '2'],
+    ['137.9', 'for i in range(2) {def w "w"+i {p=9000+i}}; print w.w0.p+w.w1.p', '18001'],
+    ['137.10', 'var x="a"; for i in range(2) {eval x=x+i}; print x', 'a01'],
+    ['137.11', 'for i in range(2) {eval i}',
+        "== /dev/stdin ==\n"
+        "0000   1:17  CONST         0 '2'\n"
+        "0002      |  ZERO\n"
+        "0003   1:20  FORRANGE      1    0   16 -> 0024\n"
+        "0008      |  GETLOCAL      1\n"
+        "0010   1:26  GETLOCAL      2\n"
+        "0012      |  POP\n"
+        "0013   1:27  POP\n"
+        "0014      |  GETLOCAL      1\n"
+        "0016      |  ONE\n"
+        "0017      |  ADD\n"
+        "0018      |  SETLOCAL      1\n"
+        "0020      |  POP\n"
+        "0021      |  LOOP         21 -> 0003\n"
+        "0024      |  POPN          2\n"
+        "0026      |  RET",
+        'disasm'
+    ],
+    ['137.12', 'for x in [1, "a", 2.5] {print x}', '1\na\n2.5'],
+    ['137.13', 'for x in [] {print x}; for x in nil {print x}', ''],
+    ['137.14', 'var m = {b: 2, a: 1}; for v in values(m) {print v}; for k in keys(m) {print k}',
+        '1\n2\na\nb'],
+    ['137.15', 'for x in [[1, 2], [3]] {for y in x {print y}}', '1\n2\n3'],
+    ['137.16', 'var xs = [1, 2]; for x in xs {eval x = x * 10; print x}; print xs', '10\n20\n[1, 2]'],
+    ['137.17', 'def b {for x in split("a b", " ") {f = x}; print f}', 'b'],
+    ['137.19', 'for i in range(0, 10, 4) {print i}; for i in range(3, 0, -2) {print i}', '0\n4\n8\n3\n1'],
+    ['137.20', 'for i in range(0, 3, -1) {print i}; for i in range(3, 0, 1) {print i}', ''],
+    ['137.21', 'var range = [7]; for i in range {print i}; for i in range(1) {print i}', '7\n0'],
+    ['137.18', 'for x in [1, 2] {eval x}',
+        "== /dev/stdin ==\n"
+        "0000   1:12  ONE\n"
+        "0001   1:15  CONST         0 '2'\n"
+        "0003   1:16  LIST          2\n"
+        "0005      |  ZERO\n"
+        "0006   1:18  FORLIST       1    0   14 -> 0025\n"
+        "0011   1:24  GETLOCAL      2\n"
+        "0013      |  POP\n"
+        "0014   1:25  POP\n"
+        "0015      |  GETLOCAL      1\n"
+        "0017      |  ONE\n"
+        "0018      |  ADD\n"
+        "0019      |  SETLOCAL      1\n"
+        "0021      |  POP\n"
+        "0022      |  LOOP         19 -> 0006\n"
+        "0025      |  POPN          2\n"
+        "0027      |  RET",
+        'disasm'
+    ],
+
+    ['138.1', 'for i in range("a", "b") {}', '',  "err: range: invalid types: string, string, expected int"],
+    ['138.2', 'for i in range(1.5) {}', '',       "err: range: invalid types: int, float, expected int"],
+    ['138.3', 'for i in range(nil, 2) {}', '',    "err: range: invalid types: nil, int, expected int"],
+    ['138.3.1', 'for i in range(0, 2, 0.5) {}', '', "err: range: invalid types: int, int, float, expected int"],
+    ['138.3.2', 'for i in range(0, 2, 0) {}', '',   "err: range: zero step"],
+    ['138.3.3', 'for i in range(0, 2, 1, 1) {}', '', "err: expected ')' after range arguments"],
+    ['138.4', 'for i in rang(3) {}', '',          "err: at 'rang': unknown function 'rang'"],
+    ['138.5', 'for i range(3) {}', '',            "err: at 'range': expected 'in'"],
+    ['138.6', 'for 1 in range(3) {}', '',         "err: at '1': expected loop variable name"],
+    ['138.7', 'for i in range(3 {}', '',          "err: expected ')' after range arguments"],
+    ['138.8', 'for i in range(3) print i', '',    "err: at 'print': expected '{'"],
+    ['138.9', 'for i in range(3) {x=1}', '',      "err: at 'x': expected statement"],
+    ['138.10', 'for i in range(3) {print i', '',   "err: at end: expected '}'"],
+    ['138.11', 'for x in "abc" {}', '',            "err: for: cannot iterate over string"],
+    ['138.12', 'for x in {a: 1} {}', '',           "err: for: cannot iterate over map"],
+    ['138.13', 'for x in 3 {}', '',                "err: for: cannot iterate over int"],
+
+    ['139.1', 'def b "x"+1 {print NAME}',                'x1'],
+    ['139.2', 'var n="q"; def b n {print NAME}',         'q'],
+    ['139.3', 'var n="q"; def b (n+n) {print TYPE+NAME}', 'bqq'],
+    ['139.4', 'def b "x"+1 {}; print b.x1.TYPE', '',      "err: path 'b.x1.TYPE' not resolved"],
+    ['139.5', 'def b nil {}', '',        "err: block name: invalid type: nil, expected string"],
+    ['139.6', 'def b {for i in range(2) {def c "x" {}}}', '', "err: child c.x duplicate at parent"],
+    ['139.7', 'var n="q"; def b n {}',
+        "== /dev/stdin ==\n"
+        "0000   1:10  CONST         0 'q'\n"
+        "0002   1:19  GETLOCAL      0\n"
+        "0004   1:21  DYNBLOCK      1 'b'\n"
+        "0006   1:22  ENDBLOCK\n"
+        "0007      |  POP\n"
+        "0008      |  RET",
+        'disasm'
+    ],
+
+    ['140.1', 'if true {print 1}',                         '1'],
+    ['140.2', 'if false {print 1}',                        ''],
+    ['140.3', 'if 0 {print 1} else {print 2}',             '2'],
+    ['140.4', 'var x=2; if x==0 {print 0} else if x==1 {print 1} else {print 2}', '2'],
+    ['140.5', 'var x=1; if x==0 {print 0} else if x==1 {print 1}', '1'],
+    ['140.6', 'if true {var y=1; print y}; var y=2; print y', '1\n2'],
+    ['140.7', 'def b {if true {p=0} else {p=1}; print p}',  '0'],
+    ['140.8', 'if true {def b "x" {}}; print b.x.TYPE', '', "err: not resolved as block field"],
+    ['140.9', 'for i in range(4) {if i>1 {print i}}',     '2\n3'],
+    ['140.10', 'if true {eval 1} else {eval 2}',
+        "== /dev/stdin ==\n"
+        "0000    1:8  TRUE\n"
+        "0001   1:10  JFALSE        6 -> 0010\n"
+        "0004      |  POP\n"
+        "0005   1:16  ONE\n"
+        "0006      |  POP\n"
+        "0007   1:17  JUMP          4 -> 0014\n"
+        "0010      |  POP\n"
+        "0011   1:30  CONST         0 '2'\n"
+        "0013      |  POP\n"
+        "0014   1:31  RET",
+        'disasm'
+    ],
+
+    ['141.1', 'print if true then 0 else 5',             '0'],
+    ['141.2', 'print if false then 0 else ""',           ''],
+    ['141.3', 'print if nil then 1 else 2+3',            '5'],
+    ['141.4', 'print 1 + if 1>2 then 10 else 20',        '21'],
+    ['141.5', 'var p=0; print if p==0 then "" else "x"', ''],
+    ['141.6', 'def b {q = if true then false else true; print q}', 'false'],
+    ['141.7', 'print if true then if false then 1 else 2 else 3', '2'],
+    ['141.8', 'eval if true then 1 else 2',
+        "== /dev/stdin ==\n"
+        "0000   1:13  TRUE\n"
+        "0001   1:18  JFALSE        5 -> 0009\n"
+        "0004      |  POP\n"
+        "0005   1:20  ONE\n"
+        "0006      |  JUMP          3 -> 0012\n"
+        "0009      |  POP\n"
+        "0010   1:27  CONST         0 '2'\n"
+        "0012      |  POP\n"
+        "0013      |  RET",
+        'disasm'
+    ],
+
+    ['142.1', 'print if true then 1', '',      "err: at end: expected 'else' in conditional expression"],
+    ['142.2', 'print if true 1 else 2', '',    "err: at '1': expected 'then' after condition"],
+    ['142.3', 'if true print 1', '',           "err: at 'print': expected '{' after condition"],
+    ['142.4', 'if true {} else print 2', '',   "err: at 'print': expected '{' or 'if' after 'else'"],
+    ['142.5', 'if true {print 1', '',          "err: at end: expected '}'"],
+    ['142.6', 'else {}', '',                   "err: at 'else': expected statement"],
+
+    ['143.1', 'var h="h"; var d="d.com"; var p=80; print "${h}.${d}:${p}"', 'h.d.com:80'],
+    ['143.2', 'print "a${1+2}b"',                    'a3b'],
+    ['143.3', 'print "${1}"',                        '1'],
+    ['143.4', 'print "${1.5}${true}${nil}${"s"}"',   '1.5trues'],
+    ['143.5', 'print "${"x${1}y"}"',                 'x1y'],
+    ['143.6', 'print "\\${x} $$ $"',                 '${x} $$ $'],
+    ['143.7', 'print "${1}" + "!"',                  '1!'],
+    ['143.8', 'def b "w${2}" {print NAME}',          'w2'],
+    ['143.9', 'print "\\t${1}\\n"',                  '\t1'],
+    ['143.10', 'def t "p" {x=1}; print "x=${t.p.x}"', 'x=1'],
+    ['143.11', 'print "a${1}b${2}"',
+        "== /dev/stdin ==\n"
+        "0000   1:11  CONST         0 'a'\n"
+        "0002   1:12  ONE\n"
+        "0003      |  TOSTR\n"
+        "0004      |  ADD\n"
+        "0005   1:16  CONST         1 'b'\n"
+        "0007      |  ADD\n"
+        "0008   1:17  CONST         2 '2'\n"
+        "0010      |  TOSTR\n"
+        "0011      |  ADD\n"
+        "0012   1:19  PRINT\n"
+        "0013      |  RET\n"
+        "a1b2",
+        'disasm'
+    ],
+    ['143.12', 'print "$${1}"',                      '$1'],
+    ['143.13', 'print "\\\\${1}"',                   '\\1'],
+    ['143.14', 'print "\\\\\\${x}"',                 '\\${x}'],
+    ['143.15', 'print "a $${b}"',                    '',   "err: at 'b': undefined variable"],
+
+    ['144.1', 'print "${x}"', '',            "err: at 'x': undefined variable"],
+    ['144.2', 'print "a${1"', '',            "err: invalid syntax"],
+    ['144.3', 'print "a${1}', '',            "err: unterminated quoted string"],
+    ['144.4', 'print "a${1 2}"', '',         "err: at '2': expected '}' ending the string interpolation"],
+    ['144.5', 'print "${}"', '',             "err: expected expression"],
+    ['144.6', 'print "${1+true}"', '',       "err: line 1:16: ADD: invalid types: int, bool"],
+    ['144.7', 'def b {print "${(x=1)+true}"}', '', "err: ADD: invalid types: int, bool"],
+    ['144.8', 'print "\\q"', '',             "err: invalid string literal"],
+
+    ['145.1', 'print `a\\n${x}`',            'a\\n${x}'],
+    ['145.2', 'print `a\nb` + "c"',          'a\nbc'],
+    ['145.3', 'print `\\${x}`',               '\\${x}'],
+    ['145.4', 'print <<EOF\nfoo\n  bar\nEOF\n', 'foo\n  bar'],
+    ['145.5', 'print <<-EOF\n\tfoo\n\t  bar\n\n\tEOF\n', 'foo\n  bar'],
+    ['145.6', 'print <<-EOF\n    foo\n  bar\n  EOF', '  foo\nbar'],
+    ['145.7', 'print <<EOF\nEOF\nprint 1', '\n1'],
+    ['145.8', 'print <<EOF\n"${x}" \\n `\nEOF\n', '"${x}" \\n `'],
+    ['145.9', 'def b `x` {y = <<E\n1\nE\n}; print b.x.y', '1'],
+    ['145.10', 'print <<EOF\na\nEOF\n + `b\n`', 'a\nb'],
+
+    ['146.1', 'print `a', '',                "err: unterminated raw string"],
+    ['146.2', 'print <<EOF\na\n', '',       "err: unterminated heredoc, expected EOF"],
+    ['146.3', 'print <<EOF a\nEOF', '',      "err: expected end of line after heredoc identifier"],
+    ['146.4', 'print << EOF\nEOF', '',       "err: expected heredoc identifier after `<<`"],
+    ['146.5', 'print <<E\n\nE\n+1+true', '', "err: line 4:8: ADD: invalid types: string, bool"],
+    ['146.6', 'print `\n\n`+1+true', '',    "err: line 3:9: ADD: invalid types: string, bool"],
+
+    ['147.1', 'print 30s',                       '30s'],
+    ['147.2', 'print 1h30m + 15s',               '1h30m15s'],
+    ['147.3', 'print 1.5h - 1m',                 '1h29m0s'],
+    ['147.4', 'print 100ms + 10us + 10µs + 1ns', '100.020001ms'],
+    ['147.5', 'print 2 * 5m; print 5m * 2; print 1h / 4; print 1h * 0.5', '10m0s\n10m0s\n15m0s\n30m0s'],
+    ['147.6', 'print -5s; print +5s',            '-5s\n5s'],
+    ['147.7', 'print 1s < 2s; print 1s > 2s; print 1s == 1000ms; print 1s == 1', 'true\nfalse\ntrue\nfalse'],
+    ['147.8', 'print "ttl=" + 30s; print "${1m}"', 'ttl=30s\n1m0s'],
+    ['147.9', 'print 0s or 5s',                  '5s'],
+    ['147.10', 'print 2024-01-02T15:04:05Z',     '2024-01-02T15:04:05Z'],
+    ['147.11', 'print 2024-01-02T15:04:05.25+02:00', '2024-01-02T15:04:05.25+02:00'],
+    ['147.12', 'var t = 2024-01-02T15:04:05Z; print t + 1h; print 1h + t; print t - 30m',
+        '2024-01-02T16:04:05Z\n2024-01-02T16:04:05Z\n2024-01-02T14:34:05Z'],
+    ['147.13', 'print 2024-01-02T00:00:00Z - 2024-01-01T00:00:00+02:00', '26h0m0s'],
+    ['147.14', 'print 2024-01-02T15:04:05Z == 2024-01-02T16:04:05+01:00', 'true'],
+    ['147.15', 'print 2024-01-02T15:04:05Z < 2024-01-02T15:04:06Z', 'true'],
+    ['147.16', 'print "at ${2024-01-02T15:04:05Z}"', 'at 2024-01-02T15:04:05Z'],
+    ['147.17', 'print 2024-1',                   '2023'],
+    ['147.18', 'print not 1s; print not 0s',     'false\ntrue'],
+    ['147.19', 'print 1h30m',
+        "== /dev/stdin ==\n"
+        "0000   1:12  CONST         0 '1h30m0s'\n"
+        "0002      |  PRINT\n"
+        "0003      |  RET\n"
+        "1h30m0s",
+        'disasm'
+    ],
+
+    ['148.1', 'print 5x', '',                    "err: invalid syntax `5x`"],
+    ['148.2', 'print 1e3s', '',                  "err: invalid syntax `1e3s`"],
+    ['148.3', 'print 2024-13-01T00:00:00Z', '',  "err: invalid time `2024-13-01T00:00:00Z`"],
+    ['148.4', 'print 1s + 1', '',                "err: ADD: invalid types: duration, int"],
+    ['148.5', 'print 1 - 1s', '',                "err: SUB: invalid types: int, duration"],
+    ['148.6', 'print 1s / 0', '',                "err: division by int zero"],
+    ['148.7', 'print 1s / 0.0', '',              "err: division by float zero"],
+    ['148.8', 'print 2024-01-02T15:04:05Z + 2024-01-02T15:04:05Z', '', "err: ADD: invalid types: time, time"],
+    ['148.9', 'print 1s < "x"', '',              "err: LT: invalid types: duration, string"],
+    ['148.10', 'print 30s + "x"', '',            "err: ADD: invalid types: duration, string"],
+
+    ['149.1', 'print 12.50d',                    '12.50'],
+    ['149.2', 'print 12.50d + 1; print 1 + 12.50d', '13.50\n13.50'],
+    ['149.3', 'print 0.1d + 0.2d; print 0.1d + 0.2d == 0.3d', '0.3\ntrue'],
+    ['149.4', 'print 1.10d * 1.10d; print 1.10d - 0.10d', '1.2100\n1.00'],
+    ['149.5', 'print 10.00d / 4; print 100d / 8; print 1d / 3', '2.50\n12.5\n0.3333333333333333'],
+    ['149.6', 'print -12.50d; print +1d',        '-12.50\n1'],
+    ['149.7', 'print 1.5d == 1.5; print 0.1d == 0.1; print 0.1d < 0.2; print 2d > 1', 'true\nfalse\ntrue\ntrue'],
+    ['149.8', 'print 1d == "1"; print 1d != nil', 'false\ntrue'],
+    ['149.9', 'print "total: " + 12.50d; print "${0.01d * 3}"', 'total: 12.50\n0.03'],
+    ['149.10', 'print 0.00d or 5d; print not 0d', '5\ntrue'],
+    ['149.11', 'var x = 0d; for i in range(10) { eval x = x + 0.1d }; print x', '1.0'],
+    ['149.12', 'print 12.50d',
+        "== /dev/stdin ==\n"
+        "0000   1:13  CONST         0 '12.50'\n"
+        "0002      |  PRINT\n"
+        "0003      |  RET\n"
+        "12.50",
+        'disasm'
+    ],
+
+    ['150.1', 'print 1dx', '',                   "err: invalid syntax `1dx`"],
+    ['150.2', 'print 1d + 1.5', '',              "err: ADD: invalid types: decimal, float"],
+    ['150.3', 'print 1.5 * 1d', '',              "err: MUL: invalid types: float, decimal"],
+    ['150.4', 'print 1.5d / 0', '',              "err: division by int zero"],
+    ['150.5', 'print 1.5d / 0.00d', '',          "err: division by decimal zero"],
+    ['150.6', 'print 1d + "x"', '',              "err: ADD: invalid types: decimal, string"],
+    ['150.7', 'print 1d < "x"', '',              "err: LT: invalid types: decimal, string"],
+    ['150.8', 'print 1s * 2d', '',               "err: MUL: invalid types: duration, decimal"],
+
+    ['151.1', 'print "8080" @int + 1',           '8081'],
+    ['151.2', 'print 1 + "x" @int', '',          'err: @int: invalid syntax: "x"'],
+    ['151.3', 'print 42 @str + "!"; print (1 + 2) @str + "!"', '42!\n3!'],
+    ['151.4', 'print 3.9 @int; print -3.9 @int; print (-3.9) @int', '3\n-3\n-3'],
+    ['151.5', 'print 12.75d @int; print -12.75d @int', '12\n-12'],
+    ['151.6', 'print "010" @int; print "-07" @int; print " 42 " @int', '10\n-7\n42'],
+    ['151.7', 'print true @int; print false @float; print 1s @int', '1\n0\n1'],
+    ['151.8', 'print "1.5" @float * 2; print 1 @float / 2; print 12.25d @float', '3\n0.5\n12.25'],
+    ['151.9', 'print 0.1 @decimal + 0.2d; print "12.50" @decimal; print 3 @decimal / 4', '0.3\n12.50\n0.75'],
+    ['151.10', 'print 1 @bool; print 0 @bool; print nil @bool; print 0.0 @bool', 'true\nfalse\nfalse\nfalse'],
+    ['151.11', 'print "true" @bool; print "0" @bool; print "" @bool', 'true\nfalse\nfalse'],
+    ['151.12', 'print nil @str + "x"; print 1.5 @str; print 1s @str', 'x\n1.5\n1s'],
+    ['151.13', 'print "90s" @duration; print 1000 @duration', '1m30s\n16m40s'],
+    ['151.13.1', 'print 5 @duration; print 1500ms @int; print -1500ms @int', '5s\n1\n-1'],
+    ['151.14', 'print "2024-01-02T15:04:05Z" @time + 1h', '2024-01-02T16:04:05Z'],
+    ['151.15', 'print 2024-01-02T15:04:05Z @str + "!"', '2024-01-02T15:04:05Z!'],
+    ['151.16', 'print "42" @int',
+        "== /dev/stdin ==\n"
+        "0000   1:11  CONST         0 '42'\n"
+        "0002   1:16  CONV          1 @int\n"
+        "0004      |  PRINT\n"
+        "0005      |  RET\n"
+        "42",
+        'disasm'
+    ],
+    ['151.17', 'print 30s @float; print 1500ms @float; print 1.5 @duration; print -0.25 @duration',
+        '30\n1.5\n1.5s\n-250ms'],
+
+    ['152.1', 'print "abc" @int', '',            'err: line 1:17: @int: invalid syntax: "abc"'],
+    ['152.2', 'print "1.5" @int', '',            'err: @int: invalid syntax: "1.5"'],
+    ['152.3', 'print 1e300 @int', '',            'err: @int: out of range: 1e+300'],
+    ['152.4', 'print nil @int', '',              'err: @int: invalid type: nil'],
+    ['152.5', 'print "x" @float', '',            'err: @float: invalid syntax: "x"'],
+    ['152.6', 'print 1e300 @duration', '',       'err: @duration: out of range: 1e+300'],
+    ['152.6.1', 'print (1<<40) @duration', '',   'err: @duration: out of range: 1099511627776'],
+    ['152.7', 'print "x" @bool', '',             'err: @bool: invalid syntax: "x"'],
+    ['152.8', 'print "1e5" @decimal', '',        'err: @decimal: invalid syntax: "1e5"'],
+    ['152.9', 'print true @decimal', '',         'err: @decimal: invalid type: bool'],
+    ['152.10', 'print "5 min" @duration', '',    'err: @duration: invalid syntax: "5 min"'],
+    ['152.11', 'print "1_000" @int', '',         'err: @int: invalid syntax: "1_000"'],
+    ['152.12', 'print "2024-01-02" @time', '',   'err: @time: invalid syntax: "2024-01-02"'],
+    ['152.13', 'print 1 @time', '',              'err: @time: invalid type: int'],
+    ['152.14', 'print 1 @foo', '',               "err: at 'foo': unknown type for the conversion"],
+    ['152.15', 'print 1 @', '',                  "err: at end: expected type name after '@'"],
+    ['152.16', 'print 1 @1', '',                 "err: at '1': expected type name after '@'"],
+    ['152.17', 'print "0x10" @int', '',          'err: @int: invalid syntax: "0x10"'],
+    ['152.18', 'print "NaN" @float @duration', '', 'err: @duration: out of range: NaN'],
+
+    ['153.1', 'print 7 % 3; print -7 % 3; print 7 % -3', '1\n2\n-2'],
+    ['153.2', 'print 7 // 2; print -7 // 2; print 7 // -2', '3\n-4\n-4'],
+    ['153.3', 'print 7.5 // 2; print 7.5 % 2; print -7.5 % 2; print 7 % 2.5', '3\n1.5\n0.5\n2'],
+    ['153.4', 'print 2 ** 10; print 2 ** -1; print 2.0 ** 0.5 > 1.41', '1024\n0.5\ntrue'],
+    ['153.5', 'print -2 ** 2; print (-2) ** 2; print 2 ** 3 ** 2; print 2 ** -2 ** 2', '-4\n4\n512\n0.0625'],
+    ['153.6', 'print 1 << 20; print 1024 >> 3; print -16 >> 2', '1048576\n128\n-4'],
+    ['153.7', 'print 12 & 10; print 12 | 3; print 12 ^ 10', '8\n15\n6'],
+    ['153.8', 'print 1 + 2 << 3; print 1 | 2 == 3; print 6 & 3 + 1; print 1 | 6 ^ 3 & 5', '24\ntrue\n4\n7'],
+    ['153.9', 'print 10 % 4 * 3; print 2 * 3 ** 2; print 17 // 5 % 2', '6\n18\n1'],
+    ['153.10', 'var x = 5; print x<<2; print x <<1', '20\n10'],
+    ['153.11', 'print 7.5d % 2; print -7.5d // 2; print 1.1d ** 3; print 2d ** -2', '1.5\n-4\n1.331\n0.25'],
+    ['153.12', 'var shard = 12345 % 16; print shard', '9'],
+    ['153.13', 'print 2 ** 3 % 3',
+        "== /dev/stdin ==\n"
+        "0000    1:8  CONST         0 '2'\n"
+        "0002   1:13  CONST         1 '3'\n"
+        "0004      |  POW\n"
+        "0005   1:17  CONST         2 '3'\n"
+        "0007      |  MOD\n"
+        "0008      |  PRINT\n"
+        "0009      |  RET\n"
+        "2",
+        'disasm'
+    ],
+
+    ['154.1', 'print 1 % 0', '',                 "err: line 1:12: modulo by int zero"],
+    ['154.2', 'print 1 // 0', '',                "err: division by int zero"],
+    ['154.3', 'print 1d % 0.0d', '',             "err: modulo by decimal zero"],
+    ['154.4', 'print 0d ** -1', '',              "err: division by decimal zero"],
+    ['154.5', 'print 1 << -1', '',               "err: negative shift count: -1"],
+    ['154.6', 'print 1.5 & 1', '',               "err: BAND: invalid types: float, int"],
+    ['154.7', 'print 1 | true', '',              "err: BOR: invalid types: int, bool"],
+    ['154.8', 'print "a" % 1', '',               "err: MOD: invalid types: string, int"],
+    ['154.9', 'print 2d ** 1.5', '',             "err: POW: invalid types: decimal, float"],
+    ['154.10', 'print 1 ^ 1d', '',               "err: BXOR: invalid types: int, decimal"],
+    ['154.11', 'print 1 ** ', '',                "err: at end: expected expression"],
+
+    ['155.1', 'print upper("abc") + lower("DEF")', 'ABCdef'],
+    ['155.2', 'print trim(" a b \\t") + trim("--c--", "-")', 'a bc'],
+    ['155.3', 'print split("a,b,,c", ",")', '["a", "b", "", "c"]'],
+    ['155.4', 'print split("", ",")', '[]'],
+    ['155.5', 'print join(split("a b c", " "), ", ")', 'a, b, c'],
+    ['155.6', 'print replace("a.b.c", ".", "::")', 'a::b::c'],
+    ['155.7', 'print contains("hello", "ell") and has_prefix("hello", "he") and '
+              'has_suffix("hello", "lo")', 'true'],
+    ['155.8', 'print format("%s:%d", "host", 8080)', 'host:8080'],
+    ['155.9', 'print format("%v %s %d", 1.5d, 5m, "x")', '1.5 5m0s %!d(string=x)'],
+    ['155.10', 'print len("żółw") + len(split("a b", " ")) * 10', '24'],
+    ['155.11', 'print substr("hello", 1, 3) + "|" + substr("hello", -3) + "|" + '
+               'substr("hello", 3, 1) + "|" + substr("hello", 2, 99)', 'el|llo||llo'],
+    ['155.12', r'print regex_match("v1.2.3", `^v\d+\.\d+\.\d+$`)', 'true'],
+    ['155.12.1', r'for s in ["a1", "b", "a22"] {print regex_match(s, `^a\d+$`)}', 'true\nfalse\ntrue'],
+    ['155.13', r'print regex_replace("2024-01-02", `(\d+)-(\d+)-(\d+)`, "$3/$2/$1")',
+               '02/01/2024'],
+    ['155.14', 'print upper(nil) + "|" + join(nil, ",") + "|" + len(nil)', '||0'],
+    ['155.15', 'print split("a,b", ",") == split("a,b", ",")', 'true'],
+    ['155.16', 'print split("a,b", ",") == "a,b"', 'false'],
+    ['155.17', 'print "${split("a,b", ",")}"', '["a", "b"]'],
+    ['155.18', 'def b { upper = 1; lower = upper + 1 }; print b.lower', '2'],
+    ['155.19', 'eval upper("a")',
+        "== /dev/stdin ==\n"
+        "0000   1:15  CONST         0 'a'\n"
+        "0002   1:16  CALL          1 'upper'\t   1#\n"
+        "0005      |  POP\n"
+        "0006      |  RET",
+        'disasm'
+    ],
+    ['155.20', 'print not split("", ",")', 'true'],
+
+    ['156.1', 'print foo(1)', '',                "err: line 1:10: error at 'foo': unknown function 'foo'"],
+    ['156.2', 'print upper()', '',               "err: upper() expects 1 argument, got 0"],
+    ['156.3', 'print substr("a", 1, 2, 3)', '',  "err: substr() expects 2 to 3 arguments, got 4"],
+    ['156.4', 'print format()', '',              "err: format() expects at least 1 argument, got 0"],
+    ['156.5', 'print upper(1)', '',              "err: line 1:15: upper: argument 1: invalid type: int, expected string"],
+    ['156.6', 'print substr("a", "b")', '',      "err: substr: argument 2: invalid type: string, expected int"],
+    ['156.7', 'print join("a", ",")', '',        "err: join: argument 1: invalid type: string, expected list"],
+    ['156.8', 'print len(1)', '',                "err: len: argument 1: invalid type: int, expected string, list or map"],
+    ['156.9', 'print regex_match("a", "(")', '', "err: regex_match: error parsing regexp: missing closing ): `(`"],
+    ['156.9.1', 'print regex_match("a", "a"); print regex_replace("a", "(", "")', '', "err: regex_replace: error parsing regexp: missing closing ): `(`"],
+    ['156.10', 'print upper("a"', '',            "err: at end: expected ')' after arguments"],
+
+    ['157.1', 'print [1, "a", nil, [2.5], {}]', '[1, "a", nil, [2.5], {}]'],
+    ['157.2', 'print {b: 1, "a-1": [1], c: {d: nil},}', '{"a-1": [1], "b": 1, "c": {"d": nil}}'],
+    ['157.3', 'var x = [\n  1,\n  2,\n]\nprint x', '[1, 2]'],
+    ['157.4', 'print [1, 2] == [1.0, 2d] and {a: 1} == {a: 1.0} and [] != {}', 'true'],
+    ['157.5', 'print [1, [2]] == [1, [3]] or {a: 1} == {b: 1}', 'false'],
+    ['157.6', 'print "${[1]}${{a: "x"}}"', '[1]{"a": "x"}'],
+    ['157.7', 'print not [] and not {}', 'true'],
+    ['157.8', 'eval [1, {a: 2}]',
+        "== /dev/stdin ==\n"
+        "0000    1:8  ONE\n"
+        "0001   1:12  CONST         0 'a'\n"
+        "0003   1:15  CONST         1 '2'\n"
+        "0005   1:16  MAP           1\n"
+        "0007   1:17  LIST          2\n"
+        "0009      |  POP\n"
+        "0010      |  RET",
+        'disasm'
+    ],
+    ['157.9', 'print "${min(3, 1.5, 2)} ${max([1, 2d, 1.5])}"', '1.5 2'],
+    ['157.10', 'print "${min(5)}${max(["a", "b"])}"', '5b'],
+    ['157.11', 'print "${max(1m, 90s)}|${min(2024-01-02T00:00:00Z, 2023-01-02T00:00:00Z)}"',
+               '1m30s|2023-01-02T00:00:00Z'],
+    ['157.12', 'print abs(-3) + abs(-1.5) + abs(2)', '6.5'],
+    ['157.13', 'print "${abs(-1.25d)} ${abs(-5s)}"', '1.25 5s'],
+    ['157.14', 'print floor(-1.5) + ceil(1.2) * 10 + round(2.5) * 100', '318'],
+    ['157.15', 'print round(-2.5d) + floor(1.99d) * 10 + ceil(-0.5d) * 100 + round(7)', '14'],
+    ['157.16', 'print clamp(15, 1, 10) + clamp(-5, 1, 10) * 100 + clamp(0.5, 0, 1) * 1000', '610'],
+    ['157.17', 'print keys({b: 1}) + values({b: 1})', '',
+               'err: ADD: invalid types: list, list'],
+    ['157.18', 'print "${keys({b: 1, a: 2})} ${values({b: 1, a: 2})} ${keys(nil)}"',
+               '["a", "b"] [2, 1] []'],
+    ['157.19', 'print sort([3, 1.5, 2, 1d])', '[1, 1.5, 2, 3]'],
+    ['157.20', 'print sort(split("c,a,b", ","))', '["a", "b", "c"]'],
+    ['157.21', 'print unique([1, 1.0, 2, "a", "a", nil, nil])', '[1, 2, "a", nil]'],
+    ['157.22', 'print sum([1, 2.5, 3]) + sum([])', '6.5'],
+    ['157.23', 'print sum([1m, 30s])', '1m30s'],
+    ['157.24', 'print "${range(3)} ${range(1, 10, 3)} ${range(5, 0, -2)} ${range(3, 1)}"',
+               '[0, 1, 2] [1, 4, 7] [5, 3, 1] []'],
+    ['157.25', 'print contains([1, 2], 2.0) and contains({a: 1}, "a") and '
+               'not contains({a: 1}, "b") and contains("abc", "b")', 'true'],
+    ['157.26', 'print len({a: 1, b: 2}) + len([1])', '3'],
+    ['157.27', 'def limits { cpu = 2; replicas = max(2, ceil(450 / 100.0)) }; print limits.replicas', '5'],
+    ['157.28', 'var m = {"a": 1}; print m.a', '1'],
+    ['157.29', 'var m = {a: {"b-c": [1, 2]}}; print m.a."b-c"; print get(m.a."b-c", 1)', '[1, 2]\n2'],
+    ['157.30', 'var m = {a: 1}; print m?.b; print m?.b.c; print get(m, "b", 0) + get([], 5, 2)', '<nil>\n<nil>\n2'],
+    ['157.31', 'def t {x = 1}; def u {var t = {x: 2}; print t.x}', '2'],
+
+    ['158.1', 'print [1, 2', '',                 "err: at end: expected ']' after list elements"],
+    ['158.2', 'print {a: 1, a: 2}', '',          "err: line 1:15: error at 'a': duplicate map key"],
+    ['158.3', 'print {1: 2}', '',                "err: error at '1': expected map key"],
+    ['158.4', 'print {a 1}', '',                 "err: expected ':' after map key"],
+    ['158.5', 'print min([])', '',               "err: min: empty list"],
+    ['158.6', 'print max(1, "a")', '',           "err: max: cannot compare string and int"],
+    ['158.7', 'print sort([1, "a"])', '',        "err: sort: cannot compare string and int"],
+    ['158.8', 'print clamp(1, 10, 1)', '',       "err: clamp: empty range: 10 > 1"],
+    ['158.9', 'print sum([1, "a"])', '',         "err: sum: element 1: invalid type: string"],
+    ['158.10', 'print range(1, 2, 0)', '',       "err: range: zero step"],
+    ['158.11', 'print range(1 << 30)', '',       "err: range: too many elements: 1073741824"],
+    ['158.12', 'print floor(1e300)', '',         "err: floor: out of range: 1e+300"],
+    ['158.13', 'print abs("a")', '',             "err: abs: argument 1: invalid type: string, expected number or duration"],
+    ['158.14', 'print keys([1])', '',            "err: keys: argument 1: invalid type: list, expected map"],
+    ['158.15', 'print contains(1, 1)', '',       "err: contains: argument 1: invalid type: int, expected string, list or map"],
+    ['158.16', 'var m = {a: 1}; print m.b', '',   'err: get: no key "b"'],
+    ['158.17', 'print get([1], -1)', '',          "err: get: index out of range: -1"],
+    ['158.18', 'var s = "x"; print s.a', '',      "err: get: argument 1: invalid type: string, expected map or list"],
+    ['158.19', 'var m = {}; print m.1', '',       "err: expected key after '.'"],
+
+    ['159.1', 'var port = 0; print port ?? 8080', '0'],
+    ['159.2', 'var port = 0; print port or 8080', '8080'],
+    ['159.3', 'print nil ?? nil ?? "" ?? 3', ''],
+    ['159.4', 'print 1 ?? 2 + 3', '1'],
+    ['159.5', 'print nil ?? 2 + 3', '5'],
+    ['159.6', 'print nil or nil ?? false', 'false'],
+    ['159.7', 'def b { x = field?.y ?? 5; y = 1; z = field?.y ?? 5 }; print b.x + b.z', '6'],
+    ['159.8', 'def b { prefix = "" }; def c { p = b?.prefix ?? "default" }; print "[${c.p}]"', '[]'],
+    ['159.9', 'def t "prod" { host = "h" }; print t?.prod.host + (t?.dev.host ?? "-")', 'h-'],
+    ['159.10', 'print nope?.x ?? "none"', 'none'],
+    ['159.11', 'eval nil ?? 1',
+        "== /dev/stdin ==\n"
+        "0000    1:9  NIL\n"
+        "0001   1:12  JNOTNIL       2 -> 0006\n"
+        "0004      |  POP\n"
+        "0005   1:14  ONE\n"
+        "0006      |  POP\n"
+        "0007      |  RET",
+        'disasm'
+    ],
+    ['159.12', 'def b { eval field?.x; eval t?.u }',
+        "== /dev/stdin ==\n"
+        "0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n"
+        "0003   1:22  TRYFIELD      2 'x'\n"
+        "0005      |  POP\n"
+        "0006   1:33  TRYPATH       2#\t   3 't'\t   4 'u'\n"
+        "0010      |  POP\n"
+        "0011   1:35  ENDBLOCK\n"
+        "0012      |  RET",
+        'disasm'
+    ],
+
+    ['160.1', 'def b { field?.x = 1 }', '',      "err: line 1:19: error at '=': invalid assignment target"],
+    ['160.2', 'print 1 ? 2', '',                 """err: expected char '?' to start token "??\""""],
+    ['160.3', 'def t "p" { def o {} }; print t?.p.o', '', "err: path 't.p.o' refers to a block, expected field"],
+    ['160.4', 'print field?.x', '',              "err: field reference outside of a block"],
+    ['160.5', 'def b { print y }', '',           "err: identifier 'y' not resolved as var or field"],
+
+    ['161.1', 'const domain = "acme.com"; def b { host = "www." + domain }; print b.host', 'www.acme.com'],
+    ['161.2', 'const a = 1; const b = a; var c = b + 1; print c', '2'],
+    ['161.3', 'const n = 2 * 3; def b { x = n }; print b.x', '6'],
+    ['161.4', 'const a = 1; def b { var a = 2; a = 3; print a }', '3'],
+    ['161.5', 'const a = 1; def b { field.a = 2; print field.a + a }', '3'],
+    ['161.6', 'const d = "x"\ndef b { h = d }',
+        "== /dev/stdin ==\n"
+        "0000   1:14  CONST         0 'x'\n"
+        "0002    2:8  DEFBLOCK      1 'b'\t   2 ''\n"
+        "0005   2:14  CONST         0 'x'\n"
+        "0007      |  SETFIELD      3 'h'\n"
+        "0009      |  POP\n"
+        "0010   2:16  ENDBLOCK\n"
+        "0011      |  POP\n"
+        "0012      |  RET",
+        'disasm'
+    ],
+    ['161.7', 'const n = 1 + 1; eval n',
+        "== /dev/stdin ==\n"
+        "0000   1:12  ONE\n"
+        "0001   1:16  ONE\n"
+        "0002      |  ADD\n"
+        "0003   1:24  GETLOCAL      0\n"
+        "0005      |  POP\n"
+        "0006      |  POP\n"
+        "0007      |  RET",
+        'disasm'
+    ],
+
+    ['162.1', 'const a = 1; eval a = 2', '',      "err: line 1:20: error at 'a': cannot assign to a constant"],
+    ['162.2', 'const a = 1; def b { a = 2 }', '', "err: error at 'a': cannot assign to a constant"],
+    ['162.3', 'const a = 1; def b { var.a = 2 }', '', "err: error at 'a': cannot assign to a constant"],
+    ['162.4', 'const a = 1; print a = 2', '',     "err: error at 'a': cannot assign to a constant"],
+    ['162.5', 'const a = 1; var a = 2', '',       "err: variable with this name already present in this scope"],
+    ['162.6', 'const a', '',                      "err: at end: expected '=' after constant name"],
+    ['162.7', 'const 1 = 1', '',                  "err: error at '1': expected constant name"],
+
+    ['163.1', 'print 1 + 2 * 3',
+        "== /dev/stdin ==\n"
+        "0000   1:16  CONST         0 '7'\n"
+        "0002      |  PRINT\n"
+        "0003      |  RET\n"
+        "7",
+        'disasm', 'O2'
+    ],
+    ['163.2', 'print "a" + "b" * 2',
+        "== /dev/stdin ==\n"
+        "0000   1:20  CONST         0 'abb'\n"
+        "0002      |  PRINT\n"
+        "0003      |  RET\n"
+        "abb",
+        'disasm', 'O2'
+    ],
+    ['163.3', 'var a = 1; print a != 2; print a <= 2; print a >= 2',
+        "== /dev/stdin ==\n"
+        "0000   1:10  ONE\n"
+        "0001   1:19  GETLOCAL      0\n"
+        "0003   1:24  CONST         0 '2'\n"
+        "0005      |  NE\n"
+        "0006      |  PRINT\n"
+        "0007   1:33  GETLOCAL      0\n"
+        "0009   1:38  CONST         1 '2'\n"
+        "0011      |  LE\n"
+        "0012      |  PRINT\n"
+        "0013   1:47  GETLOCAL      0\n"
+        "0015   1:52  CONST         2 '2'\n"
+        "0017      |  GE\n"
+        "0018      |  PRINT\n"
+        "0019      |  POP\n"
+        "0020      |  RET\n"
+        "true\n"
+        "true\n"
+        "false",
+        'disasm', 'O1'
+    ],
+    ['163.4', 'print if true then "y" else "n"',
+        "== /dev/stdin ==\n"
+        "0000   1:23  CONST         0 'y'\n"
+        "0002   1:32  PRINT\n"
+        "0003      |  RET\n"
+        "y",
+        'disasm', 'O2'
+    ],
+    ['163.5', 'print nil ?? "d"',
+        "== /dev/stdin ==\n"
+        "0000   1:17  CONST         0 'd'\n"
+        "0002      |  PRINT\n"
+        "0003      |  RET\n"
+        "d",
+        'disasm', 'O2'
+    ],
+    ['163.6', 'print "v${1 + 1}"',
+        "== /dev/stdin ==\n"
+        "0000   1:16  CONST         0 'v2'\n"
+        "0002   1:18  PRINT\n"
+        "0003      |  RET\n"
+        "v2",
+        'disasm', 'O2'
+    ],
+    ['163.7', 'var a = 1; print a and 2 + 2',
+        "== /dev/stdin ==\n"
+        "0000   1:10  ONE\n"
+        "0001   1:19  GETLOCAL      0\n"
+        "0003   1:23  JFALSE        3 -> 0009\n"
+        "0006      |  POP\n"
+        "0007   1:29  CONST         0 '4'\n"
+        "0009      |  PRINT\n"
+        "0010      |  POP\n"
+        "0011      |  RET\n"
+        "4",
+        'disasm', 'O2'
+    ],
+    ['163.8', 'print len("x" * 2000)',    '2000', 'O2'],
+    ['163.9', 'print false and 1 / 0',    'false', 'O2'],
+    ['163.10', 'print 1.50d + 0d; print 1.5d + 0d; print 1.5d', '1.50\n1.5\n1.5', 'O2'],
+    ['163.11', 'print 0.0 * -1; print 0.0', '-0\n0', 'O2'],
+    ['163.10', 'var a = 2; print not a > 1; print not a < 1', 'false\ntrue', 'O1'],
+
+    ['164.1', 'print 1 / 0', '', 'O2', "err: line 1:12: division by int zero"],
+    ['164.2', 'print "a" - 1', '', 'O2', "err: line 1:14: SUB: invalid types: string, int"],
+
+    ['165.1', 'if true { const a = 1 }; var b = 2; eval b = 3; print b', '3'],
+    ['165.2', 'def a { ' + 'def b { '*19 + 'x = 1 ' + '}'*19 + ' }; print a.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.x', '1'],
+    ['165.3', 'print "x" * -1', '',               "err: line 1:15: negative repeat count: -1"],
+    ['165.4', 'print "ab" * 9223372036854775807', '', "err: string too long, max 67108864 bytes"],
+    ['165.5', 'print 10d ** 100000', '',          "err: line 1:20: decimal exponent out of range: 100000"],
+    ['165.6', 'print 9223372036854775808', '',    "err: line 1:26: error at '9223372036854775808': invalid int literal: value out of range"],
+    ['165.7', 'print 1e400', '',                  "err: error at '1e400': invalid float literal: value out of range"],
+    ['165.8', 'var s = "x" * (1 << 25); print join([s, s, s], "")', '', "err: join: string too long, max 67108864 bytes"],
+    ['165.9', 'var s = "x" * (1 << 25); print replace(s, "x", "xyz")', '', "err: replace: string too long, max 67108864 bytes"],
+    ['165.10', 'print format("%999999d" * 100, 1)', '', "err: format: string too long, max 67108864 bytes"],
+    ['165.11', 'var s = "x" * (1 << 20); print len(join([s, s], "-") + replace(s, "x", "yy") + format("%s%9d", s, 1))',
+               f'{(1<<21) + 1 + (1<<21) + (1<<20) + 9}'],
 ]
 
 tests_64b = [
@@ -652,6 +1339,10 @@ def err_match(opt):
         return m[4:].strip() or 'err'
 
 
+def optimize_level(opt):
+    return next((int(s[1:]) for s in opt if s in ('O1', 'O2')), 0)
+
+
 def perr(*args):
     print(*args, file=stderr)
 
@@ -665,6 +1356,7 @@ def run_tests():
     for i, prog, exp, *opt in tests + tests_extra:
         cmd2 = cmd.copy()
         if 'disasm' in opt: cmd2.append('--disasm')
+        if o := optimize_level(opt): cmd2.append(f'-O{o}')
 
         proc = subprocess.run(cmd2, input=prog, text=True, capture_output=True)
 
@@ -702,16 +1394,23 @@ def removesuffix(s, suffix):
 def q(s): return s.encode('unicode-escape').decode()
 
 
-def qbt(s):
-    """Quote backticks as Go fmt.Sprintf expression."""
-    s = q(s)
+def qbt(s): return raw(q(s))
+
+
+def raw(s):
+    """Quote as Go raw string, backticks via fmt.Sprintf expression."""
     if '`' in s:
-        s = s.replace('`', '%[1]c')
+        s = s.replace('%', '%%').replace('`', '%[1]c')
         return f"fmt.Sprintf(`{s}`, '`')"
     else:
         return f"`{s}`"
 
 
+def dq(s):
+    """Quote as Go interpreted string, keeping non-ASCII chars as they are."""
+    return '"' + ''.join(c if ord(c) > 127 else q(c).replace('"', '\\"') for c in s) + '"'
+
+
 part1 = r"""// Code generated by "./test.py generate"; DO NOT EDIT.
 
 package bcl_test
@@ -736,6 +1435,7 @@ func TestInterpretFromPy(t *testing.T) {
     var tab = []struct {
         name, input, output string
         disasm              bool
+        optimize            int
         errWanted           bool
         errMatch            string
     }{"""
@@ -744,42 +1444,55 @@ part2 = r""" }
 
     for _, tc := range tab {
         tc := tc
-        t.Run(tc.name, func(t *testing.T) {
-            inp := stdinBuf{strings.NewReader(tc.input)}
-            out := new(bytes.Buffer)
-            log := new(bytes.Buffer)
-
-            _, _, err := bcl.InterpretFile(
-                inp,
-                bcl.OptDisasm(tc.disasm),
-                bcl.OptOutput(out), bcl.OptLogger(log),
-            )
-
-            switch {
-            case err != nil && !tc.errWanted:
-                t.Errorf("unexpected error: %s", relevantError(err, log))
-
-            case err != nil && tc.errWanted:
-                rerr := relevantError(err, log)
-                if !strings.Contains(rerr, tc.errMatch) {
-                    t.Errorf("error mismatch\nhave: %s\nwant matching: %s",
-                        rerr, tc.errMatch,
-                    )
-                }
-
-            case err == nil && tc.errWanted && tc.errMatch == "":
-                t.Errorf("no error when expecting one")
-
-            case err == nil && tc.errWanted && tc.errMatch != "":
-                t.Errorf("no error when expecting one matching: %s", tc.errMatch)
-
-            case err == nil && !tc.errWanted:
-                s := strings.TrimRight(out.String(), "\n")
-                if s != tc.output {
-                    t.Errorf("mismatch:\nhave: %s\nwant: %s", s, tc.output)
-                }
+        levels := []int{tc.optimize}
+        if !tc.disasm && tc.optimize == 0 {
+            // the optimized code should behave the same
+            levels = append(levels, 2)
+        }
+
+        for _, level := range levels {
+            name := tc.name
+            if level != tc.optimize {
+                name += fmt.Sprintf("-O%d", level)
             }
-        })
+            level := level
+            t.Run(name, func(t *testing.T) {
+                inp := stdinBuf{strings.NewReader(tc.input)}
+                out := new(bytes.Buffer)
+                log := new(bytes.Buffer)
+
+                _, _, err := bcl.InterpretFile(
+                    inp,
+                    bcl.OptDisasm(tc.disasm), bcl.OptOptimize(level),
+                    bcl.OptOutput(out), bcl.OptLogger(log),
+                )
+
+                switch {
+                case err != nil && !tc.errWanted:
+                    t.Errorf("unexpected error: %s", relevantError(err, log))
+
+                case err != nil && tc.errWanted:
+                    rerr := relevantError(err, log)
+                    if !strings.Contains(rerr, tc.errMatch) {
+                        t.Errorf("error mismatch\nhave: %s\nwant matching: %s",
+                            rerr, tc.errMatch,
+                        )
+                    }
+
+                case err == nil && tc.errWanted && tc.errMatch == "":
+                    t.Errorf("no error when expecting one")
+
+                case err == nil && tc.errWanted && tc.errMatch != "":
+                    t.Errorf("no error when expecting one matching: %s", tc.errMatch)
+
+                case err == nil && !tc.errWanted:
+                    s := strings.TrimRight(out.String(), "\n")
+                    if s != tc.output {
+                        t.Errorf("mismatch:\nhave: %s\nwant: %s", s, tc.output)
+                    }
+                }
+            })
+        }
     }
 }
 
@@ -800,8 +1513,9 @@ def generate(target):
         print(part1, file=f)
 
         for (i, inp, outp, *opt) in tests + tests_extra:
-            print(f'\t\t{{`{i}`, `{inp}`, "{q(outp)}", ', file=f, end='')
+            print(f'\t\t{{`{i}`, {raw(inp)}, {dq(outp)}, ', file=f, end='')
             print('true, ' if 'disasm' in opt else 'false, ', file=f, end='')
+            print(f'{optimize_level(opt)}, ', file=f, end='')
             if m := err_match(opt):
                 print('true, ', file=f, end='')
                 msg = '""' if m == 'err' else qbt(m)
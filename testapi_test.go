@@ -22,473 +22,914 @@ func TestInterpretFromPy(t *testing.T) {
 	var tab = []struct {
 		name, input, output string
 		disasm              bool
+		optimize            int
 		errWanted           bool
 		errMatch            string
 	}{
-		{`0`, ``, "", false, false, ""},
-		{`0.1`, `eval "expr that is discarded"`, "", false, false, ""},
-		{`0.2`, `eval nil`, "", false, false, ""},
-		{`0.3`, `eval "anything"`, "", false, false, ""},
-		{`1`, `var a; print not a`, "true", false, false, ""},
-		{`2`, `var a; print a==nil`, "true", false, false, ""},
-		{`3`, `var a=1; eval a=nil; print a==nil`, "true", false, false, ""},
-		{`4`, `print 1+1`, "2", false, false, ""},
-		{`5`, `print 1+2.14`, "3.14", false, false, ""},
-		{`6`, `print 123/2-50+2*8`, "27", false, false, ""},
-		{`6.1`, `print 123.0/2-50+2*8`, "27.5", false, false, ""},
-		{`6.2`, `print 123/2.0-50+2*8`, "27.5", false, false, ""},
-		{`6.3`, `print 123.0/2.0-50+2*8`, "27.5", false, false, ""},
-		{`6.4`, `print 123/2-50+2.0*8`, "27", false, false, ""},
-		{`6.5`, `print 123/2-50+2*8.0`, "27", false, false, ""},
-		{`6.6`, `print 123/2-50+2.0*8.0`, "27", false, false, ""},
-		{`6.7`, `print 123/2-50.0+2*8`, "27", false, false, ""},
-		{`7`, `print 1-2`, "-1", false, false, ""},
-		{`8`, `print 1--2`, "3", false, false, ""},
-		{`9`, `print 1- +1`, "0", false, false, ""},
-		{`10`, `print 1+ +1`, "2", false, false, ""},
-		{`10.1`, `print 1.0+ +1`, "2", false, false, ""},
-		{`10.2`, `print 1+ +1.0`, "2", false, false, ""},
-		{`10.3`, `print 1.0+ +1.0`, "2", false, false, ""},
-		{`11`, `print ---10`, "-10", false, false, ""},
-		{`11.1`, `print ---10.0`, "-10", false, false, ""},
-		{`12`, `print 1==1`, "true", false, false, ""},
-		{`12.1`, `print 1.0==1`, "true", false, false, ""},
-		{`12.2`, `print 1==1.0`, "true", false, false, ""},
-		{`12.3`, `print 1.0==1.0`, "true", false, false, ""},
-		{`13`, `print not 1>3`, "true", false, false, ""},
-		{`13.1`, `print not 1.0>3`, "true", false, false, ""},
-		{`13.2`, `print not 1>3.0`, "true", false, false, ""},
-		{`13.3`, `print not 1.0>3.0`, "true", false, false, ""},
-		{`13.4`, `print not 3<=1`, "true", false, false, ""},
-		{`14`, `print 1<2 and 0 or "whatever"`, "whatever", false, false, ""},
-		{`14.1`, `print 1.0<2 and 0 or "whatever"`, "whatever", false, false, ""},
-		{`14.2`, `print 1<2.0 and 0 or "whatever"`, "whatever", false, false, ""},
-		{`14.3`, `print 1.0<2.0 and 0 or "whatever"`, "whatever", false, false, ""},
-		{`14.4`, `print 1<2 and 0.0 or "whatever"`, "whatever", false, false, ""},
-		{`14.5`, `print 1.0<2 and 0.0 or "whatever"`, "whatever", false, false, ""},
-		{`14.6`, `print 1<2.0 and 0.0 or "whatever"`, "whatever", false, false, ""},
-		{`14.7`, `print 1.0<2.0 and 0.0 or "whatever"`, "whatever", false, false, ""},
-		{`15`, `print 1>2 or true and 42`, "42", false, false, ""},
-		{`16`, `print 1<10/5 and 127*-1+154`, "27", false, false, ""},
-		{`17`, `print "q"*2`, "qq", false, false, ""},
-		{`18`, `print "q"+"x"`, "qx", false, false, ""},
-		{`19`, `print "q"+3`, "q3", false, false, ""},
-		{`20`, `print "q"+3.14`, "q3.14", false, false, ""},
-		{`21`, `print "q"=="q"`, "true", false, false, ""},
-		{`22`, `print "q"!="p"`, "true", false, false, ""},
-		{`23`, `print "p"<"q"`, "true", false, false, ""},
-		{`24`, `print "q">"p"`, "true", false, false, ""},
-		{`25`, `print not (false or true)`, "false", false, false, ""},
-		{`25.1`, `print not (false and true)`, "true", false, false, ""},
-		{`25.2`, `print "" or 42`, "42", false, false, ""},
-		{`26`, `var a=100; var b=a-90; print -b`, "-10", false, false, ""},
-		{`27`, `var a=1; var b=2; print a+b`, "3", false, false, ""},
-		{`28`, `var a=1; eval a=a+1; print a`, "2", false, false, ""},
-		{`29`, `var a=1; print a=a+1`, "2", false, false, ""},
-		{`30`, `def blk {print TYPE}`, "blk", false, false, ""},
-		{`31`, `def blk "foo" {print TYPE+"."+NAME}`, "blk.foo", false, false, ""},
-		{`32`, `var a=1; def blk {print TYPE}`, "blk", false, false, ""},
-		{`33`, `print 1; def blk {print TYPE}`, "1\nblk", false, false, ""},
-		{`34`, `var a=1; def blk {print TYPE+a}`, "blk1", false, false, ""},
-		{`35`, `def blk {var a=1; print TYPE+a}`, "blk1", false, false, ""},
-		{`36`, `var x=1; def blk {var a=1+x; print TYPE+a}`, "blk2", false, false, ""},
-		{`37`, `def blk {var a=1; var b=2; print TYPE+(a+b)}`, "blk3", false, false, ""},
-		{`38`, `var x=5; def blk {var a=1; var b=2; print TYPE+(a+b+x)}`, "blk8", false, false, ""},
-		{`39`, `def b1{var x=1; def b2 {var a=2; print TYPE+(a+x)}}`, "b23", false, false, ""},
-		{`40`, `var a=5; def blk {var a=a+1; print TYPE+a}`, "blk6", false, false, ""},
-		{`41`, `def b1{var a=5; def b2 {var a=a+1; print TYPE+a} print TYPE+a}`, "b26\nb15", false, false, ""},
-		{`42`, `def b1{var a=5; def b2 {eval a=a+1; print TYPE+a} print TYPE+a}`, "b26\nb16", false, false, ""},
-		{`43`, `def b1{ print TYPE; def b2{print TYPE} }; def b3{print TYPE}`, "b1\nb2\nb3", false, false, ""},
-		{`44`, `var a; var b; eval a=1+(b=2); print a`, "3", false, false, ""},
-		{`45`, `var a; var b; print a=1+(b=2)`, "3", false, false, ""},
-		{`46`, `def x {42}`, "", false, false, ""},
-		{`47`, `def x {var x=42; x+1; print x}`, "42", false, false, ""},
-		{`48`, `def x {a=1+(b=2); print a}`, "3", false, false, ""},
-		{`49`, `def x {print a=1+(b=2)}`, "3", false, false, ""},
-		{`50`, `def x {print (a=1)+(b=2)}`, "3", false, false, ""},
-		{`51`, ``, "== /dev/stdin ==\n0000    1:1  RET", true, false, ""},
-		{`52`, `eval nil`, "== /dev/stdin ==\n0000    1:9  NIL\n0001      |  POP\n0002      |  RET", true, false, ""},
-		{`53`, `eval 42`, "== /dev/stdin ==\n0000    1:8  CONST         0 '42'\n0002      |  POP\n0003      |  RET", true, false, ""},
-		{`54`, `def b {}`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004      |  RET", true, false, ""},
-		{`55`, `1`, "", false, true, `expected statement`},
-		{`55.1`, `=1`, "", false, true, `expected statement`},
-		{`56`, `print`, "", false, true, `at end: expected expression`},
-		{`56.1`, `print print`, "", false, true, `at 'print': expected expression`},
-		{`56.2`, `print =`, "", false, true, `at '=': expected expression`},
-		{`57`, `eval`, "", false, true, `expected expression`},
-		{`58`, `eval (1`, "", false, true, `expected ')'`},
-		{`59`, `def 1`, "", false, true, `at '1': expected block type`},
-		{`60`, `def b {`, "", false, true, `at end: expected '}'`},
-		{`61.1`, `def b x {}`, "", false, true, `at 'x': expected '{'`},
-		{`61.2`, `def b 0 {}`, "", false, true, `at '0': expected '{'`},
-		{`62.1`, `def b "x" {`, "", false, true, `at end: expected '}'`},
-		{`62.2`, `def b "x" { z`, "", false, true, `at end: expected '}'`},
-		{`63.1`, `eval 1 =`, "", false, true, `at '=': invalid assignment target`},
-		{`63.2`, `eval "a" = `, "", false, true, `at '=': invalid assignment target`},
-		{`63.3`, `eval false = `, "", false, true, `at '=': invalid assignment target`},
-		{`64`, `eval a=42`, "", false, true, `at '=': invalid assignment target`},
-		{`65`, `var a; var a`, "", false, true, `at 'a': variable with this name already present`},
-		{`66`, `eval a`, "", false, true, `at 'a': undefined variable`},
-		{`67.1`, `print -1`, "-1", false, false, ""},
-		{`67.2`, `print -1.2`, "-1.2", false, false, ""},
-		{`67.3`, `print -(1)`, "-1", false, false, ""},
-		{`67.4`, `print -(1.2)`, "-1.2", false, false, ""},
-		{`67.5`, `var a=1;   print -a`, "-1", false, false, ""},
-		{`67.6`, `var a=1.2; print -a`, "-1.2", false, false, ""},
-		{`67.7`, `print -true`, "", false, true, `NEG: invalid type: bool, expected number`},
-		{`67.8`, `print -"abc"`, "", false, true, `NEG: invalid type: string, expected number`},
-		{`67.9`, `print -nil`, "", false, true, `NEG: invalid type: nil, expected number`},
-		{`68.1`, `print +1`, "1", false, false, ""},
-		{`68.2`, `print +1.2`, "1.2", false, false, ""},
-		{`68.3`, `print +(1)`, "1", false, false, ""},
-		{`68.4`, `print +(1.2)`, "1.2", false, false, ""},
-		{`67.5`, `var a=1;   print +a`, "1", false, false, ""},
-		{`67.6`, `var a=1.2; print +a`, "1.2", false, false, ""},
-		{`68.7`, `print +true`, "", false, true, `UNPLUS: invalid type: bool, expected number`},
-		{`68.8`, `print +"abcd"`, "", false, true, `UNPLUS: invalid type: string, expected number`},
-		{`68.9`, `print +nil`, "", false, true, `UNPLUS: invalid type: nil, expected number`},
-		{`69`, `print *1`, "", false, true, `at '*': expected expression`},
-		{`70`, `print /1`, "", false, true, `at '/': expected expression`},
-		{`71.1`, `print 1+2`, "3", false, false, ""},
-		{`71.2`, `print 1+2.5`, "3.5", false, false, ""},
-		{`71.3`, `print 1+true`, "", false, true, `ADD: invalid types: int, bool`},
-		{`71.4`, `print 1+"ab"`, "", false, true, `ADD: invalid types: int, string`},
-		{`71.5`, `print 1+nil`, "", false, true, `ADD: invalid types: int, nil`},
-		{`72.1`, `print 1.2+5`, "6.2", false, false, ""},
-		{`72.2`, `print 1.2+3.5`, "4.7", false, false, ""},
-		{`72.3`, `print 1.2+true`, "", false, true, `ADD: invalid types: float, bool`},
-		{`72.4`, `print 1.2+"ab"`, "", false, true, `ADD: invalid types: float, string`},
-		{`72.5`, `print 1.2+nil`, "", false, true, `ADD: invalid types: float, nil`},
-		{`73.1`, `print true+1`, "", false, true, `ADD: invalid types: bool, int`},
-		{`73.2`, `print true+1.2`, "", false, true, `ADD: invalid types: bool, float`},
-		{`73.3`, `print true+true`, "", false, true, `ADD: invalid types: bool, bool`},
-		{`73.4`, `print true+"ab"`, "", false, true, `ADD: invalid types: bool, string`},
-		{`73.5`, `print true+nil`, "", false, true, `ADD: invalid types: bool, nil`},
-		{`74.1`, `print "ab"+1`, "ab1", false, false, ""},
-		{`74.2`, `print "ab"+1.2`, "ab1.2", false, false, ""},
-		{`74.3`, `print "ab"+true`, "", false, true, `ADD: invalid types: string, bool`},
-		{`74.4`, `print "ab"+"cd"`, "abcd", false, false, ""},
-		{`74.5`, `print "ab"+nil`, "ab", false, false, ""},
-		{`75.1`, `print nil+1`, "", false, true, `ADD: invalid types: nil, int`},
-		{`75.2`, `print nil+1.2`, "", false, true, `ADD: invalid types: nil, float`},
-		{`75.3`, `print nil+true`, "", false, true, `ADD: invalid types: nil, bool`},
-		{`75.4`, `print nil+"ab"`, "", false, true, `ADD: invalid types: nil, string`},
-		{`75.5`, `print nil+nil`, "", false, true, `ADD: invalid types: nil, nil`},
-		{`76.1`, `print 1-2`, "-1", false, false, ""},
-		{`76.2`, `print 1-2.5`, "-1.5", false, false, ""},
-		{`76.3`, `print 1-true`, "", false, true, `SUB: invalid types: int, bool`},
-		{`76.4`, `print 1-"ab"`, "", false, true, `SUB: invalid types: int, string`},
-		{`76.5`, `print 1-nil`, "", false, true, `SUB: invalid types: int, nil`},
-		{`77.1`, `print 1.5-2`, "-0.5", false, false, ""},
-		{`77.2`, `print 1.0-2.5`, "-1.5", false, false, ""},
-		{`77.3`, `print 1.2-true`, "", false, true, `SUB: invalid types: float, bool`},
-		{`77.4`, `print 1.2-"ab"`, "", false, true, `SUB: invalid types: float, string`},
-		{`77.5`, `print 1.2-nil`, "", false, true, `SUB: invalid types: float, nil`},
-		{`78.1`, `print true-1`, "", false, true, `SUB: invalid types: bool, int`},
-		{`78.2`, `print true-1.2`, "", false, true, `SUB: invalid types: bool, float`},
-		{`78.3`, `print true-true`, "", false, true, `SUB: invalid types: bool, bool`},
-		{`78.4`, `print true-"ab"`, "", false, true, `SUB: invalid types: bool, string`},
-		{`78.5`, `print true-nil`, "", false, true, `SUB: invalid types: bool, nil`},
-		{`79.1`, `print "ab"-1`, "", false, true, `SUB: invalid types: string, int`},
-		{`79.2`, `print "ab"-1.2`, "", false, true, `SUB: invalid types: string, float`},
-		{`79.3`, `print "ab"-true`, "", false, true, `SUB: invalid types: string, bool`},
-		{`79.4`, `print "ab"-"cd"`, "", false, true, `SUB: invalid types: string, string`},
-		{`79.5`, `print "ab"-nil`, "", false, true, `SUB: invalid types: string, nil`},
-		{`80.1`, `print nil-1`, "", false, true, `SUB: invalid types: nil, int`},
-		{`80.2`, `print nil-1.2`, "", false, true, `SUB: invalid types: nil, float`},
-		{`80.3`, `print nil-true`, "", false, true, `SUB: invalid types: nil, bool`},
-		{`80.4`, `print nil-"ab"`, "", false, true, `SUB: invalid types: nil, string`},
-		{`80.5`, `print nil-nil`, "", false, true, `SUB: invalid types: nil, nil`},
-		{`81.1`, `print 1*2`, "2", false, false, ""},
-		{`81.2`, `print 1*2.5`, "2.5", false, false, ""},
-		{`81.3`, `print 1*true`, "", false, true, `MUL: invalid types: int, bool`},
-		{`81.4`, `print 1*"ab"`, "", false, true, `MUL: invalid types: int, string`},
-		{`81.5`, `print 1*nil`, "", false, true, `MUL: invalid types: int, nil`},
-		{`82.1`, `print 1.4*2`, "2.8", false, false, ""},
-		{`82.2`, `print 1.0*2.5`, "2.5", false, false, ""},
-		{`82.3`, `print 1.2*true`, "", false, true, `MUL: invalid types: float, bool`},
-		{`82.4`, `print 1.2*"ab"`, "", false, true, `MUL: invalid types: float, string`},
-		{`82.5`, `print 1.2*nil`, "", false, true, `MUL: invalid types: float, nil`},
-		{`83.1`, `print true*1`, "", false, true, `MUL: invalid types: bool, int`},
-		{`83.2`, `print true*1.2`, "", false, true, `MUL: invalid types: bool, float`},
-		{`83.3`, `print true*true`, "", false, true, `MUL: invalid types: bool, bool`},
-		{`83.4`, `print true*"ab"`, "", false, true, `MUL: invalid types: bool, string`},
-		{`83.5`, `print true*nil`, "", false, true, `MUL: invalid types: bool, nil`},
-		{`84.1`, `print "ab"*2`, "abab", false, false, ""},
-		{`84.2`, `print "ab"*1.2`, "", false, true, `MUL: invalid types: string, float`},
-		{`84.3`, `print "ab"*true`, "", false, true, `MUL: invalid types: string, bool`},
-		{`84.4`, `print "ab"*"cd"`, "", false, true, `MUL: invalid types: string, string`},
-		{`84.5`, `print "ab"*nil`, "", false, true, `MUL: invalid types: string, nil`},
-		{`85.1`, `print nil*1`, "", false, true, `MUL: invalid types: nil, int`},
-		{`85.2`, `print nil*1.2`, "", false, true, `MUL: invalid types: nil, float`},
-		{`85.3`, `print nil*true`, "", false, true, `MUL: invalid types: nil, bool`},
-		{`85.4`, `print nil*"ab"`, "", false, true, `MUL: invalid types: nil, string`},
-		{`85.5`, `print nil*nil`, "", false, true, `MUL: invalid types: nil, nil`},
-		{`86.1`, `print 1/2`, "0", false, false, ""},
-		{`86.2`, `print 1/2.0`, "0.5", false, false, ""},
-		{`86.3`, `print 1/true`, "", false, true, `DIV: invalid types: int, bool`},
-		{`86.4`, `print 1/"ab"`, "", false, true, `DIV: invalid types: int, string`},
-		{`86.5`, `print 1/nil`, "", false, true, `DIV: invalid types: int, nil`},
-		{`87.1`, `print 1.0/2`, "0.5", false, false, ""},
-		{`87.2`, `print 1.0/2.0`, "0.5", false, false, ""},
-		{`87.3`, `print 1.2/true`, "", false, true, `DIV: invalid types: float, bool`},
-		{`87.4`, `print 1.2/"ab"`, "", false, true, `DIV: invalid types: float, string`},
-		{`87.5`, `print 1.2/nil`, "", false, true, `DIV: invalid types: float, nil`},
-		{`88.1`, `print true/1`, "", false, true, `DIV: invalid types: bool, int`},
-		{`88.2`, `print true/1.2`, "", false, true, `DIV: invalid types: bool, float`},
-		{`88.3`, `print true/true`, "", false, true, `DIV: invalid types: bool, bool`},
-		{`88.4`, `print true/"ab"`, "", false, true, `DIV: invalid types: bool, string`},
-		{`88.5`, `print true/nil`, "", false, true, `DIV: invalid types: bool, nil`},
-		{`89.1`, `print "ab"/2`, "", false, true, `DIV: invalid types: string, int`},
-		{`89.2`, `print "ab"/1.2`, "", false, true, `DIV: invalid types: string, float`},
-		{`89.3`, `print "ab"/true`, "", false, true, `DIV: invalid types: string, bool`},
-		{`89.4`, `print "ab"/"cd"`, "", false, true, `DIV: invalid types: string, string`},
-		{`89.5`, `print "ab"/nil`, "", false, true, `DIV: invalid types: string, nil`},
-		{`90.1`, `print nil/1`, "", false, true, `DIV: invalid types: nil, int`},
-		{`90.2`, `print nil/1.2`, "", false, true, `DIV: invalid types: nil, float`},
-		{`90.3`, `print nil/true`, "", false, true, `DIV: invalid types: nil, bool`},
-		{`90.4`, `print nil/"ab"`, "", false, true, `DIV: invalid types: nil, string`},
-		{`90.5`, `print nil/nil`, "", false, true, `DIV: invalid types: nil, nil`},
-		{`91.1`, `print 1==1`, "true", false, false, ""},
-		{`91.2`, `print 1==1.0`, "true", false, false, ""},
-		{`91.3`, `print 1==true`, "false", false, false, ""},
-		{`91.4`, `print 1=="ab"`, "false", false, false, ""},
-		{`91.5`, `print 1==nil`, "false", false, false, ""},
-		{`92.1`, `print 1.0==1`, "true", false, false, ""},
-		{`92.2`, `print 1.0==1.0`, "true", false, false, ""},
-		{`92.3`, `print 1.2==true`, "false", false, false, ""},
-		{`92.4`, `print 1.2=="ab"`, "false", false, false, ""},
-		{`92.5`, `print 1.2==nil`, "false", false, false, ""},
-		{`93.1`, `print true==1`, "false", false, false, ""},
-		{`93.2`, `print true==1.2`, "false", false, false, ""},
-		{`93.3`, `print true==true`, "true", false, false, ""},
-		{`93.4`, `print true=="ab"`, "false", false, false, ""},
-		{`93.5`, `print true==nil`, "false", false, false, ""},
-		{`94.1`, `print "ab"==2`, "false", false, false, ""},
-		{`94.2`, `print "ab"==1.2`, "false", false, false, ""},
-		{`94.3`, `print "ab"==true`, "false", false, false, ""},
-		{`94.4`, `print "ab"=="cd"`, "false", false, false, ""},
-		{`94.5`, `print "ab"==nil`, "false", false, false, ""},
-		{`95.1`, `print nil==1`, "false", false, false, ""},
-		{`95.2`, `print nil==1.2`, "false", false, false, ""},
-		{`95.3`, `print nil==true`, "false", false, false, ""},
-		{`95.4`, `print nil=="ab"`, "false", false, false, ""},
-		{`95.5`, `print nil==nil`, "true", false, false, ""},
-		{`96.1`, `print 1<2`, "true", false, false, ""},
-		{`96.2`, `print 1<2.0`, "true", false, false, ""},
-		{`96.3`, `print 1<true`, "", false, true, `LT: invalid types: int, bool`},
-		{`96.4`, `print 1<"ab"`, "", false, true, `LT: invalid types: int, string`},
-		{`96.5`, `print 1<nil`, "", false, true, `LT: invalid types: int, nil`},
-		{`97.1`, `print 1.0<2`, "true", false, false, ""},
-		{`97.2`, `print 1.0<2.0`, "true", false, false, ""},
-		{`97.3`, `print 1.2<true`, "", false, true, `LT: invalid types: float, bool`},
-		{`97.4`, `print 1.2<"ab"`, "", false, true, `LT: invalid types: float, string`},
-		{`97.5`, `print 1.2<nil`, "", false, true, `LT: invalid types: float, nil`},
-		{`98.1`, `print true<1`, "", false, true, `LT: invalid types: bool, int`},
-		{`98.2`, `print true<1.0`, "", false, true, `LT: invalid types: bool, float`},
-		{`98.3`, `print true<true`, "", false, true, `LT: invalid types: bool, bool`},
-		{`98.4`, `print true<"ab"`, "", false, true, `LT: invalid types: bool, string`},
-		{`98.5`, `print true<nil`, "", false, true, `LT: invalid types: bool, nil`},
-		{`99.1`, `print "ab"<2`, "", false, true, `LT: invalid types: string, int`},
-		{`99.2`, `print "ab"<1.2`, "", false, true, `LT: invalid types: string, float`},
-		{`99.3`, `print "ab"<true`, "", false, true, `LT: invalid types: string, bool`},
-		{`99.4`, `print "ab"<"cd"`, "true", false, false, ""},
-		{`99.5`, `print "ab"<nil`, "", false, true, `LT: invalid types: string, nil`},
-		{`100.1`, `print nil<1`, "", false, true, `LT: invalid types: nil, int`},
-		{`100.2`, `print nil<1.2`, "", false, true, `LT: invalid types: nil, float`},
-		{`100.3`, `print nil<true`, "", false, true, `LT: invalid types: nil, bool`},
-		{`100.4`, `print nil<"ab"`, "", false, true, `LT: invalid types: nil, string`},
-		{`100.5`, `print nil<nil`, "", false, true, `LT: invalid types: nil, nil`},
-		{`101.1`, `print 1>2`, "false", false, false, ""},
-		{`101.2`, `print 1>2.0`, "false", false, false, ""},
-		{`101.3`, `print 1>true`, "", false, true, `GT: invalid types: int, bool`},
-		{`101.4`, `print 1>"ab"`, "", false, true, `GT: invalid types: int, string`},
-		{`101.5`, `print 1>nil`, "", false, true, `GT: invalid types: int, nil`},
-		{`102.1`, `print 1.0>2`, "false", false, false, ""},
-		{`102.2`, `print 1.0>2.0`, "false", false, false, ""},
-		{`102.3`, `print 1.2>true`, "", false, true, `GT: invalid types: float, bool`},
-		{`102.4`, `print 1.2>"ab"`, "", false, true, `GT: invalid types: float, string`},
-		{`102.5`, `print 1.2>nil`, "", false, true, `GT: invalid types: float, nil`},
-		{`103.1`, `print true>1`, "", false, true, `GT: invalid types: bool, int`},
-		{`103.2`, `print true>1.0`, "", false, true, `GT: invalid types: bool, float`},
-		{`103.3`, `print true>true`, "", false, true, `GT: invalid types: bool, bool`},
-		{`103.4`, `print true>"ab"`, "", false, true, `GT: invalid types: bool, string`},
-		{`103.5`, `print true>nil`, "", false, true, `GT: invalid types: bool, nil`},
-		{`104.1`, `print "ab">2`, "", false, true, `GT: invalid types: string, int`},
-		{`104.2`, `print "ab">1.2`, "", false, true, `GT: invalid types: string, float`},
-		{`104.3`, `print "ab">true`, "", false, true, `GT: invalid types: string, bool`},
-		{`104.4`, `print "ab">"cd"`, "false", false, false, ""},
-		{`104.5`, `print "ab">nil`, "", false, true, `GT: invalid types: string, nil`},
-		{`105.1`, `print nil>1`, "", false, true, `GT: invalid types: nil, int`},
-		{`105.2`, `print nil>1.2`, "", false, true, `GT: invalid types: nil, float`},
-		{`105.3`, `print nil>true`, "", false, true, `GT: invalid types: nil, bool`},
-		{`105.4`, `print nil>"ab"`, "", false, true, `GT: invalid types: nil, string`},
-		{`105.5`, `print nil>nil`, "", false, true, `GT: invalid types: nil, nil`},
-		{`106.1`, `print not 0`, "true", false, false, ""},
-		{`106.2`, `print not 0.0`, "true", false, false, ""},
-		{`106.3`, `print not false`, "true", false, false, ""},
-		{`106.4`, `print not ""`, "true", false, false, ""},
-		{`106.5`, `print not nil`, "true", false, false, ""},
-		{`107.1`, `print 1 or 2`, "1", false, false, ""},
-		{`107.2`, `print 1 or 2.0`, "1", false, false, ""},
-		{`107.3`, `print 1 or true`, "1", false, false, ""},
-		{`107.4`, `print 1 or "ab"`, "1", false, false, ""},
-		{`107.5`, `print 1 or nil`, "1", false, false, ""},
-		{`108.1`, `print 1.2 or 2`, "1.2", false, false, ""},
-		{`108.2`, `print 1.2 or 2.0`, "1.2", false, false, ""},
-		{`108.3`, `print 1.2 or true`, "1.2", false, false, ""},
-		{`108.4`, `print 1.2 or "ab"`, "1.2", false, false, ""},
-		{`108.5`, `print 1.2 or nil`, "1.2", false, false, ""},
-		{`109.1`, `print true or 1`, "true", false, false, ""},
-		{`109.2`, `print true or 1.0`, "true", false, false, ""},
-		{`109.3`, `print true or true`, "true", false, false, ""},
-		{`109.4`, `print true or "ab"`, "true", false, false, ""},
-		{`109.5`, `print true or nil`, "true", false, false, ""},
-		{`110.1`, `print "ab" or 2`, "ab", false, false, ""},
-		{`110.2`, `print "ab" or 1.2`, "ab", false, false, ""},
-		{`110.3`, `print "ab" or true`, "ab", false, false, ""},
-		{`110.4`, `print "ab" or "cd"`, "ab", false, false, ""},
-		{`110.5`, `print "ab" or nil`, "ab", false, false, ""},
-		{`112.1`, `print nil or 1`, "1", false, false, ""},
-		{`112.2`, `print nil or 1.2`, "1.2", false, false, ""},
-		{`112.3`, `print nil or true`, "true", false, false, ""},
-		{`112.4`, `print nil or "ab"`, "ab", false, false, ""},
-		{`112.5`, `print nil or nil`, "<nil>", false, false, ""},
-		{`113.1`, `print 1 and 2`, "2", false, false, ""},
-		{`113.2`, `print 1 and 2.5`, "2.5", false, false, ""},
-		{`113.3`, `print 1 and true`, "true", false, false, ""},
-		{`113.4`, `print 1 and "ab"`, "ab", false, false, ""},
-		{`113.5`, `print 1 and nil`, "<nil>", false, false, ""},
-		{`114.1`, `print 1.2 and 2`, "2", false, false, ""},
-		{`114.2`, `print 1.2 and 2.5`, "2.5", false, false, ""},
-		{`114.3`, `print 1.2 and true`, "true", false, false, ""},
-		{`114.4`, `print 1.2 and "ab"`, "ab", false, false, ""},
-		{`114.5`, `print 1.2 and nil`, "<nil>", false, false, ""},
-		{`115.1`, `print true and 2`, "2", false, false, ""},
-		{`115.2`, `print true and 2.5`, "2.5", false, false, ""},
-		{`115.3`, `print true and true`, "true", false, false, ""},
-		{`115.4`, `print true and "ab"`, "ab", false, false, ""},
-		{`115.5`, `print true and nil`, "<nil>", false, false, ""},
-		{`116.1`, `print "ab" and 2`, "2", false, false, ""},
-		{`116.2`, `print "ab" and 2.5`, "2.5", false, false, ""},
-		{`116.3`, `print "ab" and true`, "true", false, false, ""},
-		{`116.4`, `print "ab" and "cd"`, "cd", false, false, ""},
-		{`116.5`, `print "ab" and nil`, "<nil>", false, false, ""},
-		{`117.1`, `print nil and 2`, "<nil>", false, false, ""},
-		{`117.2`, `print nil and 2.5`, "<nil>", false, false, ""},
-		{`117.3`, `print nil and true`, "<nil>", false, false, ""},
-		{`117.4`, `print nil and "ab"`, "<nil>", false, false, ""},
-		{`117.5`, `print nil and nil`, "<nil>", false, false, ""},
-		{`120`, `def b{x}`, "", false, true, `'x' not resolved as var or field`},
-		{`121.1`, `print 1/0`, "", false, true, `division by int zero`},
-		{`121.2`, `print 1/0.0`, "+Inf", false, false, ""},
-		{`122.1`, `print  2147483647-1`, "2147483646", false, false, ""},
-		{`122.2`, `print -2147483647+1`, "-2147483646", false, false, ""},
-		{`123.1`, `var a; print "foo"+(a=1); print a`, "foo1\n1", false, false, ""},
-		{`123.2`, `var a; print 2+(a=1); print a`, "3\n1", false, false, ""},
-		{`123.3`, `def b{print "foo"+(a=1); print a}`, "foo1\n1", false, false, ""},
-		{`123.4`, `def b{var a; print "foo"+(a=1); print a}`, "foo1\n1", false, false, ""},
-		{`124.1`, `eval 42q`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c42q%[1]c`, '`')},
-		{`124.2`, `eval 42"q"`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c42"%[1]c`, '`')},
-		{`124.3`, `eval 42.0q`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c42.0q%[1]c`, '`')},
-		{`124.4`, `eval 0x42q`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c0x42q%[1]c`, '`')},
-		{`124.5`, `eval 42.0"q"`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c42.0"%[1]c`, '`')},
-		{`124.6`, `eval 0x42"q"`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c0x42"%[1]c`, '`')},
-		{`124.7`, `eval var"q"`, "", false, true, fmt.Sprintf(`invalid syntax %[1]cvar"%[1]c`, '`')},
-		{`124.8`, `eval foo"q"`, "", false, true, fmt.Sprintf(`invalid syntax %[1]cfoo"%[1]c`, '`')},
-		{`124.9`, `eval "foo"1`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c"foo"1%[1]c`, '`')},
-		{`124.10`, `eval "foo"q`, "", false, true, fmt.Sprintf(`invalid syntax %[1]c"foo"q%[1]c`, '`')},
-		{`125.1`, `def b {}; bind b`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  BIND 0x11     0 'b'\t   0#\n0008      |  RET", true, false, ""},
-		{`125.2`, `def b {}; bind b:all`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:21  BIND 0x2F     0 'b'\t   0#\n0008      |  RET", true, false, ""},
-		{`125.3`, `def b {}; def b{x=1}; bind b:first`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  DEFBLOCK      0 'b'\t   1 ''\n0007   1:20  ONE\n0008      |  SETFIELD      2 'x'\n0010      |  POP\n0011   1:21  ENDBLOCK\n0012   1:35  BIND 0x12     0 'b'\t   0#\n0016      |  RET", true, false, ""},
-		{`125.4`, `def b {}; def b{x=1}; bind b:last`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  DEFBLOCK      0 'b'\t   1 ''\n0007   1:20  ONE\n0008      |  SETFIELD      2 'x'\n0010      |  POP\n0011   1:21  ENDBLOCK\n0012   1:34  BIND 0x13     0 'b'\t   0#\n0016      |  RET", true, false, ""},
-		{`125.5`, `def b {}; def b{x=1}; bind b:all`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  DEFBLOCK      0 'b'\t   1 ''\n0007   1:20  ONE\n0008      |  SETFIELD      2 'x'\n0010      |  POP\n0011   1:21  ENDBLOCK\n0012   1:33  BIND 0x2F     0 'b'\t   0#\n0016      |  RET", true, false, ""},
-		{`126.1`, `bind x`, "", false, true, `no blocks of type x`},
-		{`126.2`, `def x{}; def x{}; bind x`, "", false, true, `found 2 blocks of type x`},
-		{`126.3`, `def x{}; def x{}; bind x:1`, "", false, true, `found 2 blocks of type x`},
-		{`126.4`, `def x{}; bind x:   `, "", false, true, `expected block selector`},
-		{`126.5`, `def x{}; bind x:foo`, "", false, true, `expected block selector`},
-		{`126.6`, `def x{}; bind x; bind x:all`, "", false, false, ""},
-		{`127.1`, `def b "foo"{}; bind b:"foo"`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:28  BIND 0x14     0 'b'\t   1#\t   1 'foo'\n0009      |  RET", true, false, ""},
-		{`127.2`, `def b "foo"{}; def b "bar"{}; bind b:"bar"`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:28  DEFBLOCK      0 'b'\t   2 'bar'\n0007   1:29  ENDBLOCK\n0008   1:43  BIND 0x14     0 'b'\t   1#\t   2 'bar'\n0013      |  RET", true, false, ""},
-		{`128.1`, `bind x:"foo"`, "", false, true, `no blocks of type x`},
-		{`128.2`, `def x{}; bind x:"foo"`, "", false, true, `block x:"foo" not found`},
-		{`128.3`, `def x "a"{}; bind x:"foo"`, "", false, true, `block x:"foo" not found`},
-		{`128.4`, `def x "a"{}; bind x:"foo",`, "", false, true, `block x:"foo" not found`},
-		{`128.5`, `def x "a"{}; def x "b"{}; bind x:"a"`, "", false, false, ""},
-		{`128.6`, `def x "a"{}; def x "b"{}; bind x:"b"`, "", false, false, ""},
-		{`128.7`, `def x "a"{}; bind x:"a",`, "", false, false, ""},
-		{`128.8`, `def x "a"{}; def x "b"{}; bind x:"a",`, "", false, false, ""},
-		{`128.9`, `def x "a"{}; def x "b"{}; bind x:"b",`, "", false, false, ""},
-		{`129.1`, `def b "foo"{}; bind b:"foo",`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:29  BIND 0x25     0 'b'\t   1#\t   1 'foo'\n0009      |  RET", true, false, ""},
-		{`129.2`, `def b "foo"{}; def b "bar"{}; bind b:"foo","bar"`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:28  DEFBLOCK      0 'b'\t   2 'bar'\n0007   1:29  ENDBLOCK\n0008   1:49  BIND 0x25     0 'b'\t   2#\t   1 'foo'\t   2 'bar'\n0014      |  RET", true, false, ""},
-		{`130.1`, `def x "a"{}; bind x:"a",`, "", false, false, ""},
-		{`130.2`, `def x "a"{}; def x "b"{}; bind x:"a","b"`, "", false, false, ""},
-		{`130.3`, `def x "a"{}; def x "b"{}; bind x:"a","b",`, "", false, false, ""},
-		{`130.4`, `def x "a"{}; bind x:"foo",`, "", false, true, `block x:"foo" not found`},
-		{`130.5`, `def x "a"{}; def x "b"{}; bind x:"c",`, "", false, true, `block x:"c" not found`},
-		{`131.1`, `bind {}`, "== /dev/stdin ==\n0000    1:7  DEFUBIND\n0001    1:8  ENDUBIND\n0002      |  RET", true, false, ""},
-		{`131.2`, `def x{}; bind {x}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFUBIND\n0005   1:17  BIND 0x11     0 'x'\t   0#\n0009   1:18  ENDUBIND\n0010      |  RET", true, false, ""},
-		{`131.3`, `def x{}; def y{}; bind {x; y:all}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:26  BIND 0x11     0 'x'\t   0#\n0013   1:33  BIND 0x2F     2 'y'\t   0#\n0017   1:34  ENDUBIND\n0018      |  RET", true, false, ""},
-		{`131.4`, `def x{}; def y{}; bind {y; x:all}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:26  BIND 0x11     2 'y'\t   0#\n0013   1:33  BIND 0x2F     0 'x'\t   0#\n0017   1:34  ENDUBIND\n0018      |  RET", true, false, ""},
-		{`131.5`, `def x{}; def y{}; bind {x:first; y:last}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:32  BIND 0x12     0 'x'\t   0#\n0013   1:40  BIND 0x13     2 'y'\t   0#\n0017   1:41  ENDUBIND\n0018      |  RET", true, false, ""},
-		{`131.6`, `def x{}; def y{}; bind {x:last; y:first}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:31  BIND 0x13     0 'x'\t   0#\n0013   1:40  BIND 0x12     2 'y'\t   0#\n0017   1:41  ENDUBIND\n0018      |  RET", true, false, ""},
-		{`131.7`, `def x "a"{}; bind {x:"a"}`, "== /dev/stdin ==\n0000   1:11  DEFBLOCK      0 'x'\t   1 'a'\n0003   1:12  ENDBLOCK\n0004   1:20  DEFUBIND\n0005   1:25  BIND 0x14     0 'x'\t   1#\t   1 'a'\n0010   1:26  ENDUBIND\n0011      |  RET", true, false, ""},
-		{`131.8`, `def x "a"{}; bind {x:"a",}`, "== /dev/stdin ==\n0000   1:11  DEFBLOCK      0 'x'\t   1 'a'\n0003   1:12  ENDBLOCK\n0004   1:20  DEFUBIND\n0005   1:26  BIND 0x25     0 'x'\t   1#\t   1 'a'\n0010   1:27  ENDUBIND\n0011      |  RET", true, false, ""},
-		{`132.1`, `bind {}`, "", false, false, ""},
-		{`132.2`, `def x{}; bind {x}`, "", false, false, ""},
-		{`132.3`, `def x{}; bind {x:all}`, "", false, false, ""},
-		{`132.4`, `def x{}; def y{}; bind {x; y}`, "", false, false, ""},
-		{`132.5`, `def x{}; def y{}; bind {x; y:all}`, "", false, false, ""},
-		{`132.6`, `def x{}; def y{}; bind {x:all; y}`, "", false, false, ""},
-		{`133.1`, `bind {42x}`, "", false, true, `invalid syntax`},
-		{`133.2`, `bind {x}`, "", false, true, `no blocks of type x`},
-		{`133.3`, `def x{}; bind {x:"a"}`, "", false, true, `block x:"a" not found`},
-		{`133.4`, `def x{}; bind {x:"a",}`, "", false, true, `block x:"a" not found`},
-		{`133.5`, `bind{bind}`, "", false, true, `expected block type`},
-		{`133.6`, `bind{bind{}}`, "", false, true, `expected block type`},
-		{`122.1-64`, `print  9223372036854775807-1`, "9223372036854775806", false, false, ""},
-		{`122.2-64`, `print -9223372036854775807+1`, "-9223372036854775806", false, false, ""},
+		{`0`, ``, "", false, 0, false, ""},
+		{`0.1`, `eval "expr that is discarded"`, "", false, 0, false, ""},
+		{`0.2`, `eval nil`, "", false, 0, false, ""},
+		{`0.3`, `eval "anything"`, "", false, 0, false, ""},
+		{`1`, `var a; print not a`, "true", false, 0, false, ""},
+		{`2`, `var a; print a==nil`, "true", false, 0, false, ""},
+		{`3`, `var a=1; eval a=nil; print a==nil`, "true", false, 0, false, ""},
+		{`4`, `print 1+1`, "2", false, 0, false, ""},
+		{`5`, `print 1+2.14`, "3.14", false, 0, false, ""},
+		{`6`, `print 123/2-50+2*8`, "27", false, 0, false, ""},
+		{`6.1`, `print 123.0/2-50+2*8`, "27.5", false, 0, false, ""},
+		{`6.2`, `print 123/2.0-50+2*8`, "27.5", false, 0, false, ""},
+		{`6.3`, `print 123.0/2.0-50+2*8`, "27.5", false, 0, false, ""},
+		{`6.4`, `print 123/2-50+2.0*8`, "27", false, 0, false, ""},
+		{`6.5`, `print 123/2-50+2*8.0`, "27", false, 0, false, ""},
+		{`6.6`, `print 123/2-50+2.0*8.0`, "27", false, 0, false, ""},
+		{`6.7`, `print 123/2-50.0+2*8`, "27", false, 0, false, ""},
+		{`7`, `print 1-2`, "-1", false, 0, false, ""},
+		{`8`, `print 1--2`, "3", false, 0, false, ""},
+		{`9`, `print 1- +1`, "0", false, 0, false, ""},
+		{`10`, `print 1+ +1`, "2", false, 0, false, ""},
+		{`10.1`, `print 1.0+ +1`, "2", false, 0, false, ""},
+		{`10.2`, `print 1+ +1.0`, "2", false, 0, false, ""},
+		{`10.3`, `print 1.0+ +1.0`, "2", false, 0, false, ""},
+		{`11`, `print ---10`, "-10", false, 0, false, ""},
+		{`11.1`, `print ---10.0`, "-10", false, 0, false, ""},
+		{`12`, `print 1==1`, "true", false, 0, false, ""},
+		{`12.1`, `print 1.0==1`, "true", false, 0, false, ""},
+		{`12.2`, `print 1==1.0`, "true", false, 0, false, ""},
+		{`12.3`, `print 1.0==1.0`, "true", false, 0, false, ""},
+		{`13`, `print not 1>3`, "true", false, 0, false, ""},
+		{`13.1`, `print not 1.0>3`, "true", false, 0, false, ""},
+		{`13.2`, `print not 1>3.0`, "true", false, 0, false, ""},
+		{`13.3`, `print not 1.0>3.0`, "true", false, 0, false, ""},
+		{`13.4`, `print not 3<=1`, "true", false, 0, false, ""},
+		{`14`, `print 1<2 and 0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.1`, `print 1.0<2 and 0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.2`, `print 1<2.0 and 0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.3`, `print 1.0<2.0 and 0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.4`, `print 1<2 and 0.0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.5`, `print 1.0<2 and 0.0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.6`, `print 1<2.0 and 0.0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`14.7`, `print 1.0<2.0 and 0.0 or "whatever"`, "whatever", false, 0, false, ""},
+		{`15`, `print 1>2 or true and 42`, "42", false, 0, false, ""},
+		{`16`, `print 1<10/5 and 127*-1+154`, "27", false, 0, false, ""},
+		{`17`, `print "q"*2`, "qq", false, 0, false, ""},
+		{`18`, `print "q"+"x"`, "qx", false, 0, false, ""},
+		{`19`, `print "q"+3`, "q3", false, 0, false, ""},
+		{`20`, `print "q"+3.14`, "q3.14", false, 0, false, ""},
+		{`21`, `print "q"=="q"`, "true", false, 0, false, ""},
+		{`22`, `print "q"!="p"`, "true", false, 0, false, ""},
+		{`23`, `print "p"<"q"`, "true", false, 0, false, ""},
+		{`24`, `print "q">"p"`, "true", false, 0, false, ""},
+		{`25`, `print not (false or true)`, "false", false, 0, false, ""},
+		{`25.1`, `print not (false and true)`, "true", false, 0, false, ""},
+		{`25.2`, `print "" or 42`, "42", false, 0, false, ""},
+		{`26`, `var a=100; var b=a-90; print -b`, "-10", false, 0, false, ""},
+		{`27`, `var a=1; var b=2; print a+b`, "3", false, 0, false, ""},
+		{`28`, `var a=1; eval a=a+1; print a`, "2", false, 0, false, ""},
+		{`29`, `var a=1; print a=a+1`, "2", false, 0, false, ""},
+		{`30`, `def blk {print TYPE}`, "blk", false, 0, false, ""},
+		{`31`, `def blk "foo" {print TYPE+"."+NAME}`, "blk.foo", false, 0, false, ""},
+		{`32`, `var a=1; def blk {print TYPE}`, "blk", false, 0, false, ""},
+		{`33`, `print 1; def blk {print TYPE}`, "1\nblk", false, 0, false, ""},
+		{`34`, `var a=1; def blk {print TYPE+a}`, "blk1", false, 0, false, ""},
+		{`35`, `def blk {var a=1; print TYPE+a}`, "blk1", false, 0, false, ""},
+		{`36`, `var x=1; def blk {var a=1+x; print TYPE+a}`, "blk2", false, 0, false, ""},
+		{`37`, `def blk {var a=1; var b=2; print TYPE+(a+b)}`, "blk3", false, 0, false, ""},
+		{`38`, `var x=5; def blk {var a=1; var b=2; print TYPE+(a+b+x)}`, "blk8", false, 0, false, ""},
+		{`39`, `def b1{var x=1; def b2 {var a=2; print TYPE+(a+x)}}`, "b23", false, 0, false, ""},
+		{`40`, `var a=5; def blk {var a=a+1; print TYPE+a}`, "blk6", false, 0, false, ""},
+		{`41`, `def b1{var a=5; def b2 {var a=a+1; print TYPE+a} print TYPE+a}`, "b26\nb15", false, 0, false, ""},
+		{`42`, `def b1{var a=5; def b2 {eval a=a+1; print TYPE+a} print TYPE+a}`, "b26\nb16", false, 0, false, ""},
+		{`43`, `def b1{ print TYPE; def b2{print TYPE} }; def b3{print TYPE}`, "b1\nb2\nb3", false, 0, false, ""},
+		{`44`, `var a; var b; eval a=1+(b=2); print a`, "3", false, 0, false, ""},
+		{`45`, `var a; var b; print a=1+(b=2)`, "3", false, 0, false, ""},
+		{`46`, `def x {42}`, "", false, 0, false, ""},
+		{`47`, `def x {var x=42; x+1; print x}`, "42", false, 0, false, ""},
+		{`48`, `def x {a=1+(b=2); print a}`, "3", false, 0, false, ""},
+		{`49`, `def x {print a=1+(b=2)}`, "3", false, 0, false, ""},
+		{`50`, `def x {print (a=1)+(b=2)}`, "3", false, 0, false, ""},
+		{`51`, ``, "== /dev/stdin ==\n0000    1:1  RET", true, 0, false, ""},
+		{`52`, `eval nil`, "== /dev/stdin ==\n0000    1:9  NIL\n0001      |  POP\n0002      |  RET", true, 0, false, ""},
+		{`53`, `eval 42`, "== /dev/stdin ==\n0000    1:8  CONST         0 '42'\n0002      |  POP\n0003      |  RET", true, 0, false, ""},
+		{`54`, `def b {}`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004      |  RET", true, 0, false, ""},
+		{`55`, `1`, "", false, 0, true, `expected statement`},
+		{`55.1`, `=1`, "", false, 0, true, `expected statement`},
+		{`56`, `print`, "", false, 0, true, `at end: expected expression`},
+		{`56.1`, `print print`, "", false, 0, true, `at 'print': expected expression`},
+		{`56.2`, `print =`, "", false, 0, true, `at '=': expected expression`},
+		{`57`, `eval`, "", false, 0, true, `expected expression`},
+		{`58`, `eval (1`, "", false, 0, true, `expected ')'`},
+		{`59`, `def 1`, "", false, 0, true, `at '1': expected block type`},
+		{`60`, `def b {`, "", false, 0, true, `at end: expected '}'`},
+		{`61.1`, `def b x {}`, "", false, 0, true, `at 'x': undefined variable`},
+		{`61.2`, `def b 0 {}`, "", false, 0, true, `block name: invalid type: int, expected string`},
+		{`61.3`, `def b "x" y {}`, "", false, 0, true, `at 'y': expected '{'`},
+		{`62.1`, `def b "x" {`, "", false, 0, true, `at end: expected '}'`},
+		{`62.2`, `def b "x" { z`, "", false, 0, true, `at end: expected '}'`},
+		{`63.1`, `eval 1 =`, "", false, 0, true, `at '=': invalid assignment target`},
+		{`63.2`, `eval "a" = `, "", false, 0, true, `at '=': invalid assignment target`},
+		{`63.3`, `eval false = `, "", false, 0, true, `at '=': invalid assignment target`},
+		{`64`, `eval a=42`, "", false, 0, true, `at '=': invalid assignment target`},
+		{`65`, `var a; var a`, "", false, 0, true, `at 'a': variable with this name already present`},
+		{`66`, `eval a`, "", false, 0, true, `at 'a': undefined variable`},
+		{`67.1`, `print -1`, "-1", false, 0, false, ""},
+		{`67.2`, `print -1.2`, "-1.2", false, 0, false, ""},
+		{`67.3`, `print -(1)`, "-1", false, 0, false, ""},
+		{`67.4`, `print -(1.2)`, "-1.2", false, 0, false, ""},
+		{`67.5`, `var a=1;   print -a`, "-1", false, 0, false, ""},
+		{`67.6`, `var a=1.2; print -a`, "-1.2", false, 0, false, ""},
+		{`67.7`, `print -true`, "", false, 0, true, `NEG: invalid type: bool, expected number`},
+		{`67.8`, `print -"abc"`, "", false, 0, true, `NEG: invalid type: string, expected number`},
+		{`67.9`, `print -nil`, "", false, 0, true, `NEG: invalid type: nil, expected number`},
+		{`68.1`, `print +1`, "1", false, 0, false, ""},
+		{`68.2`, `print +1.2`, "1.2", false, 0, false, ""},
+		{`68.3`, `print +(1)`, "1", false, 0, false, ""},
+		{`68.4`, `print +(1.2)`, "1.2", false, 0, false, ""},
+		{`67.5`, `var a=1;   print +a`, "1", false, 0, false, ""},
+		{`67.6`, `var a=1.2; print +a`, "1.2", false, 0, false, ""},
+		{`68.7`, `print +true`, "", false, 0, true, `UNPLUS: invalid type: bool, expected number`},
+		{`68.8`, `print +"abcd"`, "", false, 0, true, `UNPLUS: invalid type: string, expected number`},
+		{`68.9`, `print +nil`, "", false, 0, true, `UNPLUS: invalid type: nil, expected number`},
+		{`69`, `print *1`, "", false, 0, true, `at '*': expected expression`},
+		{`70`, `print /1`, "", false, 0, true, `at '/': expected expression`},
+		{`71.1`, `print 1+2`, "3", false, 0, false, ""},
+		{`71.2`, `print 1+2.5`, "3.5", false, 0, false, ""},
+		{`71.3`, `print 1+true`, "", false, 0, true, `ADD: invalid types: int, bool`},
+		{`71.4`, `print 1+"ab"`, "", false, 0, true, `ADD: invalid types: int, string`},
+		{`71.5`, `print 1+nil`, "", false, 0, true, `ADD: invalid types: int, nil`},
+		{`72.1`, `print 1.2+5`, "6.2", false, 0, false, ""},
+		{`72.2`, `print 1.2+3.5`, "4.7", false, 0, false, ""},
+		{`72.3`, `print 1.2+true`, "", false, 0, true, `ADD: invalid types: float, bool`},
+		{`72.4`, `print 1.2+"ab"`, "", false, 0, true, `ADD: invalid types: float, string`},
+		{`72.5`, `print 1.2+nil`, "", false, 0, true, `ADD: invalid types: float, nil`},
+		{`73.1`, `print true+1`, "", false, 0, true, `ADD: invalid types: bool, int`},
+		{`73.2`, `print true+1.2`, "", false, 0, true, `ADD: invalid types: bool, float`},
+		{`73.3`, `print true+true`, "", false, 0, true, `ADD: invalid types: bool, bool`},
+		{`73.4`, `print true+"ab"`, "", false, 0, true, `ADD: invalid types: bool, string`},
+		{`73.5`, `print true+nil`, "", false, 0, true, `ADD: invalid types: bool, nil`},
+		{`74.1`, `print "ab"+1`, "ab1", false, 0, false, ""},
+		{`74.2`, `print "ab"+1.2`, "ab1.2", false, 0, false, ""},
+		{`74.3`, `print "ab"+true`, "", false, 0, true, `ADD: invalid types: string, bool`},
+		{`74.4`, `print "ab"+"cd"`, "abcd", false, 0, false, ""},
+		{`74.5`, `print "ab"+nil`, "ab", false, 0, false, ""},
+		{`75.1`, `print nil+1`, "", false, 0, true, `ADD: invalid types: nil, int`},
+		{`75.2`, `print nil+1.2`, "", false, 0, true, `ADD: invalid types: nil, float`},
+		{`75.3`, `print nil+true`, "", false, 0, true, `ADD: invalid types: nil, bool`},
+		{`75.4`, `print nil+"ab"`, "", false, 0, true, `ADD: invalid types: nil, string`},
+		{`75.5`, `print nil+nil`, "", false, 0, true, `ADD: invalid types: nil, nil`},
+		{`76.1`, `print 1-2`, "-1", false, 0, false, ""},
+		{`76.2`, `print 1-2.5`, "-1.5", false, 0, false, ""},
+		{`76.3`, `print 1-true`, "", false, 0, true, `SUB: invalid types: int, bool`},
+		{`76.4`, `print 1-"ab"`, "", false, 0, true, `SUB: invalid types: int, string`},
+		{`76.5`, `print 1-nil`, "", false, 0, true, `SUB: invalid types: int, nil`},
+		{`77.1`, `print 1.5-2`, "-0.5", false, 0, false, ""},
+		{`77.2`, `print 1.0-2.5`, "-1.5", false, 0, false, ""},
+		{`77.3`, `print 1.2-true`, "", false, 0, true, `SUB: invalid types: float, bool`},
+		{`77.4`, `print 1.2-"ab"`, "", false, 0, true, `SUB: invalid types: float, string`},
+		{`77.5`, `print 1.2-nil`, "", false, 0, true, `SUB: invalid types: float, nil`},
+		{`78.1`, `print true-1`, "", false, 0, true, `SUB: invalid types: bool, int`},
+		{`78.2`, `print true-1.2`, "", false, 0, true, `SUB: invalid types: bool, float`},
+		{`78.3`, `print true-true`, "", false, 0, true, `SUB: invalid types: bool, bool`},
+		{`78.4`, `print true-"ab"`, "", false, 0, true, `SUB: invalid types: bool, string`},
+		{`78.5`, `print true-nil`, "", false, 0, true, `SUB: invalid types: bool, nil`},
+		{`79.1`, `print "ab"-1`, "", false, 0, true, `SUB: invalid types: string, int`},
+		{`79.2`, `print "ab"-1.2`, "", false, 0, true, `SUB: invalid types: string, float`},
+		{`79.3`, `print "ab"-true`, "", false, 0, true, `SUB: invalid types: string, bool`},
+		{`79.4`, `print "ab"-"cd"`, "", false, 0, true, `SUB: invalid types: string, string`},
+		{`79.5`, `print "ab"-nil`, "", false, 0, true, `SUB: invalid types: string, nil`},
+		{`80.1`, `print nil-1`, "", false, 0, true, `SUB: invalid types: nil, int`},
+		{`80.2`, `print nil-1.2`, "", false, 0, true, `SUB: invalid types: nil, float`},
+		{`80.3`, `print nil-true`, "", false, 0, true, `SUB: invalid types: nil, bool`},
+		{`80.4`, `print nil-"ab"`, "", false, 0, true, `SUB: invalid types: nil, string`},
+		{`80.5`, `print nil-nil`, "", false, 0, true, `SUB: invalid types: nil, nil`},
+		{`81.1`, `print 1*2`, "2", false, 0, false, ""},
+		{`81.2`, `print 1*2.5`, "2.5", false, 0, false, ""},
+		{`81.3`, `print 1*true`, "", false, 0, true, `MUL: invalid types: int, bool`},
+		{`81.4`, `print 1*"ab"`, "", false, 0, true, `MUL: invalid types: int, string`},
+		{`81.5`, `print 1*nil`, "", false, 0, true, `MUL: invalid types: int, nil`},
+		{`82.1`, `print 1.4*2`, "2.8", false, 0, false, ""},
+		{`82.2`, `print 1.0*2.5`, "2.5", false, 0, false, ""},
+		{`82.3`, `print 1.2*true`, "", false, 0, true, `MUL: invalid types: float, bool`},
+		{`82.4`, `print 1.2*"ab"`, "", false, 0, true, `MUL: invalid types: float, string`},
+		{`82.5`, `print 1.2*nil`, "", false, 0, true, `MUL: invalid types: float, nil`},
+		{`83.1`, `print true*1`, "", false, 0, true, `MUL: invalid types: bool, int`},
+		{`83.2`, `print true*1.2`, "", false, 0, true, `MUL: invalid types: bool, float`},
+		{`83.3`, `print true*true`, "", false, 0, true, `MUL: invalid types: bool, bool`},
+		{`83.4`, `print true*"ab"`, "", false, 0, true, `MUL: invalid types: bool, string`},
+		{`83.5`, `print true*nil`, "", false, 0, true, `MUL: invalid types: bool, nil`},
+		{`84.1`, `print "ab"*2`, "abab", false, 0, false, ""},
+		{`84.2`, `print "ab"*1.2`, "", false, 0, true, `MUL: invalid types: string, float`},
+		{`84.3`, `print "ab"*true`, "", false, 0, true, `MUL: invalid types: string, bool`},
+		{`84.4`, `print "ab"*"cd"`, "", false, 0, true, `MUL: invalid types: string, string`},
+		{`84.5`, `print "ab"*nil`, "", false, 0, true, `MUL: invalid types: string, nil`},
+		{`85.1`, `print nil*1`, "", false, 0, true, `MUL: invalid types: nil, int`},
+		{`85.2`, `print nil*1.2`, "", false, 0, true, `MUL: invalid types: nil, float`},
+		{`85.3`, `print nil*true`, "", false, 0, true, `MUL: invalid types: nil, bool`},
+		{`85.4`, `print nil*"ab"`, "", false, 0, true, `MUL: invalid types: nil, string`},
+		{`85.5`, `print nil*nil`, "", false, 0, true, `MUL: invalid types: nil, nil`},
+		{`86.1`, `print 1/2`, "0", false, 0, false, ""},
+		{`86.2`, `print 1/2.0`, "0.5", false, 0, false, ""},
+		{`86.3`, `print 1/true`, "", false, 0, true, `DIV: invalid types: int, bool`},
+		{`86.4`, `print 1/"ab"`, "", false, 0, true, `DIV: invalid types: int, string`},
+		{`86.5`, `print 1/nil`, "", false, 0, true, `DIV: invalid types: int, nil`},
+		{`87.1`, `print 1.0/2`, "0.5", false, 0, false, ""},
+		{`87.2`, `print 1.0/2.0`, "0.5", false, 0, false, ""},
+		{`87.3`, `print 1.2/true`, "", false, 0, true, `DIV: invalid types: float, bool`},
+		{`87.4`, `print 1.2/"ab"`, "", false, 0, true, `DIV: invalid types: float, string`},
+		{`87.5`, `print 1.2/nil`, "", false, 0, true, `DIV: invalid types: float, nil`},
+		{`88.1`, `print true/1`, "", false, 0, true, `DIV: invalid types: bool, int`},
+		{`88.2`, `print true/1.2`, "", false, 0, true, `DIV: invalid types: bool, float`},
+		{`88.3`, `print true/true`, "", false, 0, true, `DIV: invalid types: bool, bool`},
+		{`88.4`, `print true/"ab"`, "", false, 0, true, `DIV: invalid types: bool, string`},
+		{`88.5`, `print true/nil`, "", false, 0, true, `DIV: invalid types: bool, nil`},
+		{`89.1`, `print "ab"/2`, "", false, 0, true, `DIV: invalid types: string, int`},
+		{`89.2`, `print "ab"/1.2`, "", false, 0, true, `DIV: invalid types: string, float`},
+		{`89.3`, `print "ab"/true`, "", false, 0, true, `DIV: invalid types: string, bool`},
+		{`89.4`, `print "ab"/"cd"`, "", false, 0, true, `DIV: invalid types: string, string`},
+		{`89.5`, `print "ab"/nil`, "", false, 0, true, `DIV: invalid types: string, nil`},
+		{`90.1`, `print nil/1`, "", false, 0, true, `DIV: invalid types: nil, int`},
+		{`90.2`, `print nil/1.2`, "", false, 0, true, `DIV: invalid types: nil, float`},
+		{`90.3`, `print nil/true`, "", false, 0, true, `DIV: invalid types: nil, bool`},
+		{`90.4`, `print nil/"ab"`, "", false, 0, true, `DIV: invalid types: nil, string`},
+		{`90.5`, `print nil/nil`, "", false, 0, true, `DIV: invalid types: nil, nil`},
+		{`91.1`, `print 1==1`, "true", false, 0, false, ""},
+		{`91.2`, `print 1==1.0`, "true", false, 0, false, ""},
+		{`91.3`, `print 1==true`, "false", false, 0, false, ""},
+		{`91.4`, `print 1=="ab"`, "false", false, 0, false, ""},
+		{`91.5`, `print 1==nil`, "false", false, 0, false, ""},
+		{`92.1`, `print 1.0==1`, "true", false, 0, false, ""},
+		{`92.2`, `print 1.0==1.0`, "true", false, 0, false, ""},
+		{`92.3`, `print 1.2==true`, "false", false, 0, false, ""},
+		{`92.4`, `print 1.2=="ab"`, "false", false, 0, false, ""},
+		{`92.5`, `print 1.2==nil`, "false", false, 0, false, ""},
+		{`93.1`, `print true==1`, "false", false, 0, false, ""},
+		{`93.2`, `print true==1.2`, "false", false, 0, false, ""},
+		{`93.3`, `print true==true`, "true", false, 0, false, ""},
+		{`93.4`, `print true=="ab"`, "false", false, 0, false, ""},
+		{`93.5`, `print true==nil`, "false", false, 0, false, ""},
+		{`94.1`, `print "ab"==2`, "false", false, 0, false, ""},
+		{`94.2`, `print "ab"==1.2`, "false", false, 0, false, ""},
+		{`94.3`, `print "ab"==true`, "false", false, 0, false, ""},
+		{`94.4`, `print "ab"=="cd"`, "false", false, 0, false, ""},
+		{`94.5`, `print "ab"==nil`, "false", false, 0, false, ""},
+		{`95.1`, `print nil==1`, "false", false, 0, false, ""},
+		{`95.2`, `print nil==1.2`, "false", false, 0, false, ""},
+		{`95.3`, `print nil==true`, "false", false, 0, false, ""},
+		{`95.4`, `print nil=="ab"`, "false", false, 0, false, ""},
+		{`95.5`, `print nil==nil`, "true", false, 0, false, ""},
+		{`96.1`, `print 1<2`, "true", false, 0, false, ""},
+		{`96.2`, `print 1<2.0`, "true", false, 0, false, ""},
+		{`96.3`, `print 1<true`, "", false, 0, true, `LT: invalid types: int, bool`},
+		{`96.4`, `print 1<"ab"`, "", false, 0, true, `LT: invalid types: int, string`},
+		{`96.5`, `print 1<nil`, "", false, 0, true, `LT: invalid types: int, nil`},
+		{`97.1`, `print 1.0<2`, "true", false, 0, false, ""},
+		{`97.2`, `print 1.0<2.0`, "true", false, 0, false, ""},
+		{`97.3`, `print 1.2<true`, "", false, 0, true, `LT: invalid types: float, bool`},
+		{`97.4`, `print 1.2<"ab"`, "", false, 0, true, `LT: invalid types: float, string`},
+		{`97.5`, `print 1.2<nil`, "", false, 0, true, `LT: invalid types: float, nil`},
+		{`98.1`, `print true<1`, "", false, 0, true, `LT: invalid types: bool, int`},
+		{`98.2`, `print true<1.0`, "", false, 0, true, `LT: invalid types: bool, float`},
+		{`98.3`, `print true<true`, "", false, 0, true, `LT: invalid types: bool, bool`},
+		{`98.4`, `print true<"ab"`, "", false, 0, true, `LT: invalid types: bool, string`},
+		{`98.5`, `print true<nil`, "", false, 0, true, `LT: invalid types: bool, nil`},
+		{`99.1`, `print "ab"<2`, "", false, 0, true, `LT: invalid types: string, int`},
+		{`99.2`, `print "ab"<1.2`, "", false, 0, true, `LT: invalid types: string, float`},
+		{`99.3`, `print "ab"<true`, "", false, 0, true, `LT: invalid types: string, bool`},
+		{`99.4`, `print "ab"<"cd"`, "true", false, 0, false, ""},
+		{`99.5`, `print "ab"<nil`, "", false, 0, true, `LT: invalid types: string, nil`},
+		{`100.1`, `print nil<1`, "", false, 0, true, `LT: invalid types: nil, int`},
+		{`100.2`, `print nil<1.2`, "", false, 0, true, `LT: invalid types: nil, float`},
+		{`100.3`, `print nil<true`, "", false, 0, true, `LT: invalid types: nil, bool`},
+		{`100.4`, `print nil<"ab"`, "", false, 0, true, `LT: invalid types: nil, string`},
+		{`100.5`, `print nil<nil`, "", false, 0, true, `LT: invalid types: nil, nil`},
+		{`101.1`, `print 1>2`, "false", false, 0, false, ""},
+		{`101.2`, `print 1>2.0`, "false", false, 0, false, ""},
+		{`101.3`, `print 1>true`, "", false, 0, true, `GT: invalid types: int, bool`},
+		{`101.4`, `print 1>"ab"`, "", false, 0, true, `GT: invalid types: int, string`},
+		{`101.5`, `print 1>nil`, "", false, 0, true, `GT: invalid types: int, nil`},
+		{`102.1`, `print 1.0>2`, "false", false, 0, false, ""},
+		{`102.2`, `print 1.0>2.0`, "false", false, 0, false, ""},
+		{`102.3`, `print 1.2>true`, "", false, 0, true, `GT: invalid types: float, bool`},
+		{`102.4`, `print 1.2>"ab"`, "", false, 0, true, `GT: invalid types: float, string`},
+		{`102.5`, `print 1.2>nil`, "", false, 0, true, `GT: invalid types: float, nil`},
+		{`103.1`, `print true>1`, "", false, 0, true, `GT: invalid types: bool, int`},
+		{`103.2`, `print true>1.0`, "", false, 0, true, `GT: invalid types: bool, float`},
+		{`103.3`, `print true>true`, "", false, 0, true, `GT: invalid types: bool, bool`},
+		{`103.4`, `print true>"ab"`, "", false, 0, true, `GT: invalid types: bool, string`},
+		{`103.5`, `print true>nil`, "", false, 0, true, `GT: invalid types: bool, nil`},
+		{`104.1`, `print "ab">2`, "", false, 0, true, `GT: invalid types: string, int`},
+		{`104.2`, `print "ab">1.2`, "", false, 0, true, `GT: invalid types: string, float`},
+		{`104.3`, `print "ab">true`, "", false, 0, true, `GT: invalid types: string, bool`},
+		{`104.4`, `print "ab">"cd"`, "false", false, 0, false, ""},
+		{`104.5`, `print "ab">nil`, "", false, 0, true, `GT: invalid types: string, nil`},
+		{`105.1`, `print nil>1`, "", false, 0, true, `GT: invalid types: nil, int`},
+		{`105.2`, `print nil>1.2`, "", false, 0, true, `GT: invalid types: nil, float`},
+		{`105.3`, `print nil>true`, "", false, 0, true, `GT: invalid types: nil, bool`},
+		{`105.4`, `print nil>"ab"`, "", false, 0, true, `GT: invalid types: nil, string`},
+		{`105.5`, `print nil>nil`, "", false, 0, true, `GT: invalid types: nil, nil`},
+		{`106.1`, `print not 0`, "true", false, 0, false, ""},
+		{`106.2`, `print not 0.0`, "true", false, 0, false, ""},
+		{`106.3`, `print not false`, "true", false, 0, false, ""},
+		{`106.4`, `print not ""`, "true", false, 0, false, ""},
+		{`106.5`, `print not nil`, "true", false, 0, false, ""},
+		{`107.1`, `print 1 or 2`, "1", false, 0, false, ""},
+		{`107.2`, `print 1 or 2.0`, "1", false, 0, false, ""},
+		{`107.3`, `print 1 or true`, "1", false, 0, false, ""},
+		{`107.4`, `print 1 or "ab"`, "1", false, 0, false, ""},
+		{`107.5`, `print 1 or nil`, "1", false, 0, false, ""},
+		{`108.1`, `print 1.2 or 2`, "1.2", false, 0, false, ""},
+		{`108.2`, `print 1.2 or 2.0`, "1.2", false, 0, false, ""},
+		{`108.3`, `print 1.2 or true`, "1.2", false, 0, false, ""},
+		{`108.4`, `print 1.2 or "ab"`, "1.2", false, 0, false, ""},
+		{`108.5`, `print 1.2 or nil`, "1.2", false, 0, false, ""},
+		{`109.1`, `print true or 1`, "true", false, 0, false, ""},
+		{`109.2`, `print true or 1.0`, "true", false, 0, false, ""},
+		{`109.3`, `print true or true`, "true", false, 0, false, ""},
+		{`109.4`, `print true or "ab"`, "true", false, 0, false, ""},
+		{`109.5`, `print true or nil`, "true", false, 0, false, ""},
+		{`110.1`, `print "ab" or 2`, "ab", false, 0, false, ""},
+		{`110.2`, `print "ab" or 1.2`, "ab", false, 0, false, ""},
+		{`110.3`, `print "ab" or true`, "ab", false, 0, false, ""},
+		{`110.4`, `print "ab" or "cd"`, "ab", false, 0, false, ""},
+		{`110.5`, `print "ab" or nil`, "ab", false, 0, false, ""},
+		{`112.1`, `print nil or 1`, "1", false, 0, false, ""},
+		{`112.2`, `print nil or 1.2`, "1.2", false, 0, false, ""},
+		{`112.3`, `print nil or true`, "true", false, 0, false, ""},
+		{`112.4`, `print nil or "ab"`, "ab", false, 0, false, ""},
+		{`112.5`, `print nil or nil`, "<nil>", false, 0, false, ""},
+		{`113.1`, `print 1 and 2`, "2", false, 0, false, ""},
+		{`113.2`, `print 1 and 2.5`, "2.5", false, 0, false, ""},
+		{`113.3`, `print 1 and true`, "true", false, 0, false, ""},
+		{`113.4`, `print 1 and "ab"`, "ab", false, 0, false, ""},
+		{`113.5`, `print 1 and nil`, "<nil>", false, 0, false, ""},
+		{`114.1`, `print 1.2 and 2`, "2", false, 0, false, ""},
+		{`114.2`, `print 1.2 and 2.5`, "2.5", false, 0, false, ""},
+		{`114.3`, `print 1.2 and true`, "true", false, 0, false, ""},
+		{`114.4`, `print 1.2 and "ab"`, "ab", false, 0, false, ""},
+		{`114.5`, `print 1.2 and nil`, "<nil>", false, 0, false, ""},
+		{`115.1`, `print true and 2`, "2", false, 0, false, ""},
+		{`115.2`, `print true and 2.5`, "2.5", false, 0, false, ""},
+		{`115.3`, `print true and true`, "true", false, 0, false, ""},
+		{`115.4`, `print true and "ab"`, "ab", false, 0, false, ""},
+		{`115.5`, `print true and nil`, "<nil>", false, 0, false, ""},
+		{`116.1`, `print "ab" and 2`, "2", false, 0, false, ""},
+		{`116.2`, `print "ab" and 2.5`, "2.5", false, 0, false, ""},
+		{`116.3`, `print "ab" and true`, "true", false, 0, false, ""},
+		{`116.4`, `print "ab" and "cd"`, "cd", false, 0, false, ""},
+		{`116.5`, `print "ab" and nil`, "<nil>", false, 0, false, ""},
+		{`117.1`, `print nil and 2`, "<nil>", false, 0, false, ""},
+		{`117.2`, `print nil and 2.5`, "<nil>", false, 0, false, ""},
+		{`117.3`, `print nil and true`, "<nil>", false, 0, false, ""},
+		{`117.4`, `print nil and "ab"`, "<nil>", false, 0, false, ""},
+		{`117.5`, `print nil and nil`, "<nil>", false, 0, false, ""},
+		{`120`, `def b{x}`, "", false, 0, true, `'x' not resolved as var or field`},
+		{`121.1`, `print 1/0`, "", false, 0, true, `division by int zero`},
+		{`121.2`, `print 1/0.0`, "+Inf", false, 0, false, ""},
+		{`122.1`, `print  2147483647-1`, "2147483646", false, 0, false, ""},
+		{`122.2`, `print -2147483647+1`, "-2147483646", false, 0, false, ""},
+		{`123.1`, `var a; print "foo"+(a=1); print a`, "foo1\n1", false, 0, false, ""},
+		{`123.2`, `var a; print 2+(a=1); print a`, "3\n1", false, 0, false, ""},
+		{`123.3`, `def b{print "foo"+(a=1); print a}`, "foo1\n1", false, 0, false, ""},
+		{`123.4`, `def b{var a; print "foo"+(a=1); print a}`, "foo1\n1", false, 0, false, ""},
+		{`124.1`, `eval 42q`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c42q%[1]c`, '`')},
+		{`124.2`, `eval 42"q"`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c42"%[1]c`, '`')},
+		{`124.3`, `eval 42.0q`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c42.0q%[1]c`, '`')},
+		{`124.4`, `eval 0x42q`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c0x42q%[1]c`, '`')},
+		{`124.5`, `eval 42.0"q"`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c42.0"%[1]c`, '`')},
+		{`124.6`, `eval 0x42"q"`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c0x42"%[1]c`, '`')},
+		{`124.7`, `eval var"q"`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]cvar"%[1]c`, '`')},
+		{`124.8`, `eval foo"q"`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]cfoo"%[1]c`, '`')},
+		{`124.9`, `eval "foo"1`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c"foo"1%[1]c`, '`')},
+		{`124.10`, `eval "foo"q`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c"foo"q%[1]c`, '`')},
+		{`125.1`, `def b {}; bind b`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  BIND 0x11     0 'b'\t   0#\n0008      |  RET", true, 0, false, ""},
+		{`125.2`, `def b {}; bind b:all`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:21  BIND 0x2F     0 'b'\t   0#\n0008      |  RET", true, 0, false, ""},
+		{`125.3`, `def b {}; def b{x=1}; bind b:first`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  DEFBLOCK      0 'b'\t   1 ''\n0007   1:20  ONE\n0008      |  SETFIELD      2 'x'\n0010      |  POP\n0011   1:21  ENDBLOCK\n0012   1:35  BIND 0x12     0 'b'\t   0#\n0016      |  RET", true, 0, false, ""},
+		{`125.4`, `def b {}; def b{x=1}; bind b:last`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  DEFBLOCK      0 'b'\t   1 ''\n0007   1:20  ONE\n0008      |  SETFIELD      2 'x'\n0010      |  POP\n0011   1:21  ENDBLOCK\n0012   1:34  BIND 0x13     0 'b'\t   0#\n0016      |  RET", true, 0, false, ""},
+		{`125.5`, `def b {}; def b{x=1}; bind b:all`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003    1:9  ENDBLOCK\n0004   1:17  DEFBLOCK      0 'b'\t   1 ''\n0007   1:20  ONE\n0008      |  SETFIELD      2 'x'\n0010      |  POP\n0011   1:21  ENDBLOCK\n0012   1:33  BIND 0x2F     0 'b'\t   0#\n0016      |  RET", true, 0, false, ""},
+		{`126.1`, `bind x`, "", false, 0, true, `no blocks of type x`},
+		{`126.2`, `def x{}; def x{}; bind x`, "", false, 0, true, `found 2 blocks of type x`},
+		{`126.3`, `def x{}; def x{}; bind x:1`, "", false, 0, true, `found 2 blocks of type x`},
+		{`126.4`, `def x{}; bind x:   `, "", false, 0, true, `expected block selector`},
+		{`126.5`, `def x{}; bind x:foo`, "", false, 0, true, `expected block selector`},
+		{`126.6`, `def x{}; bind x; bind x:all`, "", false, 0, false, ""},
+		{`127.1`, `def b "foo"{}; bind b:"foo"`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:28  BIND 0x14     0 'b'\t   1#\t   1 'foo'\n0009      |  RET", true, 0, false, ""},
+		{`127.2`, `def b "foo"{}; def b "bar"{}; bind b:"bar"`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:28  DEFBLOCK      0 'b'\t   2 'bar'\n0007   1:29  ENDBLOCK\n0008   1:43  BIND 0x14     0 'b'\t   1#\t   2 'bar'\n0013      |  RET", true, 0, false, ""},
+		{`128.1`, `bind x:"foo"`, "", false, 0, true, `no blocks of type x`},
+		{`128.2`, `def x{}; bind x:"foo"`, "", false, 0, true, `block x:"foo" not found`},
+		{`128.3`, `def x "a"{}; bind x:"foo"`, "", false, 0, true, `block x:"foo" not found`},
+		{`128.4`, `def x "a"{}; bind x:"foo",`, "", false, 0, true, `block x:"foo" not found`},
+		{`128.5`, `def x "a"{}; def x "b"{}; bind x:"a"`, "", false, 0, false, ""},
+		{`128.6`, `def x "a"{}; def x "b"{}; bind x:"b"`, "", false, 0, false, ""},
+		{`128.7`, `def x "a"{}; bind x:"a",`, "", false, 0, false, ""},
+		{`128.8`, `def x "a"{}; def x "b"{}; bind x:"a",`, "", false, 0, false, ""},
+		{`128.9`, `def x "a"{}; def x "b"{}; bind x:"b",`, "", false, 0, false, ""},
+		{`129.1`, `def b "foo"{}; bind b:"foo",`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:29  BIND 0x25     0 'b'\t   1#\t   1 'foo'\n0009      |  RET", true, 0, false, ""},
+		{`129.2`, `def b "foo"{}; def b "bar"{}; bind b:"foo","bar"`, "== /dev/stdin ==\n0000   1:13  DEFBLOCK      0 'b'\t   1 'foo'\n0003   1:14  ENDBLOCK\n0004   1:28  DEFBLOCK      0 'b'\t   2 'bar'\n0007   1:29  ENDBLOCK\n0008   1:49  BIND 0x25     0 'b'\t   2#\t   1 'foo'\t   2 'bar'\n0014      |  RET", true, 0, false, ""},
+		{`130.1`, `def x "a"{}; bind x:"a",`, "", false, 0, false, ""},
+		{`130.2`, `def x "a"{}; def x "b"{}; bind x:"a","b"`, "", false, 0, false, ""},
+		{`130.3`, `def x "a"{}; def x "b"{}; bind x:"a","b",`, "", false, 0, false, ""},
+		{`130.4`, `def x "a"{}; bind x:"foo",`, "", false, 0, true, `block x:"foo" not found`},
+		{`130.5`, `def x "a"{}; def x "b"{}; bind x:"c",`, "", false, 0, true, `block x:"c" not found`},
+		{`131.1`, `bind {}`, "== /dev/stdin ==\n0000    1:7  DEFUBIND\n0001    1:8  ENDUBIND\n0002      |  RET", true, 0, false, ""},
+		{`131.2`, `def x{}; bind {x}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFUBIND\n0005   1:17  BIND 0x11     0 'x'\t   0#\n0009   1:18  ENDUBIND\n0010      |  RET", true, 0, false, ""},
+		{`131.3`, `def x{}; def y{}; bind {x; y:all}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:26  BIND 0x11     0 'x'\t   0#\n0013   1:33  BIND 0x2F     2 'y'\t   0#\n0017   1:34  ENDUBIND\n0018      |  RET", true, 0, false, ""},
+		{`131.4`, `def x{}; def y{}; bind {y; x:all}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:26  BIND 0x11     2 'y'\t   0#\n0013   1:33  BIND 0x2F     0 'x'\t   0#\n0017   1:34  ENDUBIND\n0018      |  RET", true, 0, false, ""},
+		{`131.5`, `def x{}; def y{}; bind {x:first; y:last}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:32  BIND 0x12     0 'x'\t   0#\n0013   1:40  BIND 0x13     2 'y'\t   0#\n0017   1:41  ENDUBIND\n0018      |  RET", true, 0, false, ""},
+		{`131.6`, `def x{}; def y{}; bind {x:last; y:first}`, "== /dev/stdin ==\n0000    1:7  DEFBLOCK      0 'x'\t   1 ''\n0003    1:8  ENDBLOCK\n0004   1:16  DEFBLOCK      2 'y'\t   1 ''\n0007   1:17  ENDBLOCK\n0008   1:25  DEFUBIND\n0009   1:31  BIND 0x13     0 'x'\t   0#\n0013   1:40  BIND 0x12     2 'y'\t   0#\n0017   1:41  ENDUBIND\n0018      |  RET", true, 0, false, ""},
+		{`131.7`, `def x "a"{}; bind {x:"a"}`, "== /dev/stdin ==\n0000   1:11  DEFBLOCK      0 'x'\t   1 'a'\n0003   1:12  ENDBLOCK\n0004   1:20  DEFUBIND\n0005   1:25  BIND 0x14     0 'x'\t   1#\t   1 'a'\n0010   1:26  ENDUBIND\n0011      |  RET", true, 0, false, ""},
+		{`131.8`, `def x "a"{}; bind {x:"a",}`, "== /dev/stdin ==\n0000   1:11  DEFBLOCK      0 'x'\t   1 'a'\n0003   1:12  ENDBLOCK\n0004   1:20  DEFUBIND\n0005   1:26  BIND 0x25     0 'x'\t   1#\t   1 'a'\n0010   1:27  ENDUBIND\n0011      |  RET", true, 0, false, ""},
+		{`132.1`, `bind {}`, "", false, 0, false, ""},
+		{`132.2`, `def x{}; bind {x}`, "", false, 0, false, ""},
+		{`132.3`, `def x{}; bind {x:all}`, "", false, 0, false, ""},
+		{`132.4`, `def x{}; def y{}; bind {x; y}`, "", false, 0, false, ""},
+		{`132.5`, `def x{}; def y{}; bind {x; y:all}`, "", false, 0, false, ""},
+		{`132.6`, `def x{}; def y{}; bind {x:all; y}`, "", false, 0, false, ""},
+		{`133.1`, `bind {42x}`, "", false, 0, true, `invalid syntax`},
+		{`133.2`, `bind {x}`, "", false, 0, true, `no blocks of type x`},
+		{`133.3`, `def x{}; bind {x:"a"}`, "", false, 0, true, `block x:"a" not found`},
+		{`133.4`, `def x{}; bind {x:"a",}`, "", false, 0, true, `block x:"a" not found`},
+		{`133.5`, `bind{bind}`, "", false, 0, true, `expected block type`},
+		{`133.6`, `bind{bind{}}`, "", false, 0, true, `expected block type`},
+		{`134.1`, `def t "p" {x=1}; print t.p.x`, "1", false, 0, false, ""},
+		{`134.2`, `def t "p" {x=1}; print t."p".x`, "1", false, 0, false, ""},
+		{`134.3`, `def t {x=1}; print t.x`, "1", false, 0, false, ""},
+		{`134.4`, `def t "p-1" {x=1}; def c {y=t."p-1".x+1; print y}`, "2", false, 0, false, ""},
+		{`134.5`, `def t {def u "v" {x=1}}; print t.u.v.x`, "1", false, 0, false, ""},
+		{`134.6`, `def t {def u "v" {x=1}}; print t.u."v".x`, "1", false, 0, false, ""},
+		{`134.7`, `def t {x=1}; def t {x=2}; print t.x`, "2", false, 0, false, ""},
+		{`134.8`, `def t "p" {x=1}; def t "q" {x=2}; print t.p.x+t.q.x`, "3", false, 0, false, ""},
+		{`134.9`, `def t "p" {x=1}; eval t.p.x`, "== /dev/stdin ==\n0000   1:12  DEFBLOCK      0 't'\t   1 'p'\n0003   1:15  ONE\n0004      |  SETFIELD      2 'x'\n0006      |  POP\n0007   1:16  ENDBLOCK\n0008   1:28  GETPATH       3#\t   0 't'\t   1 'p'\t   2 'x'\n0013      |  POP\n0014      |  RET", true, 0, false, ""},
+		{`135.1`, `print t.x`, "", false, 0, true, `path 't.x': no blocks of type t`},
+		{`135.2`, `def t {}; print t.x`, "", false, 0, true, `path 't.x' not resolved as block field`},
+		{`135.3`, `def t "p" {}; print t.q.x`, "", false, 0, true, `path 't.q.x' not resolved as block field`},
+		{`135.4`, `def t "p" {}; print t.p`, "", false, 0, true, `path 't.p' refers to a block`},
+		{`135.5`, `def t {x=1}; eval t.x=2`, "", false, 0, true, `at '=': invalid assignment target`},
+		{`135.6`, `def t {x=1}; print t.`, "", false, 0, true, `expected block name or field after '.'`},
+		{`135.7`, `def t {x=1}; print t.1`, "", false, 0, true, `at '1': expected block name or field`},
+		{`135.8`, `def t {print t.x}`, "", false, 0, true, `path 't.x': no blocks of type t`},
+		{`136.1`, `var x=1; def b {var.x=2; print x}; print x`, "2\n2", false, 0, false, ""},
+		{`136.2`, `var x=1; def b {field.x=2; print x}; print x`, "1\n1", false, 0, false, ""},
+		{`136.3`, `var x=1; def b {field.x=2; print field.x}`, "2", false, 0, false, ""},
+		{`136.4`, `var x=1; def b {x=2}; print x`, "2", false, 0, false, ""},
+		{`136.5`, `def b {var x=1; var.x=x+1; print var.x}`, "2", false, 0, false, ""},
+		{`136.6`, `var x; eval var.x=5; print var.x`, "5", false, 0, false, ""},
+		{`136.7`, `def b {x=1; def c {print field.x}}`, "1", false, 0, false, ""},
+		{`136.8`, `def b {field=1; print field}`, "1", false, 0, false, ""},
+		{`136.9`, `def b {print var.x}`, "", false, 0, true, `at 'x': undefined variable`},
+		{`136.10`, `print field.x`, "", false, 0, true, `at 'x': field reference outside of a block`},
+		{`136.11`, `var.x=1`, "", false, 0, true, `at 'var': expected statement`},
+		{`136.12`, `def b {var x; print var x}`, "", false, 0, true, `at 'x': expected '.' after 'var'`},
+		{`136.13`, `def b {field.1=1}`, "", false, 0, true, `at '1': expected field name after 'field.'`},
+		{`136.14`, `def b {x=1}; print field.x`, "", false, 0, true, `field reference outside of a block`},
+		{`136.15`, `def b {print field.x}`, "", false, 0, true, `'x' not resolved as var or field`},
+		{`137.1`, `for i in range(3) {print i}`, "0\n1\n2", false, 0, false, ""},
+		{`137.2`, `for i in range(1, 3) {print i}`, "1\n2", false, 0, false, ""},
+		{`137.3`, `for i in range(3, 1) {print i}`, "", false, 0, false, ""},
+		{`137.4`, `for i in range(0) {print i}`, "", false, 0, false, ""},
+		{`137.5`, `var n=2; for i in range(n) {for j in range(i, n) {print i*10+j}}`, "0\n1\n11", false, 0, false, ""},
+		{`137.6`, `for i in range(3) {eval i=10; print i}`, "10\n10\n10", false, 0, false, ""},
+		{`137.7`, `for i in range(2) {var x=i+1; print x}`, "1\n2", false, 0, false, ""},
+		{`137.8`, `def b {for i in range(3) {f=i}; print f}`, "2", false, 0, false, ""},
+		{`137.9`, `for i in range(2) {def w "w"+i {p=9000+i}}; print w.w0.p+w.w1.p`, "18001", false, 0, false, ""},
+		{`137.10`, `var x="a"; for i in range(2) {eval x=x+i}; print x`, "a01", false, 0, false, ""},
+		{`137.11`, `for i in range(2) {eval i}`, "== /dev/stdin ==\n0000   1:17  CONST         0 '2'\n0002      |  ZERO\n0003   1:20  FORRANGE      1    0   16 -> 0024\n0008      |  GETLOCAL      1\n0010   1:26  GETLOCAL      2\n0012      |  POP\n0013   1:27  POP\n0014      |  GETLOCAL      1\n0016      |  ONE\n0017      |  ADD\n0018      |  SETLOCAL      1\n0020      |  POP\n0021      |  LOOP         21 -> 0003\n0024      |  POPN          2\n0026      |  RET", true, 0, false, ""},
+		{`137.12`, `for x in [1, "a", 2.5] {print x}`, "1\na\n2.5", false, 0, false, ""},
+		{`137.13`, `for x in [] {print x}; for x in nil {print x}`, "", false, 0, false, ""},
+		{`137.14`, `var m = {b: 2, a: 1}; for v in values(m) {print v}; for k in keys(m) {print k}`, "1\n2\na\nb", false, 0, false, ""},
+		{`137.15`, `for x in [[1, 2], [3]] {for y in x {print y}}`, "1\n2\n3", false, 0, false, ""},
+		{`137.16`, `var xs = [1, 2]; for x in xs {eval x = x * 10; print x}; print xs`, "10\n20\n[1, 2]", false, 0, false, ""},
+		{`137.17`, `def b {for x in split("a b", " ") {f = x}; print f}`, "b", false, 0, false, ""},
+		{`137.19`, `for i in range(0, 10, 4) {print i}; for i in range(3, 0, -2) {print i}`, "0\n4\n8\n3\n1", false, 0, false, ""},
+		{`137.20`, `for i in range(0, 3, -1) {print i}; for i in range(3, 0, 1) {print i}`, "", false, 0, false, ""},
+		{`137.21`, `var range = [7]; for i in range {print i}; for i in range(1) {print i}`, "7\n0", false, 0, false, ""},
+		{`137.18`, `for x in [1, 2] {eval x}`, "== /dev/stdin ==\n0000   1:12  ONE\n0001   1:15  CONST         0 '2'\n0003   1:16  LIST          2\n0005      |  ZERO\n0006   1:18  FORLIST       1    0   14 -> 0025\n0011   1:24  GETLOCAL      2\n0013      |  POP\n0014   1:25  POP\n0015      |  GETLOCAL      1\n0017      |  ONE\n0018      |  ADD\n0019      |  SETLOCAL      1\n0021      |  POP\n0022      |  LOOP         19 -> 0006\n0025      |  POPN          2\n0027      |  RET", true, 0, false, ""},
+		{`138.1`, `for i in range("a", "b") {}`, "", false, 0, true, `range: invalid types: string, string, expected int`},
+		{`138.2`, `for i in range(1.5) {}`, "", false, 0, true, `range: invalid types: int, float, expected int`},
+		{`138.3`, `for i in range(nil, 2) {}`, "", false, 0, true, `range: invalid types: nil, int, expected int`},
+		{`138.3.1`, `for i in range(0, 2, 0.5) {}`, "", false, 0, true, `range: invalid types: int, int, float, expected int`},
+		{`138.3.2`, `for i in range(0, 2, 0) {}`, "", false, 0, true, `range: zero step`},
+		{`138.3.3`, `for i in range(0, 2, 1, 1) {}`, "", false, 0, true, `expected ')' after range arguments`},
+		{`138.4`, `for i in rang(3) {}`, "", false, 0, true, `at 'rang': unknown function 'rang'`},
+		{`138.5`, `for i range(3) {}`, "", false, 0, true, `at 'range': expected 'in'`},
+		{`138.6`, `for 1 in range(3) {}`, "", false, 0, true, `at '1': expected loop variable name`},
+		{`138.7`, `for i in range(3 {}`, "", false, 0, true, `expected ')' after range arguments`},
+		{`138.8`, `for i in range(3) print i`, "", false, 0, true, `at 'print': expected '{'`},
+		{`138.9`, `for i in range(3) {x=1}`, "", false, 0, true, `at 'x': expected statement`},
+		{`138.10`, `for i in range(3) {print i`, "", false, 0, true, `at end: expected '}'`},
+		{`138.11`, `for x in "abc" {}`, "", false, 0, true, `for: cannot iterate over string`},
+		{`138.12`, `for x in {a: 1} {}`, "", false, 0, true, `for: cannot iterate over map`},
+		{`138.13`, `for x in 3 {}`, "", false, 0, true, `for: cannot iterate over int`},
+		{`139.1`, `def b "x"+1 {print NAME}`, "x1", false, 0, false, ""},
+		{`139.2`, `var n="q"; def b n {print NAME}`, "q", false, 0, false, ""},
+		{`139.3`, `var n="q"; def b (n+n) {print TYPE+NAME}`, "bqq", false, 0, false, ""},
+		{`139.4`, `def b "x"+1 {}; print b.x1.TYPE`, "", false, 0, true, `path 'b.x1.TYPE' not resolved`},
+		{`139.5`, `def b nil {}`, "", false, 0, true, `block name: invalid type: nil, expected string`},
+		{`139.6`, `def b {for i in range(2) {def c "x" {}}}`, "", false, 0, true, `child c.x duplicate at parent`},
+		{`139.7`, `var n="q"; def b n {}`, "== /dev/stdin ==\n0000   1:10  CONST         0 'q'\n0002   1:19  GETLOCAL      0\n0004   1:21  DYNBLOCK      1 'b'\n0006   1:22  ENDBLOCK\n0007      |  POP\n0008      |  RET", true, 0, false, ""},
+		{`140.1`, `if true {print 1}`, "1", false, 0, false, ""},
+		{`140.2`, `if false {print 1}`, "", false, 0, false, ""},
+		{`140.3`, `if 0 {print 1} else {print 2}`, "2", false, 0, false, ""},
+		{`140.4`, `var x=2; if x==0 {print 0} else if x==1 {print 1} else {print 2}`, "2", false, 0, false, ""},
+		{`140.5`, `var x=1; if x==0 {print 0} else if x==1 {print 1}`, "1", false, 0, false, ""},
+		{`140.6`, `if true {var y=1; print y}; var y=2; print y`, "1\n2", false, 0, false, ""},
+		{`140.7`, `def b {if true {p=0} else {p=1}; print p}`, "0", false, 0, false, ""},
+		{`140.8`, `if true {def b "x" {}}; print b.x.TYPE`, "", false, 0, true, `not resolved as block field`},
+		{`140.9`, `for i in range(4) {if i>1 {print i}}`, "2\n3", false, 0, false, ""},
+		{`140.10`, `if true {eval 1} else {eval 2}`, "== /dev/stdin ==\n0000    1:8  TRUE\n0001   1:10  JFALSE        6 -> 0010\n0004      |  POP\n0005   1:16  ONE\n0006      |  POP\n0007   1:17  JUMP          4 -> 0014\n0010      |  POP\n0011   1:30  CONST         0 '2'\n0013      |  POP\n0014   1:31  RET", true, 0, false, ""},
+		{`141.1`, `print if true then 0 else 5`, "0", false, 0, false, ""},
+		{`141.2`, `print if false then 0 else ""`, "", false, 0, false, ""},
+		{`141.3`, `print if nil then 1 else 2+3`, "5", false, 0, false, ""},
+		{`141.4`, `print 1 + if 1>2 then 10 else 20`, "21", false, 0, false, ""},
+		{`141.5`, `var p=0; print if p==0 then "" else "x"`, "", false, 0, false, ""},
+		{`141.6`, `def b {q = if true then false else true; print q}`, "false", false, 0, false, ""},
+		{`141.7`, `print if true then if false then 1 else 2 else 3`, "2", false, 0, false, ""},
+		{`141.8`, `eval if true then 1 else 2`, "== /dev/stdin ==\n0000   1:13  TRUE\n0001   1:18  JFALSE        5 -> 0009\n0004      |  POP\n0005   1:20  ONE\n0006      |  JUMP          3 -> 0012\n0009      |  POP\n0010   1:27  CONST         0 '2'\n0012      |  POP\n0013      |  RET", true, 0, false, ""},
+		{`142.1`, `print if true then 1`, "", false, 0, true, `at end: expected 'else' in conditional expression`},
+		{`142.2`, `print if true 1 else 2`, "", false, 0, true, `at '1': expected 'then' after condition`},
+		{`142.3`, `if true print 1`, "", false, 0, true, `at 'print': expected '{' after condition`},
+		{`142.4`, `if true {} else print 2`, "", false, 0, true, `at 'print': expected '{' or 'if' after 'else'`},
+		{`142.5`, `if true {print 1`, "", false, 0, true, `at end: expected '}'`},
+		{`142.6`, `else {}`, "", false, 0, true, `at 'else': expected statement`},
+		{`143.1`, `var h="h"; var d="d.com"; var p=80; print "${h}.${d}:${p}"`, "h.d.com:80", false, 0, false, ""},
+		{`143.2`, `print "a${1+2}b"`, "a3b", false, 0, false, ""},
+		{`143.3`, `print "${1}"`, "1", false, 0, false, ""},
+		{`143.4`, `print "${1.5}${true}${nil}${"s"}"`, "1.5trues", false, 0, false, ""},
+		{`143.5`, `print "${"x${1}y"}"`, "x1y", false, 0, false, ""},
+		{`143.6`, `print "\${x} $$ $"`, "${x} $$ $", false, 0, false, ""},
+		{`143.7`, `print "${1}" + "!"`, "1!", false, 0, false, ""},
+		{`143.8`, `def b "w${2}" {print NAME}`, "w2", false, 0, false, ""},
+		{`143.9`, `print "\t${1}\n"`, "\t1", false, 0, false, ""},
+		{`143.10`, `def t "p" {x=1}; print "x=${t.p.x}"`, "x=1", false, 0, false, ""},
+		{`143.11`, `print "a${1}b${2}"`, "== /dev/stdin ==\n0000   1:11  CONST         0 'a'\n0002   1:12  ONE\n0003      |  TOSTR\n0004      |  ADD\n0005   1:16  CONST         1 'b'\n0007      |  ADD\n0008   1:17  CONST         2 '2'\n0010      |  TOSTR\n0011      |  ADD\n0012   1:19  PRINT\n0013      |  RET\na1b2", true, 0, false, ""},
+		{`143.12`, `print "$${1}"`, "$1", false, 0, false, ""},
+		{`143.13`, `print "\\${1}"`, "\\1", false, 0, false, ""},
+		{`143.14`, `print "\\\${x}"`, "\\${x}", false, 0, false, ""},
+		{`143.15`, `print "a $${b}"`, "", false, 0, true, `at 'b': undefined variable`},
+		{`144.1`, `print "${x}"`, "", false, 0, true, `at 'x': undefined variable`},
+		{`144.2`, `print "a${1"`, "", false, 0, true, `invalid syntax`},
+		{`144.3`, `print "a${1}`, "", false, 0, true, `unterminated quoted string`},
+		{`144.4`, `print "a${1 2}"`, "", false, 0, true, `at '2': expected '}' ending the string interpolation`},
+		{`144.5`, `print "${}"`, "", false, 0, true, `expected expression`},
+		{`144.6`, `print "${1+true}"`, "", false, 0, true, `line 1:16: ADD: invalid types: int, bool`},
+		{`144.7`, `def b {print "${(x=1)+true}"}`, "", false, 0, true, `ADD: invalid types: int, bool`},
+		{`144.8`, `print "\q"`, "", false, 0, true, `invalid string literal`},
+		{`145.1`, fmt.Sprintf(`print %[1]ca\n${x}%[1]c`, '`'), "a\\n${x}", false, 0, false, ""},
+		{`145.2`, fmt.Sprintf(`print %[1]ca
+b%[1]c + "c"`, '`'), "a\nbc", false, 0, false, ""},
+		{`145.3`, fmt.Sprintf(`print %[1]c\${x}%[1]c`, '`'), "\\${x}", false, 0, false, ""},
+		{`145.4`, `print <<EOF
+foo
+  bar
+EOF
+`, "foo\n  bar", false, 0, false, ""},
+		{`145.5`, `print <<-EOF
+	foo
+	  bar
+
+	EOF
+`, "foo\n  bar", false, 0, false, ""},
+		{`145.6`, `print <<-EOF
+    foo
+  bar
+  EOF`, "  foo\nbar", false, 0, false, ""},
+		{`145.7`, `print <<EOF
+EOF
+print 1`, "\n1", false, 0, false, ""},
+		{`145.8`, fmt.Sprintf(`print <<EOF
+"${x}" \n %[1]c
+EOF
+`, '`'), "\"${x}\" \\n `", false, 0, false, ""},
+		{`145.9`, fmt.Sprintf(`def b %[1]cx%[1]c {y = <<E
+1
+E
+}; print b.x.y`, '`'), "1", false, 0, false, ""},
+		{`145.10`, fmt.Sprintf(`print <<EOF
+a
+EOF
+ + %[1]cb
+%[1]c`, '`'), "a\nb", false, 0, false, ""},
+		{`146.1`, fmt.Sprintf(`print %[1]ca`, '`'), "", false, 0, true, `unterminated raw string`},
+		{`146.2`, `print <<EOF
+a
+`, "", false, 0, true, `unterminated heredoc, expected EOF`},
+		{`146.3`, `print <<EOF a
+EOF`, "", false, 0, true, `expected end of line after heredoc identifier`},
+		{`146.4`, `print << EOF
+EOF`, "", false, 0, true, fmt.Sprintf(`expected heredoc identifier after %[1]c<<%[1]c`, '`')},
+		{`146.5`, `print <<E
+
+E
++1+true`, "", false, 0, true, `line 4:8: ADD: invalid types: string, bool`},
+		{`146.6`, fmt.Sprintf(`print %[1]c
+
+%[1]c+1+true`, '`'), "", false, 0, true, `line 3:9: ADD: invalid types: string, bool`},
+		{`147.1`, `print 30s`, "30s", false, 0, false, ""},
+		{`147.2`, `print 1h30m + 15s`, "1h30m15s", false, 0, false, ""},
+		{`147.3`, `print 1.5h - 1m`, "1h29m0s", false, 0, false, ""},
+		{`147.4`, `print 100ms + 10us + 10µs + 1ns`, "100.020001ms", false, 0, false, ""},
+		{`147.5`, `print 2 * 5m; print 5m * 2; print 1h / 4; print 1h * 0.5`, "10m0s\n10m0s\n15m0s\n30m0s", false, 0, false, ""},
+		{`147.6`, `print -5s; print +5s`, "-5s\n5s", false, 0, false, ""},
+		{`147.7`, `print 1s < 2s; print 1s > 2s; print 1s == 1000ms; print 1s == 1`, "true\nfalse\ntrue\nfalse", false, 0, false, ""},
+		{`147.8`, `print "ttl=" + 30s; print "${1m}"`, "ttl=30s\n1m0s", false, 0, false, ""},
+		{`147.9`, `print 0s or 5s`, "5s", false, 0, false, ""},
+		{`147.10`, `print 2024-01-02T15:04:05Z`, "2024-01-02T15:04:05Z", false, 0, false, ""},
+		{`147.11`, `print 2024-01-02T15:04:05.25+02:00`, "2024-01-02T15:04:05.25+02:00", false, 0, false, ""},
+		{`147.12`, `var t = 2024-01-02T15:04:05Z; print t + 1h; print 1h + t; print t - 30m`, "2024-01-02T16:04:05Z\n2024-01-02T16:04:05Z\n2024-01-02T14:34:05Z", false, 0, false, ""},
+		{`147.13`, `print 2024-01-02T00:00:00Z - 2024-01-01T00:00:00+02:00`, "26h0m0s", false, 0, false, ""},
+		{`147.14`, `print 2024-01-02T15:04:05Z == 2024-01-02T16:04:05+01:00`, "true", false, 0, false, ""},
+		{`147.15`, `print 2024-01-02T15:04:05Z < 2024-01-02T15:04:06Z`, "true", false, 0, false, ""},
+		{`147.16`, `print "at ${2024-01-02T15:04:05Z}"`, "at 2024-01-02T15:04:05Z", false, 0, false, ""},
+		{`147.17`, `print 2024-1`, "2023", false, 0, false, ""},
+		{`147.18`, `print not 1s; print not 0s`, "false\ntrue", false, 0, false, ""},
+		{`147.19`, `print 1h30m`, "== /dev/stdin ==\n0000   1:12  CONST         0 '1h30m0s'\n0002      |  PRINT\n0003      |  RET\n1h30m0s", true, 0, false, ""},
+		{`148.1`, `print 5x`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c5x%[1]c`, '`')},
+		{`148.2`, `print 1e3s`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c1e3s%[1]c`, '`')},
+		{`148.3`, `print 2024-13-01T00:00:00Z`, "", false, 0, true, fmt.Sprintf(`invalid time %[1]c2024-13-01T00:00:00Z%[1]c`, '`')},
+		{`148.4`, `print 1s + 1`, "", false, 0, true, `ADD: invalid types: duration, int`},
+		{`148.5`, `print 1 - 1s`, "", false, 0, true, `SUB: invalid types: int, duration`},
+		{`148.6`, `print 1s / 0`, "", false, 0, true, `division by int zero`},
+		{`148.7`, `print 1s / 0.0`, "", false, 0, true, `division by float zero`},
+		{`148.8`, `print 2024-01-02T15:04:05Z + 2024-01-02T15:04:05Z`, "", false, 0, true, `ADD: invalid types: time, time`},
+		{`148.9`, `print 1s < "x"`, "", false, 0, true, `LT: invalid types: duration, string`},
+		{`148.10`, `print 30s + "x"`, "", false, 0, true, `ADD: invalid types: duration, string`},
+		{`149.1`, `print 12.50d`, "12.50", false, 0, false, ""},
+		{`149.2`, `print 12.50d + 1; print 1 + 12.50d`, "13.50\n13.50", false, 0, false, ""},
+		{`149.3`, `print 0.1d + 0.2d; print 0.1d + 0.2d == 0.3d`, "0.3\ntrue", false, 0, false, ""},
+		{`149.4`, `print 1.10d * 1.10d; print 1.10d - 0.10d`, "1.2100\n1.00", false, 0, false, ""},
+		{`149.5`, `print 10.00d / 4; print 100d / 8; print 1d / 3`, "2.50\n12.5\n0.3333333333333333", false, 0, false, ""},
+		{`149.6`, `print -12.50d; print +1d`, "-12.50\n1", false, 0, false, ""},
+		{`149.7`, `print 1.5d == 1.5; print 0.1d == 0.1; print 0.1d < 0.2; print 2d > 1`, "true\nfalse\ntrue\ntrue", false, 0, false, ""},
+		{`149.8`, `print 1d == "1"; print 1d != nil`, "false\ntrue", false, 0, false, ""},
+		{`149.9`, `print "total: " + 12.50d; print "${0.01d * 3}"`, "total: 12.50\n0.03", false, 0, false, ""},
+		{`149.10`, `print 0.00d or 5d; print not 0d`, "5\ntrue", false, 0, false, ""},
+		{`149.11`, `var x = 0d; for i in range(10) { eval x = x + 0.1d }; print x`, "1.0", false, 0, false, ""},
+		{`149.12`, `print 12.50d`, "== /dev/stdin ==\n0000   1:13  CONST         0 '12.50'\n0002      |  PRINT\n0003      |  RET\n12.50", true, 0, false, ""},
+		{`150.1`, `print 1dx`, "", false, 0, true, fmt.Sprintf(`invalid syntax %[1]c1dx%[1]c`, '`')},
+		{`150.2`, `print 1d + 1.5`, "", false, 0, true, `ADD: invalid types: decimal, float`},
+		{`150.3`, `print 1.5 * 1d`, "", false, 0, true, `MUL: invalid types: float, decimal`},
+		{`150.4`, `print 1.5d / 0`, "", false, 0, true, `division by int zero`},
+		{`150.5`, `print 1.5d / 0.00d`, "", false, 0, true, `division by decimal zero`},
+		{`150.6`, `print 1d + "x"`, "", false, 0, true, `ADD: invalid types: decimal, string`},
+		{`150.7`, `print 1d < "x"`, "", false, 0, true, `LT: invalid types: decimal, string`},
+		{`150.8`, `print 1s * 2d`, "", false, 0, true, `MUL: invalid types: duration, decimal`},
+		{`151.1`, `print "8080" @int + 1`, "8081", false, 0, false, ""},
+		{`151.2`, `print 1 + "x" @int`, "", false, 0, true, `@int: invalid syntax: "x"`},
+		{`151.3`, `print 42 @str + "!"; print (1 + 2) @str + "!"`, "42!\n3!", false, 0, false, ""},
+		{`151.4`, `print 3.9 @int; print -3.9 @int; print (-3.9) @int`, "3\n-3\n-3", false, 0, false, ""},
+		{`151.5`, `print 12.75d @int; print -12.75d @int`, "12\n-12", false, 0, false, ""},
+		{`151.6`, `print "010" @int; print "-07" @int; print " 42 " @int`, "10\n-7\n42", false, 0, false, ""},
+		{`151.7`, `print true @int; print false @float; print 1s @int`, "1\n0\n1", false, 0, false, ""},
+		{`151.8`, `print "1.5" @float * 2; print 1 @float / 2; print 12.25d @float`, "3\n0.5\n12.25", false, 0, false, ""},
+		{`151.9`, `print 0.1 @decimal + 0.2d; print "12.50" @decimal; print 3 @decimal / 4`, "0.3\n12.50\n0.75", false, 0, false, ""},
+		{`151.10`, `print 1 @bool; print 0 @bool; print nil @bool; print 0.0 @bool`, "true\nfalse\nfalse\nfalse", false, 0, false, ""},
+		{`151.11`, `print "true" @bool; print "0" @bool; print "" @bool`, "true\nfalse\nfalse", false, 0, false, ""},
+		{`151.12`, `print nil @str + "x"; print 1.5 @str; print 1s @str`, "x\n1.5\n1s", false, 0, false, ""},
+		{`151.13`, `print "90s" @duration; print 1000 @duration`, "1m30s\n16m40s", false, 0, false, ""},
+		{`151.13.1`, `print 5 @duration; print 1500ms @int; print -1500ms @int`, "5s\n1\n-1", false, 0, false, ""},
+		{`151.14`, `print "2024-01-02T15:04:05Z" @time + 1h`, "2024-01-02T16:04:05Z", false, 0, false, ""},
+		{`151.15`, `print 2024-01-02T15:04:05Z @str + "!"`, "2024-01-02T15:04:05Z!", false, 0, false, ""},
+		{`151.16`, `print "42" @int`, "== /dev/stdin ==\n0000   1:11  CONST         0 '42'\n0002   1:16  CONV          1 @int\n0004      |  PRINT\n0005      |  RET\n42", true, 0, false, ""},
+		{`151.17`, `print 30s @float; print 1500ms @float; print 1.5 @duration; print -0.25 @duration`, "30\n1.5\n1.5s\n-250ms", false, 0, false, ""},
+		{`152.1`, `print "abc" @int`, "", false, 0, true, `line 1:17: @int: invalid syntax: "abc"`},
+		{`152.2`, `print "1.5" @int`, "", false, 0, true, `@int: invalid syntax: "1.5"`},
+		{`152.3`, `print 1e300 @int`, "", false, 0, true, `@int: out of range: 1e+300`},
+		{`152.4`, `print nil @int`, "", false, 0, true, `@int: invalid type: nil`},
+		{`152.5`, `print "x" @float`, "", false, 0, true, `@float: invalid syntax: "x"`},
+		{`152.6`, `print 1e300 @duration`, "", false, 0, true, `@duration: out of range: 1e+300`},
+		{`152.6.1`, `print (1<<40) @duration`, "", false, 0, true, `@duration: out of range: 1099511627776`},
+		{`152.7`, `print "x" @bool`, "", false, 0, true, `@bool: invalid syntax: "x"`},
+		{`152.8`, `print "1e5" @decimal`, "", false, 0, true, `@decimal: invalid syntax: "1e5"`},
+		{`152.9`, `print true @decimal`, "", false, 0, true, `@decimal: invalid type: bool`},
+		{`152.10`, `print "5 min" @duration`, "", false, 0, true, `@duration: invalid syntax: "5 min"`},
+		{`152.11`, `print "1_000" @int`, "", false, 0, true, `@int: invalid syntax: "1_000"`},
+		{`152.12`, `print "2024-01-02" @time`, "", false, 0, true, `@time: invalid syntax: "2024-01-02"`},
+		{`152.13`, `print 1 @time`, "", false, 0, true, `@time: invalid type: int`},
+		{`152.14`, `print 1 @foo`, "", false, 0, true, `at 'foo': unknown type for the conversion`},
+		{`152.15`, `print 1 @`, "", false, 0, true, `at end: expected type name after '@'`},
+		{`152.16`, `print 1 @1`, "", false, 0, true, `at '1': expected type name after '@'`},
+		{`152.17`, `print "0x10" @int`, "", false, 0, true, `@int: invalid syntax: "0x10"`},
+		{`152.18`, `print "NaN" @float @duration`, "", false, 0, true, `@duration: out of range: NaN`},
+		{`153.1`, `print 7 % 3; print -7 % 3; print 7 % -3`, "1\n2\n-2", false, 0, false, ""},
+		{`153.2`, `print 7 // 2; print -7 // 2; print 7 // -2`, "3\n-4\n-4", false, 0, false, ""},
+		{`153.3`, `print 7.5 // 2; print 7.5 % 2; print -7.5 % 2; print 7 % 2.5`, "3\n1.5\n0.5\n2", false, 0, false, ""},
+		{`153.4`, `print 2 ** 10; print 2 ** -1; print 2.0 ** 0.5 > 1.41`, "1024\n0.5\ntrue", false, 0, false, ""},
+		{`153.5`, `print -2 ** 2; print (-2) ** 2; print 2 ** 3 ** 2; print 2 ** -2 ** 2`, "-4\n4\n512\n0.0625", false, 0, false, ""},
+		{`153.6`, `print 1 << 20; print 1024 >> 3; print -16 >> 2`, "1048576\n128\n-4", false, 0, false, ""},
+		{`153.7`, `print 12 & 10; print 12 | 3; print 12 ^ 10`, "8\n15\n6", false, 0, false, ""},
+		{`153.8`, `print 1 + 2 << 3; print 1 | 2 == 3; print 6 & 3 + 1; print 1 | 6 ^ 3 & 5`, "24\ntrue\n4\n7", false, 0, false, ""},
+		{`153.9`, `print 10 % 4 * 3; print 2 * 3 ** 2; print 17 // 5 % 2`, "6\n18\n1", false, 0, false, ""},
+		{`153.10`, `var x = 5; print x<<2; print x <<1`, "20\n10", false, 0, false, ""},
+		{`153.11`, `print 7.5d % 2; print -7.5d // 2; print 1.1d ** 3; print 2d ** -2`, "1.5\n-4\n1.331\n0.25", false, 0, false, ""},
+		{`153.12`, `var shard = 12345 % 16; print shard`, "9", false, 0, false, ""},
+		{`153.13`, `print 2 ** 3 % 3`, "== /dev/stdin ==\n0000    1:8  CONST         0 '2'\n0002   1:13  CONST         1 '3'\n0004      |  POW\n0005   1:17  CONST         2 '3'\n0007      |  MOD\n0008      |  PRINT\n0009      |  RET\n2", true, 0, false, ""},
+		{`154.1`, `print 1 % 0`, "", false, 0, true, `line 1:12: modulo by int zero`},
+		{`154.2`, `print 1 // 0`, "", false, 0, true, `division by int zero`},
+		{`154.3`, `print 1d % 0.0d`, "", false, 0, true, `modulo by decimal zero`},
+		{`154.4`, `print 0d ** -1`, "", false, 0, true, `division by decimal zero`},
+		{`154.5`, `print 1 << -1`, "", false, 0, true, `negative shift count: -1`},
+		{`154.6`, `print 1.5 & 1`, "", false, 0, true, `BAND: invalid types: float, int`},
+		{`154.7`, `print 1 | true`, "", false, 0, true, `BOR: invalid types: int, bool`},
+		{`154.8`, `print "a" % 1`, "", false, 0, true, `MOD: invalid types: string, int`},
+		{`154.9`, `print 2d ** 1.5`, "", false, 0, true, `POW: invalid types: decimal, float`},
+		{`154.10`, `print 1 ^ 1d`, "", false, 0, true, `BXOR: invalid types: int, decimal`},
+		{`154.11`, `print 1 ** `, "", false, 0, true, `at end: expected expression`},
+		{`155.1`, `print upper("abc") + lower("DEF")`, "ABCdef", false, 0, false, ""},
+		{`155.2`, `print trim(" a b \t") + trim("--c--", "-")`, "a bc", false, 0, false, ""},
+		{`155.3`, `print split("a,b,,c", ",")`, "[\"a\", \"b\", \"\", \"c\"]", false, 0, false, ""},
+		{`155.4`, `print split("", ",")`, "[]", false, 0, false, ""},
+		{`155.5`, `print join(split("a b c", " "), ", ")`, "a, b, c", false, 0, false, ""},
+		{`155.6`, `print replace("a.b.c", ".", "::")`, "a::b::c", false, 0, false, ""},
+		{`155.7`, `print contains("hello", "ell") and has_prefix("hello", "he") and has_suffix("hello", "lo")`, "true", false, 0, false, ""},
+		{`155.8`, `print format("%s:%d", "host", 8080)`, "host:8080", false, 0, false, ""},
+		{`155.9`, `print format("%v %s %d", 1.5d, 5m, "x")`, "1.5 5m0s %!d(string=x)", false, 0, false, ""},
+		{`155.10`, `print len("żółw") + len(split("a b", " ")) * 10`, "24", false, 0, false, ""},
+		{`155.11`, `print substr("hello", 1, 3) + "|" + substr("hello", -3) + "|" + substr("hello", 3, 1) + "|" + substr("hello", 2, 99)`, "el|llo||llo", false, 0, false, ""},
+		{`155.12`, fmt.Sprintf(`print regex_match("v1.2.3", %[1]c^v\d+\.\d+\.\d+$%[1]c)`, '`'), "true", false, 0, false, ""},
+		{`155.12.1`, fmt.Sprintf(`for s in ["a1", "b", "a22"] {print regex_match(s, %[1]c^a\d+$%[1]c)}`, '`'), "true\nfalse\ntrue", false, 0, false, ""},
+		{`155.13`, fmt.Sprintf(`print regex_replace("2024-01-02", %[1]c(\d+)-(\d+)-(\d+)%[1]c, "$3/$2/$1")`, '`'), "02/01/2024", false, 0, false, ""},
+		{`155.14`, `print upper(nil) + "|" + join(nil, ",") + "|" + len(nil)`, "||0", false, 0, false, ""},
+		{`155.15`, `print split("a,b", ",") == split("a,b", ",")`, "true", false, 0, false, ""},
+		{`155.16`, `print split("a,b", ",") == "a,b"`, "false", false, 0, false, ""},
+		{`155.17`, `print "${split("a,b", ",")}"`, "[\"a\", \"b\"]", false, 0, false, ""},
+		{`155.18`, `def b { upper = 1; lower = upper + 1 }; print b.lower`, "2", false, 0, false, ""},
+		{`155.19`, `eval upper("a")`, "== /dev/stdin ==\n0000   1:15  CONST         0 'a'\n0002   1:16  CALL          1 'upper'\t   1#\n0005      |  POP\n0006      |  RET", true, 0, false, ""},
+		{`155.20`, `print not split("", ",")`, "true", false, 0, false, ""},
+		{`156.1`, `print foo(1)`, "", false, 0, true, `line 1:10: error at 'foo': unknown function 'foo'`},
+		{`156.2`, `print upper()`, "", false, 0, true, `upper() expects 1 argument, got 0`},
+		{`156.3`, `print substr("a", 1, 2, 3)`, "", false, 0, true, `substr() expects 2 to 3 arguments, got 4`},
+		{`156.4`, `print format()`, "", false, 0, true, `format() expects at least 1 argument, got 0`},
+		{`156.5`, `print upper(1)`, "", false, 0, true, `line 1:15: upper: argument 1: invalid type: int, expected string`},
+		{`156.6`, `print substr("a", "b")`, "", false, 0, true, `substr: argument 2: invalid type: string, expected int`},
+		{`156.7`, `print join("a", ",")`, "", false, 0, true, `join: argument 1: invalid type: string, expected list`},
+		{`156.8`, `print len(1)`, "", false, 0, true, `len: argument 1: invalid type: int, expected string, list or map`},
+		{`156.9`, `print regex_match("a", "(")`, "", false, 0, true, fmt.Sprintf(`regex_match: error parsing regexp: missing closing ): %[1]c(%[1]c`, '`')},
+		{`156.9.1`, `print regex_match("a", "a"); print regex_replace("a", "(", "")`, "", false, 0, true, fmt.Sprintf(`regex_replace: error parsing regexp: missing closing ): %[1]c(%[1]c`, '`')},
+		{`156.10`, `print upper("a"`, "", false, 0, true, `at end: expected ')' after arguments`},
+		{`157.1`, `print [1, "a", nil, [2.5], {}]`, "[1, \"a\", nil, [2.5], {}]", false, 0, false, ""},
+		{`157.2`, `print {b: 1, "a-1": [1], c: {d: nil},}`, "{\"a-1\": [1], \"b\": 1, \"c\": {\"d\": nil}}", false, 0, false, ""},
+		{`157.3`, `var x = [
+  1,
+  2,
+]
+print x`, "[1, 2]", false, 0, false, ""},
+		{`157.4`, `print [1, 2] == [1.0, 2d] and {a: 1} == {a: 1.0} and [] != {}`, "true", false, 0, false, ""},
+		{`157.5`, `print [1, [2]] == [1, [3]] or {a: 1} == {b: 1}`, "false", false, 0, false, ""},
+		{`157.6`, `print "${[1]}${{a: "x"}}"`, "[1]{\"a\": \"x\"}", false, 0, false, ""},
+		{`157.7`, `print not [] and not {}`, "true", false, 0, false, ""},
+		{`157.8`, `eval [1, {a: 2}]`, "== /dev/stdin ==\n0000    1:8  ONE\n0001   1:12  CONST         0 'a'\n0003   1:15  CONST         1 '2'\n0005   1:16  MAP           1\n0007   1:17  LIST          2\n0009      |  POP\n0010      |  RET", true, 0, false, ""},
+		{`157.9`, `print "${min(3, 1.5, 2)} ${max([1, 2d, 1.5])}"`, "1.5 2", false, 0, false, ""},
+		{`157.10`, `print "${min(5)}${max(["a", "b"])}"`, "5b", false, 0, false, ""},
+		{`157.11`, `print "${max(1m, 90s)}|${min(2024-01-02T00:00:00Z, 2023-01-02T00:00:00Z)}"`, "1m30s|2023-01-02T00:00:00Z", false, 0, false, ""},
+		{`157.12`, `print abs(-3) + abs(-1.5) + abs(2)`, "6.5", false, 0, false, ""},
+		{`157.13`, `print "${abs(-1.25d)} ${abs(-5s)}"`, "1.25 5s", false, 0, false, ""},
+		{`157.14`, `print floor(-1.5) + ceil(1.2) * 10 + round(2.5) * 100`, "318", false, 0, false, ""},
+		{`157.15`, `print round(-2.5d) + floor(1.99d) * 10 + ceil(-0.5d) * 100 + round(7)`, "14", false, 0, false, ""},
+		{`157.16`, `print clamp(15, 1, 10) + clamp(-5, 1, 10) * 100 + clamp(0.5, 0, 1) * 1000`, "610", false, 0, false, ""},
+		{`157.17`, `print keys({b: 1}) + values({b: 1})`, "", false, 0, true, `ADD: invalid types: list, list`},
+		{`157.18`, `print "${keys({b: 1, a: 2})} ${values({b: 1, a: 2})} ${keys(nil)}"`, "[\"a\", \"b\"] [2, 1] []", false, 0, false, ""},
+		{`157.19`, `print sort([3, 1.5, 2, 1d])`, "[1, 1.5, 2, 3]", false, 0, false, ""},
+		{`157.20`, `print sort(split("c,a,b", ","))`, "[\"a\", \"b\", \"c\"]", false, 0, false, ""},
+		{`157.21`, `print unique([1, 1.0, 2, "a", "a", nil, nil])`, "[1, 2, \"a\", nil]", false, 0, false, ""},
+		{`157.22`, `print sum([1, 2.5, 3]) + sum([])`, "6.5", false, 0, false, ""},
+		{`157.23`, `print sum([1m, 30s])`, "1m30s", false, 0, false, ""},
+		{`157.24`, `print "${range(3)} ${range(1, 10, 3)} ${range(5, 0, -2)} ${range(3, 1)}"`, "[0, 1, 2] [1, 4, 7] [5, 3, 1] []", false, 0, false, ""},
+		{`157.25`, `print contains([1, 2], 2.0) and contains({a: 1}, "a") and not contains({a: 1}, "b") and contains("abc", "b")`, "true", false, 0, false, ""},
+		{`157.26`, `print len({a: 1, b: 2}) + len([1])`, "3", false, 0, false, ""},
+		{`157.27`, `def limits { cpu = 2; replicas = max(2, ceil(450 / 100.0)) }; print limits.replicas`, "5", false, 0, false, ""},
+		{`157.28`, `var m = {"a": 1}; print m.a`, "1", false, 0, false, ""},
+		{`157.29`, `var m = {a: {"b-c": [1, 2]}}; print m.a."b-c"; print get(m.a."b-c", 1)`, "[1, 2]\n2", false, 0, false, ""},
+		{`157.30`, `var m = {a: 1}; print m?.b; print m?.b.c; print get(m, "b", 0) + get([], 5, 2)`, "<nil>\n<nil>\n2", false, 0, false, ""},
+		{`157.31`, `def t {x = 1}; def u {var t = {x: 2}; print t.x}`, "2", false, 0, false, ""},
+		{`158.1`, `print [1, 2`, "", false, 0, true, `at end: expected ']' after list elements`},
+		{`158.2`, `print {a: 1, a: 2}`, "", false, 0, true, `line 1:15: error at 'a': duplicate map key`},
+		{`158.3`, `print {1: 2}`, "", false, 0, true, `error at '1': expected map key`},
+		{`158.4`, `print {a 1}`, "", false, 0, true, `expected ':' after map key`},
+		{`158.5`, `print min([])`, "", false, 0, true, `min: empty list`},
+		{`158.6`, `print max(1, "a")`, "", false, 0, true, `max: cannot compare string and int`},
+		{`158.7`, `print sort([1, "a"])`, "", false, 0, true, `sort: cannot compare string and int`},
+		{`158.8`, `print clamp(1, 10, 1)`, "", false, 0, true, `clamp: empty range: 10 > 1`},
+		{`158.9`, `print sum([1, "a"])`, "", false, 0, true, `sum: element 1: invalid type: string`},
+		{`158.10`, `print range(1, 2, 0)`, "", false, 0, true, `range: zero step`},
+		{`158.11`, `print range(1 << 30)`, "", false, 0, true, `range: too many elements: 1073741824`},
+		{`158.12`, `print floor(1e300)`, "", false, 0, true, `floor: out of range: 1e+300`},
+		{`158.13`, `print abs("a")`, "", false, 0, true, `abs: argument 1: invalid type: string, expected number or duration`},
+		{`158.14`, `print keys([1])`, "", false, 0, true, `keys: argument 1: invalid type: list, expected map`},
+		{`158.15`, `print contains(1, 1)`, "", false, 0, true, `contains: argument 1: invalid type: int, expected string, list or map`},
+		{`158.16`, `var m = {a: 1}; print m.b`, "", false, 0, true, `get: no key "b"`},
+		{`158.17`, `print get([1], -1)`, "", false, 0, true, `get: index out of range: -1`},
+		{`158.18`, `var s = "x"; print s.a`, "", false, 0, true, `get: argument 1: invalid type: string, expected map or list`},
+		{`158.19`, `var m = {}; print m.1`, "", false, 0, true, `expected key after '.'`},
+		{`159.1`, `var port = 0; print port ?? 8080`, "0", false, 0, false, ""},
+		{`159.2`, `var port = 0; print port or 8080`, "8080", false, 0, false, ""},
+		{`159.3`, `print nil ?? nil ?? "" ?? 3`, "", false, 0, false, ""},
+		{`159.4`, `print 1 ?? 2 + 3`, "1", false, 0, false, ""},
+		{`159.5`, `print nil ?? 2 + 3`, "5", false, 0, false, ""},
+		{`159.6`, `print nil or nil ?? false`, "false", false, 0, false, ""},
+		{`159.7`, `def b { x = field?.y ?? 5; y = 1; z = field?.y ?? 5 }; print b.x + b.z`, "6", false, 0, false, ""},
+		{`159.8`, `def b { prefix = "" }; def c { p = b?.prefix ?? "default" }; print "[${c.p}]"`, "[]", false, 0, false, ""},
+		{`159.9`, `def t "prod" { host = "h" }; print t?.prod.host + (t?.dev.host ?? "-")`, "h-", false, 0, false, ""},
+		{`159.10`, `print nope?.x ?? "none"`, "none", false, 0, false, ""},
+		{`159.11`, `eval nil ?? 1`, "== /dev/stdin ==\n0000    1:9  NIL\n0001   1:12  JNOTNIL       2 -> 0006\n0004      |  POP\n0005   1:14  ONE\n0006      |  POP\n0007      |  RET", true, 0, false, ""},
+		{`159.12`, `def b { eval field?.x; eval t?.u }`, "== /dev/stdin ==\n0000    1:8  DEFBLOCK      0 'b'\t   1 ''\n0003   1:22  TRYFIELD      2 'x'\n0005      |  POP\n0006   1:33  TRYPATH       2#\t   3 't'\t   4 'u'\n0010      |  POP\n0011   1:35  ENDBLOCK\n0012      |  RET", true, 0, false, ""},
+		{`160.1`, `def b { field?.x = 1 }`, "", false, 0, true, `line 1:19: error at '=': invalid assignment target`},
+		{`160.2`, `print 1 ? 2`, "", false, 0, true, `expected char '?' to start token "??"`},
+		{`160.3`, `def t "p" { def o {} }; print t?.p.o`, "", false, 0, true, `path 't.p.o' refers to a block, expected field`},
+		{`160.4`, `print field?.x`, "", false, 0, true, `field reference outside of a block`},
+		{`160.5`, `def b { print y }`, "", false, 0, true, `identifier 'y' not resolved as var or field`},
+		{`161.1`, `const domain = "acme.com"; def b { host = "www." + domain }; print b.host`, "www.acme.com", false, 0, false, ""},
+		{`161.2`, `const a = 1; const b = a; var c = b + 1; print c`, "2", false, 0, false, ""},
+		{`161.3`, `const n = 2 * 3; def b { x = n }; print b.x`, "6", false, 0, false, ""},
+		{`161.4`, `const a = 1; def b { var a = 2; a = 3; print a }`, "3", false, 0, false, ""},
+		{`161.5`, `const a = 1; def b { field.a = 2; print field.a + a }`, "3", false, 0, false, ""},
+		{`161.6`, `const d = "x"
+def b { h = d }`, "== /dev/stdin ==\n0000   1:14  CONST         0 'x'\n0002    2:8  DEFBLOCK      1 'b'\t   2 ''\n0005   2:14  CONST         0 'x'\n0007      |  SETFIELD      3 'h'\n0009      |  POP\n0010   2:16  ENDBLOCK\n0011      |  POP\n0012      |  RET", true, 0, false, ""},
+		{`161.7`, `const n = 1 + 1; eval n`, "== /dev/stdin ==\n0000   1:12  ONE\n0001   1:16  ONE\n0002      |  ADD\n0003   1:24  GETLOCAL      0\n0005      |  POP\n0006      |  POP\n0007      |  RET", true, 0, false, ""},
+		{`162.1`, `const a = 1; eval a = 2`, "", false, 0, true, `line 1:20: error at 'a': cannot assign to a constant`},
+		{`162.2`, `const a = 1; def b { a = 2 }`, "", false, 0, true, `error at 'a': cannot assign to a constant`},
+		{`162.3`, `const a = 1; def b { var.a = 2 }`, "", false, 0, true, `error at 'a': cannot assign to a constant`},
+		{`162.4`, `const a = 1; print a = 2`, "", false, 0, true, `error at 'a': cannot assign to a constant`},
+		{`162.5`, `const a = 1; var a = 2`, "", false, 0, true, `variable with this name already present in this scope`},
+		{`162.6`, `const a`, "", false, 0, true, `at end: expected '=' after constant name`},
+		{`162.7`, `const 1 = 1`, "", false, 0, true, `error at '1': expected constant name`},
+		{`163.1`, `print 1 + 2 * 3`, "== /dev/stdin ==\n0000   1:16  CONST         0 '7'\n0002      |  PRINT\n0003      |  RET\n7", true, 2, false, ""},
+		{`163.2`, `print "a" + "b" * 2`, "== /dev/stdin ==\n0000   1:20  CONST         0 'abb'\n0002      |  PRINT\n0003      |  RET\nabb", true, 2, false, ""},
+		{`163.3`, `var a = 1; print a != 2; print a <= 2; print a >= 2`, "== /dev/stdin ==\n0000   1:10  ONE\n0001   1:19  GETLOCAL      0\n0003   1:24  CONST         0 '2'\n0005      |  NE\n0006      |  PRINT\n0007   1:33  GETLOCAL      0\n0009   1:38  CONST         1 '2'\n0011      |  LE\n0012      |  PRINT\n0013   1:47  GETLOCAL      0\n0015   1:52  CONST         2 '2'\n0017      |  GE\n0018      |  PRINT\n0019      |  POP\n0020      |  RET\ntrue\ntrue\nfalse", true, 1, false, ""},
+		{`163.4`, `print if true then "y" else "n"`, "== /dev/stdin ==\n0000   1:23  CONST         0 'y'\n0002   1:32  PRINT\n0003      |  RET\ny", true, 2, false, ""},
+		{`163.5`, `print nil ?? "d"`, "== /dev/stdin ==\n0000   1:17  CONST         0 'd'\n0002      |  PRINT\n0003      |  RET\nd", true, 2, false, ""},
+		{`163.6`, `print "v${1 + 1}"`, "== /dev/stdin ==\n0000   1:16  CONST         0 'v2'\n0002   1:18  PRINT\n0003      |  RET\nv2", true, 2, false, ""},
+		{`163.7`, `var a = 1; print a and 2 + 2`, "== /dev/stdin ==\n0000   1:10  ONE\n0001   1:19  GETLOCAL      0\n0003   1:23  JFALSE        3 -> 0009\n0006      |  POP\n0007   1:29  CONST         0 '4'\n0009      |  PRINT\n0010      |  POP\n0011      |  RET\n4", true, 2, false, ""},
+		{`163.8`, `print len("x" * 2000)`, "2000", false, 2, false, ""},
+		{`163.9`, `print false and 1 / 0`, "false", false, 2, false, ""},
+		{`163.10`, `print 1.50d + 0d; print 1.5d + 0d; print 1.5d`, "1.50\n1.5\n1.5", false, 2, false, ""},
+		{`163.11`, `print 0.0 * -1; print 0.0`, "-0\n0", false, 2, false, ""},
+		{`163.10`, `var a = 2; print not a > 1; print not a < 1`, "false\ntrue", false, 1, false, ""},
+		{`164.1`, `print 1 / 0`, "", false, 2, true, `line 1:12: division by int zero`},
+		{`164.2`, `print "a" - 1`, "", false, 2, true, `line 1:14: SUB: invalid types: string, int`},
+		{`165.1`, `if true { const a = 1 }; var b = 2; eval b = 3; print b`, "3", false, 0, false, ""},
+		{`165.2`, `def a { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { def b { x = 1 }}}}}}}}}}}}}}}}}}} }; print a.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.b.x`, "1", false, 0, false, ""},
+		{`165.3`, `print "x" * -1`, "", false, 0, true, `line 1:15: negative repeat count: -1`},
+		{`165.4`, `print "ab" * 9223372036854775807`, "", false, 0, true, `string too long, max 67108864 bytes`},
+		{`165.5`, `print 10d ** 100000`, "", false, 0, true, `line 1:20: decimal exponent out of range: 100000`},
+		{`165.6`, `print 9223372036854775808`, "", false, 0, true, `line 1:26: error at '9223372036854775808': invalid int literal: value out of range`},
+		{`165.7`, `print 1e400`, "", false, 0, true, `error at '1e400': invalid float literal: value out of range`},
+		{`165.8`, `var s = "x" * (1 << 25); print join([s, s, s], "")`, "", false, 0, true, `join: string too long, max 67108864 bytes`},
+		{`165.9`, `var s = "x" * (1 << 25); print replace(s, "x", "xyz")`, "", false, 0, true, `replace: string too long, max 67108864 bytes`},
+		{`165.10`, `print format("%999999d" * 100, 1)`, "", false, 0, true, `format: string too long, max 67108864 bytes`},
+		{`165.11`, `var s = "x" * (1 << 20); print len(join([s, s], "-") + replace(s, "x", "yy") + format("%s%9d", s, 1))`, "5242890", false, 0, false, ""},
+		{`122.1-64`, `print  9223372036854775807-1`, "9223372036854775806", false, 0, false, ""},
+		{`122.2-64`, `print -9223372036854775807+1`, "-9223372036854775806", false, 0, false, ""},
 	}
 
 	for _, tc := range tab {
 		tc := tc
-		t.Run(tc.name, func(t *testing.T) {
-			inp := stdinBuf{strings.NewReader(tc.input)}
-			out := new(bytes.Buffer)
-			log := new(bytes.Buffer)
+		levels := []int{tc.optimize}
+		if !tc.disasm && tc.optimize == 0 {
+			// the optimized code should behave the same
+			levels = append(levels, 2)
+		}
 
-			_, _, err := bcl.InterpretFile(
-				inp,
-				bcl.OptDisasm(tc.disasm),
-				bcl.OptOutput(out), bcl.OptLogger(log),
-			)
+		for _, level := range levels {
+			name := tc.name
+			if level != tc.optimize {
+				name += fmt.Sprintf("-O%d", level)
+			}
+			level := level
+			t.Run(name, func(t *testing.T) {
+				inp := stdinBuf{strings.NewReader(tc.input)}
+				out := new(bytes.Buffer)
+				log := new(bytes.Buffer)
 
-			switch {
-			case err != nil && !tc.errWanted:
-				t.Errorf("unexpected error: %s", relevantError(err, log))
+				_, _, err := bcl.InterpretFile(
+					inp,
+					bcl.OptDisasm(tc.disasm), bcl.OptOptimize(level),
+					bcl.OptOutput(out), bcl.OptLogger(log),
+				)
 
-			case err != nil && tc.errWanted:
-				rerr := relevantError(err, log)
-				if !strings.Contains(rerr, tc.errMatch) {
-					t.Errorf("error mismatch\nhave: %s\nwant matching: %s",
-						rerr, tc.errMatch,
-					)
-				}
+				switch {
+				case err != nil && !tc.errWanted:
+					t.Errorf("unexpected error: %s", relevantError(err, log))
+
+				case err != nil && tc.errWanted:
+					rerr := relevantError(err, log)
+					if !strings.Contains(rerr, tc.errMatch) {
+						t.Errorf("error mismatch\nhave: %s\nwant matching: %s",
+							rerr, tc.errMatch,
+						)
+					}
 
-			case err == nil && tc.errWanted && tc.errMatch == "":
-				t.Errorf("no error when expecting one")
+				case err == nil && tc.errWanted && tc.errMatch == "":
+					t.Errorf("no error when expecting one")
 
-			case err == nil && tc.errWanted && tc.errMatch != "":
-				t.Errorf("no error when expecting one matching: %s", tc.errMatch)
+				case err == nil && tc.errWanted && tc.errMatch != "":
+					t.Errorf("no error when expecting one matching: %s", tc.errMatch)
 
-			case err == nil && !tc.errWanted:
-				s := strings.TrimRight(out.String(), "\n")
-				if s != tc.output {
-					t.Errorf("mismatch:\nhave: %s\nwant: %s", s, tc.output)
+				case err == nil && !tc.errWanted:
+					s := strings.TrimRight(out.String(), "\n")
+					if s != tc.output {
+						t.Errorf("mismatch:\nhave: %s\nwant: %s", s, tc.output)
+					}
 				}
-			}
-		})
+			})
+		}
 	}
 }
 
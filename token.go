@@ -18,14 +18,27 @@ const (
 
 	tINT
 	tFLOAT
+	tDECIMAL
+	tDURATION
+	tTIME
 	tSTR
+	tHEREDOC
 	tIDENT
 
+	tINTERP    // string fragment followed by an interpolated expr
+	tINTERPEND // string fragment ending the interpolated string
+
 	tVAR
+	tCONST
 	tDEF
 	tEVAL
 	tPRINT
 	tBIND
+	tFOR
+	tIN
+	tIF
+	tTHEN
+	tELSE
 	tTRUE
 	tFALSE
 	tNIL
@@ -35,8 +48,8 @@ const (
 	tRCURLY
 	tLPAREN
 	tRPAREN
-	//tLBRACKET
-	//tRBRACKET
+	tLBRACKET
+	tRBRACKET
 
 	tOR
 	tAND
@@ -52,8 +65,20 @@ const (
 	tMINUS
 	tSTAR
 	tSLASH
+	tPERCENT
+	tSTARSTAR
+	tSLASHSLASH
+	tAMP
+	tPIPE
+	tCARET
+	tSHL
+	tSHR
+	tQUESTQUEST
 
 	tCOLON
+	tDOT
+	tQUESTDOT
+	tAT
 
 	tSEMICOLON
 	tCOMMA
@@ -13,43 +13,69 @@ func _() {
 	_ = x[tERR-2]
 	_ = x[tINT-3]
 	_ = x[tFLOAT-4]
-	_ = x[tSTR-5]
-	_ = x[tIDENT-6]
-	_ = x[tVAR-7]
-	_ = x[tDEF-8]
-	_ = x[tEVAL-9]
-	_ = x[tPRINT-10]
-	_ = x[tBIND-11]
-	_ = x[tTRUE-12]
-	_ = x[tFALSE-13]
-	_ = x[tNIL-14]
-	_ = x[tEQ-15]
-	_ = x[tLCURLY-16]
-	_ = x[tRCURLY-17]
-	_ = x[tLPAREN-18]
-	_ = x[tRPAREN-19]
-	_ = x[tOR-20]
-	_ = x[tAND-21]
-	_ = x[tNOT-22]
-	_ = x[tEE-23]
-	_ = x[tBE-24]
-	_ = x[tLT-25]
-	_ = x[tLE-26]
-	_ = x[tGT-27]
-	_ = x[tGE-28]
-	_ = x[tPLUS-29]
-	_ = x[tMINUS-30]
-	_ = x[tSTAR-31]
-	_ = x[tSLASH-32]
-	_ = x[tCOLON-33]
-	_ = x[tSEMICOLON-34]
-	_ = x[tCOMMA-35]
-	_ = x[tMAX-36]
+	_ = x[tDECIMAL-5]
+	_ = x[tDURATION-6]
+	_ = x[tTIME-7]
+	_ = x[tSTR-8]
+	_ = x[tHEREDOC-9]
+	_ = x[tIDENT-10]
+	_ = x[tINTERP-11]
+	_ = x[tINTERPEND-12]
+	_ = x[tVAR-13]
+	_ = x[tCONST-14]
+	_ = x[tDEF-15]
+	_ = x[tEVAL-16]
+	_ = x[tPRINT-17]
+	_ = x[tBIND-18]
+	_ = x[tFOR-19]
+	_ = x[tIN-20]
+	_ = x[tIF-21]
+	_ = x[tTHEN-22]
+	_ = x[tELSE-23]
+	_ = x[tTRUE-24]
+	_ = x[tFALSE-25]
+	_ = x[tNIL-26]
+	_ = x[tEQ-27]
+	_ = x[tLCURLY-28]
+	_ = x[tRCURLY-29]
+	_ = x[tLPAREN-30]
+	_ = x[tRPAREN-31]
+	_ = x[tLBRACKET-32]
+	_ = x[tRBRACKET-33]
+	_ = x[tOR-34]
+	_ = x[tAND-35]
+	_ = x[tNOT-36]
+	_ = x[tEE-37]
+	_ = x[tBE-38]
+	_ = x[tLT-39]
+	_ = x[tLE-40]
+	_ = x[tGT-41]
+	_ = x[tGE-42]
+	_ = x[tPLUS-43]
+	_ = x[tMINUS-44]
+	_ = x[tSTAR-45]
+	_ = x[tSLASH-46]
+	_ = x[tPERCENT-47]
+	_ = x[tSTARSTAR-48]
+	_ = x[tSLASHSLASH-49]
+	_ = x[tAMP-50]
+	_ = x[tPIPE-51]
+	_ = x[tCARET-52]
+	_ = x[tSHL-53]
+	_ = x[tSHR-54]
+	_ = x[tQUESTQUEST-55]
+	_ = x[tCOLON-56]
+	_ = x[tDOT-57]
+	_ = x[tQUESTDOT-58]
+	_ = x[tAT-59]
+	_ = x[tSEMICOLON-60]
+	_ = x[tCOMMA-61]
+	_ = x[tMAX-62]
 }
 
-const _tokenType_name = "tFAILtEOFtERRtINTtFLOATtSTRtIDENTtVARtDEFtEVALtPRINTtBINDtTRUEtFALSEtNILtEQtLCURLYtRCURLYtLPARENtRPARENtORtANDtNOTtEEtBEtLTtLEtGTtGEtPLUStMINUStSTARtSLASHtCOLONtSEMICOLONtCOMMAtMAX"
+const _tokenType_name = "tFAILtEOFtERRtINTtFLOATtDECIMALtDURATIONtTIMEtSTRtHEREDOCtIDENTtINTERPtINTERPENDtVARtCONSTtDEFtEVALtPRINTtBINDtFORtINtIFtTHENtELSEtTRUEtFALSEtNILtEQtLCURLYtRCURLYtLPARENtRPARENtLBRACKETtRBRACKETtORtANDtNOTtEEtBEtLTtLEtGTtGEtPLUStMINUStSTARtSLASHtPERCENTtSTARSTARtSLASHSLASHtAMPtPIPEtCARETtSHLtSHRtQUESTQUESTtCOLONtDOTtQUESTDOTtATtSEMICOLONtCOMMAtMAX"
 
-var _tokenType_index = [...]uint8{0, 5, 9, 13, 17, 23, 27, 33, 37, 41, 46, 52, 57, 62, 68, 72, 75, 82, 89, 96, 103, 106, 110, 114, 117, 120, 123, 126, 129, 132, 137, 143, 148, 154, 160, 170, 176, 180}
+var _tokenType_index = [...]uint16{0, 5, 9, 13, 17, 23, 31, 40, 45, 49, 57, 63, 70, 80, 84, 90, 94, 99, 105, 110, 114, 117, 120, 125, 130, 135, 141, 145, 148, 155, 162, 169, 176, 185, 194, 197, 201, 205, 208, 211, 214, 217, 220, 223, 228, 234, 239, 245, 253, 262, 273, 277, 282, 288, 292, 296, 307, 313, 317, 326, 329, 339, 345, 349}
 
 func (i tokenType) String() string {
 	if i < 0 || i >= tokenType(len(_tokenType_index)-1) {
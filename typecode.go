@@ -8,6 +8,9 @@ const (
 	typeFLOAT
 	typeSTR
 	typeBOOL
+	typeDURATION
+	typeTIME
+	typeDECIMAL
 )
 
 //go:generate stringer -type typecode -trimprefix type
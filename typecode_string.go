@@ -13,11 +13,14 @@ func _() {
 	_ = x[typeFLOAT-2]
 	_ = x[typeSTR-3]
 	_ = x[typeBOOL-4]
+	_ = x[typeDURATION-5]
+	_ = x[typeTIME-6]
+	_ = x[typeDECIMAL-7]
 }
 
-const _typecode_name = "NILINTFLOATSTRBOOL"
+const _typecode_name = "NILINTFLOATSTRBOOLDURATIONTIMEDECIMAL"
 
-var _typecode_index = [...]uint8{0, 3, 6, 11, 14, 18}
+var _typecode_index = [...]uint8{0, 3, 6, 11, 14, 18, 26, 30, 37}
 
 func (i typecode) String() string {
 	if i >= typecode(len(_typecode_index)-1) {
@@ -1,6 +1,9 @@
 package bcl
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type value any
 
@@ -14,8 +17,13 @@ func isFloat(v value) bool {
 	return ok
 }
 
+func isDecimal(v value) bool {
+	_, ok := v.(Decimal)
+	return ok
+}
+
 func isNumber(v value) bool {
-	return isInt(v) || isFloat(v)
+	return isInt(v) || isFloat(v) || isDecimal(v)
 }
 
 func isString(v value) bool {
@@ -28,6 +36,16 @@ func isBool(v value) bool {
 	return ok
 }
 
+func isDuration(v value) bool {
+	_, ok := v.(time.Duration)
+	return ok
+}
+
+func isTime(v value) bool {
+	_, ok := v.(time.Time)
+	return ok
+}
+
 func isFalsey(v value) bool {
 	switch x := v.(type) {
 	case bool:
@@ -36,8 +54,18 @@ func isFalsey(v value) bool {
 		return x == 0
 	case float64:
 		return x == 0.0
+	case Decimal:
+		return x.isZero()
 	case string:
 		return x == ""
+	case time.Duration:
+		return x == 0
+	case time.Time:
+		return x.IsZero()
+	case []value:
+		return len(x) == 0
+	case map[string]value:
+		return len(x) == 0
 	default:
 		return x == nil
 	}
@@ -45,16 +73,74 @@ func isFalsey(v value) bool {
 
 func isTruthy(v value) bool { return !isFalsey(v) }
 
+// equal is the `==` for the values not handled by the numeric, string
+// or time operations; lists and maps are compared element by element.
+func equal(a, b value) bool {
+	switch x := a.(type) {
+	case []value:
+		y, ok := b.([]value)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !elemEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]value:
+		y, ok := b.(map[string]value)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !elemEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	switch b.(type) {
+	case []value, map[string]value, Block:
+		return false
+	}
+	if _, ok := a.(Block); ok {
+		return false
+	}
+	return a == b
+}
+
+func elemEqual(a, b value) bool {
+	switch {
+	case isNumber(a) && isNumber(b):
+		return binopNumeric(opEQ, a, b) == true
+	case isDuration(a) && isDuration(b) || isTime(a) && isTime(b):
+		return binopTime(opEQ, a, b) == true
+	}
+	return equal(a, b)
+}
+
 func vtype(v value) string {
 	switch v.(type) {
 	case int:
 		return "int"
 	case float64:
 		return "float"
+	case Decimal:
+		return "decimal"
 	case string:
 		return "string"
 	case bool:
 		return "bool"
+	case time.Duration:
+		return "duration"
+	case time.Time:
+		return "time"
+	case []value:
+		return "list"
+	case map[string]value:
+		return "map"
 	default:
 		if v == nil {
 			return "nil"
@@ -0,0 +1,229 @@
+package bcl
+
+import "fmt"
+
+// verify checks the code of a loaded Prog. The VM trusts the code it runs,
+// as the parser emits only the valid one, so a corrupted or crafted dump
+// could crash it. Here the opcodes, the operands, the constants they refer to
+// and the jump targets are checked, and then each path through the code
+// is followed, checking the depths of the stack and of the blocks.
+func (p *Prog) verify() error {
+	code, err := decodeCode(p, nil)
+	if err != nil {
+		return err
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("invalid code: empty")
+	}
+
+	for _, in := range code {
+		if err := p.verifyInstr(in); err != nil {
+			return err
+		}
+	}
+	return verifyFlow(code)
+}
+
+func (p *Prog) verifyInstr(in *instr) error {
+	k := 0
+	for _, kind := range operands[in.op] {
+		switch kind {
+		case argByte, argNum:
+			k++
+		case argConst:
+			if err := p.verifyConst(in, in.args[k]); err != nil {
+				return err
+			}
+			k++
+		case argConsts:
+			n := in.args[k]
+			for _, idx := range in.args[k+1 : k+1+n] {
+				if err := p.verifyConst(in, idx); err != nil {
+					return err
+				}
+			}
+			k += 1 + n
+		}
+	}
+
+	switch in.op {
+	case opCALL:
+		name, argc := p.constants[in.args[0]].(string), in.args[1]
+		b, ok := builtins[name]
+		if !ok {
+			return verifyError(in, "unknown function '%s'", name)
+		}
+		if !b.accepts(argc) {
+			return verifyError(in, "%s() expects %s, got %d", name, b.arity(), argc)
+		}
+
+	case opGETPATH, opTRYPATH:
+		if in.args[0] < 2 {
+			return verifyError(in, "path too short: %d", in.args[0])
+		}
+
+	case opBIND:
+		if bindSelector(in.args[0]&0x0F) == bindNamedBlock && in.args[2] != 1 {
+			return verifyError(in, "expected 1 block name, got %d", in.args[2])
+		}
+	}
+	return nil
+}
+
+// verifyConst checks the constant index; the constants used other way
+// than being pushed by CONST are names, so they must be strings.
+func (p *Prog) verifyConst(in *instr, idx int) error {
+	if idx >= len(p.constants) {
+		return verifyError(in, "constant index out of range: %d", idx)
+	}
+	if _, ok := p.constants[idx].(string); !ok && in.op != opCONST {
+		return verifyError(in, "constant %d: invalid type: %s, expected string",
+			idx, vtype(p.constants[idx]),
+		)
+	}
+	return nil
+}
+
+// flowState is what is known at the start of an instruction.
+type flowState struct {
+	stack  int // values on the VM stack
+	blocks int // open blocks
+}
+
+func verifyFlow(code []*instr) error {
+	index := make(map[*instr]int, len(code))
+	for i, in := range code {
+		index[in] = i
+	}
+
+	states := make([]*flowState, len(code))
+	states[0] = &flowState{}
+	work := []int{0}
+
+	follow := func(from *instr, j int, st flowState) error {
+		switch {
+		case j == len(code):
+			return verifyError(from, "code runs past the end")
+		case states[j] == nil:
+			states[j] = &st
+			work = append(work, j)
+		case *states[j] != st:
+			return verifyError(code[j],
+				"inconsistent stack depth: %d and %d", states[j].stack, st.stack,
+			)
+		}
+		return nil
+	}
+
+	for len(work) > 0 {
+		i := work[len(work)-1]
+		work = work[:len(work)-1]
+		in, st := code[i], *states[i]
+
+		pops, pushes := stackEffect(in)
+		if st.stack < pops {
+			return verifyError(in, "stack underflow")
+		}
+
+		switch in.op {
+		case opGETLOCAL, opSETLOCAL:
+			if in.args[0] >= st.stack {
+				return verifyError(in, "local slot out of range: %d", in.args[0])
+			}
+		case opFORRANGE, opFORLIST:
+			if in.args[0] >= st.stack || in.args[1] >= st.stack {
+				return verifyError(in, "local slot out of range: %d, %d",
+					in.args[0], in.args[1],
+				)
+			}
+		case opFORSTEP:
+			if in.args[0] >= st.stack || in.args[1] >= st.stack || in.args[2] >= st.stack {
+				return verifyError(in, "local slot out of range: %d, %d, %d",
+					in.args[0], in.args[1], in.args[2],
+				)
+			}
+		case opGETFIELD, opSETFIELD, opTRYFIELD:
+			if st.blocks == 0 {
+				return verifyError(in, "field outside of a block")
+			}
+		case opDEFBLOCK, opDYNBLOCK:
+			st.blocks++
+		case opENDBLOCK:
+			if st.blocks == 0 {
+				return verifyError(in, "no block to end")
+			}
+			st.blocks--
+		case opRET:
+			if st.stack != 0 || st.blocks != 0 {
+				return verifyError(in, "stack not empty at the end: %d values, %d blocks",
+					st.stack, st.blocks,
+				)
+			}
+		}
+		st.stack += pushes - pops
+
+		var err error
+		switch in.op {
+		case opRET:
+		case opJUMP, opLOOP:
+			err = follow(in, index[in.target], st)
+		case opFORLIST:
+			// the element is pushed only when not jumping out
+			exit := st
+			exit.stack--
+			err = follow(in, index[in.target], exit)
+			if err == nil {
+				err = follow(in, i+1, st)
+			}
+		default:
+			if in.target != nil {
+				err = follow(in, index[in.target], st)
+			}
+			if err == nil {
+				err = follow(in, i+1, st)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackEffect gives how many values the instruction pops and pushes,
+// following the stack comments of the VM.
+func stackEffect(in *instr) (pops, pushes int) {
+	switch in.op {
+	case opCONST, opNIL, opZERO, opONE, opTRUE, opFALSE,
+		opGETLOCAL, opGETFIELD, opTRYFIELD, opGETPATH, opTRYPATH, opFORLIST:
+		return 0, 1
+
+	case opEQ, opLT, opGT, opNE, opLE, opGE,
+		opADD, opSUB, opMUL, opDIV, opMOD, opFLOORDIV, opPOW,
+		opBAND, opBOR, opBXOR, opSHL, opSHR:
+		return 2, 1
+
+	case opNEG, opUNPLUS, opNOT, opTOSTR, opCONV,
+		opJFALSE, opJNOTNIL, opSETLOCAL, opSETFIELD:
+		return 1, 1
+
+	case opPOP, opPRINT, opDYNBLOCK:
+		return 1, 0
+
+	case opPOPN:
+		return in.args[0], 0
+	case opCALL:
+		return in.args[1], 1
+	case opLIST:
+		return in.args[0], 1
+	case opMAP:
+		return 2 * in.args[0], 1
+	}
+	return 0, 0
+}
+
+func verifyError(in *instr, format string, a ...any) error {
+	return fmt.Errorf("invalid code at %04d: %s: %s",
+		in.offset, in.op, fmt.Sprintf(format, a...),
+	)
+}
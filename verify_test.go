@@ -0,0 +1,186 @@
+package bcl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	const (
+		CONST    = byte(opCONST)
+		ZERO     = byte(opZERO)
+		TRUE     = byte(opTRUE)
+		POP      = byte(opPOP)
+		POPN     = byte(opPOPN)
+		RET      = byte(opRET)
+		JUMP     = byte(opJUMP)
+		JFALSE   = byte(opJFALSE)
+		DEFBLOCK = byte(opDEFBLOCK)
+		ENDBLOCK = byte(opENDBLOCK)
+		GETFIELD = byte(opGETFIELD)
+		GETLOCAL = byte(opGETLOCAL)
+		CALL     = byte(opCALL)
+	)
+	var tab = []struct {
+		code      []byte
+		constants []value
+		errMatch  string
+	}{
+		{[]byte{ZERO, POP, RET}, nil, ""},
+		{[]byte{CONST, 0, POP, RET}, []value{1.5}, ""},
+		{[]byte{0xEE}, nil, "at 0000: unknown opcode 238"},
+		{[]byte{ZERO, CONST}, nil, "at 0001: CONST: truncated operand"},
+		{[]byte{JUMP, 0}, nil, "at 0000: JUMP: truncated operand"},
+		{[]byte{CONST, 1, POP, RET}, []value{1}, "at 0000: CONST: constant index out of range: 1"},
+		{[]byte{DEFBLOCK, 0, 1, ENDBLOCK, RET}, []value{1, "b"},
+			"at 0000: DEFBLOCK: constant 0: invalid type: int, expected string",
+		},
+		{[]byte{JUMP, 0, 1, CONST, 0, RET}, []value{1},
+			"at 0000: JUMP: jump to 0004, not to an instruction",
+		},
+		{[]byte{JUMP, 0, 9, RET}, nil, "at 0000: JUMP: jump to 0012, not to an instruction"},
+		{[]byte{POP, RET}, nil, "at 0000: POP: stack underflow"},
+		{[]byte{ZERO, POPN, 2, RET}, nil, "at 0001: POPN: stack underflow"},
+		{[]byte{ZERO, RET}, nil, "at 0001: RET: stack not empty at the end: 1 values, 0 blocks"},
+		{[]byte{ZERO, POP}, nil, "at 0001: POP: code runs past the end"},
+		{[]byte{TRUE, JFALSE, 0, 1, ZERO, POP, POP, RET}, nil,
+			"at 0005: POP: inconsistent stack depth: 1 and 2",
+		},
+		{[]byte{GETFIELD, 0, POP, RET}, []value{"x"}, "at 0000: GETFIELD: field outside of a block"},
+		{[]byte{ENDBLOCK, RET}, nil, "at 0000: ENDBLOCK: no block to end"},
+		{[]byte{GETLOCAL, 3, POP, RET}, nil, "at 0000: GETLOCAL: local slot out of range: 3"},
+		{[]byte{ZERO, ZERO, CALL, 0, 2, POP, RET}, []value{"upper"},
+			"at 0002: CALL: upper() expects 1 argument, got 2",
+		},
+		{[]byte{CALL, 0, 0, POP, RET}, []value{"nope"}, "at 0000: CALL: unknown function 'nope'"},
+	}
+
+	for i, tc := range tab {
+		p := &Prog{
+			code:      tc.code,
+			constants: tc.constants,
+			positions: posTableOf(make([]int, len(tc.code))),
+			linePos:   &lineCalc{},
+		}
+		err := p.verify()
+		switch {
+		case err != nil && tc.errMatch == "":
+			t.Errorf("tc#%d: unexpected error: %v", i, err)
+		case err == nil && tc.errMatch != "":
+			t.Errorf("tc#%d: no error when expecting one", i)
+		case err != nil && !strings.Contains(err.Error(), tc.errMatch):
+			t.Errorf("tc#%d: error mismatch\nhave: %s\nwant matching: %s",
+				i, err, tc.errMatch,
+			)
+		}
+	}
+
+	p := &Prog{code: []byte{RET}, positions: posTableOf([]int{0, 0})}
+	if err := p.verify(); err == nil || err.Error() != "invalid code: 2 positions for 1 bytes" {
+		t.Errorf("positions mismatch not detected, have: %v", err)
+	}
+}
+
+// TestLoadCorrupted checks that any truncation or any change of a byte
+// in the dump gives an error or a Prog which can be executed without panics.
+// The checksum is recomputed, so that the corrupted data is actually read.
+func TestLoadCorrupted(t *testing.T) {
+	if err := loadForTest(dumpForTest(t, "print 1")[:40]); err == nil {
+		t.Errorf("no error for the dump with a wrong checksum")
+	}
+
+	body := dumpBody(dumpForTest(t, `
+		var x = 2
+		def a "b" {
+			y = x * 3 + len("abc") ?? 1
+			def c { z = if y > 1 then [1, 2] else {k: nil} }
+		}
+		bind a:"b"
+	`))
+
+	for n := 0; n < len(body); n++ {
+		if err := loadForTest(withChecksum(body[:n])); err == nil {
+			t.Errorf("no error for the dump truncated at %d", n)
+		}
+	}
+
+	b := make([]byte, len(body))
+	for i := range body {
+		for _, x := range []byte{0, 1, 0x7f, 0xf1, 0xff, body[i] ^ 0x10} {
+			copy(b, body)
+			b[i] = x
+			loadForTest(withChecksum(b))
+		}
+	}
+}
+
+func TestLoadLineFeeds(t *testing.T) {
+	body := dumpBody(dumpForTest(t, "print 1\nprint 2\nprint 3"))
+	n := len(body)
+	if !bytes.HasSuffix(body, []byte{2, 7, 15}) {
+		t.Fatalf("unexpected lfs at the end of the dump: %v", body[n-3:])
+	}
+
+	for _, lfs := range [][]byte{{15, 7}, {7, 7}} {
+		b := append(body[:n-2:n-2], lfs...)
+		err := loadForTest(withChecksum(b))
+		if want := "lfs[1]: invalid line feed offset 7"; err == nil || err.Error() != want {
+			t.Errorf("lfs %v: error mismatch\nhave: %v\nwant: %s", lfs, err, want)
+		}
+	}
+}
+
+func FuzzLoadProg(f *testing.F) {
+	for _, s := range []string{
+		`print 1`,
+		`var a = 1; def b { c = a + 1; def d {} }; bind b`,
+		`print "x${1}" + upper("y") ?? nil`,
+		`for i in range(3) { print i }`,
+	} {
+		f.Add(dumpForTest(f, s))
+	}
+
+	f.Fuzz(func(t *testing.T, dump []byte) {
+		loadForTest(withChecksum(dumpBody(dump)))
+	})
+}
+
+func dumpBody(dump []byte) []byte {
+	return dump[:max(len(dump)-sha256.Size, 0)]
+}
+
+func withChecksum(body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return append(body[:len(body):len(body)], sum[:]...)
+}
+
+func dumpForTest(t testing.TB, input string) []byte {
+	t.Helper()
+	p, err := Parse([]byte(input), "input", OptOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	if err := p.Dump(b); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// loadForTest loads the dump and executes it, with the steps limited,
+// as a loop after the corruption could run forever.
+func loadForTest(dump []byte) error {
+	p, err := LoadProg(bytes.NewReader(dump), "input",
+		OptOutput(io.Discard), OptLogger(io.Discard),
+	)
+	if err != nil {
+		return err
+	}
+	_, _, err = Execute(p, OptMaxSteps(100_000),
+		OptOutput(io.Discard), OptLogger(io.Discard),
+	)
+	return err
+}